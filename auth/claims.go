@@ -0,0 +1,36 @@
+package auth
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Claims are the validated claims of a token. Registered claims (sub, iss, aud,
+// exp, nbf, iat, jti) are embedded; Scope holds the space separated OAuth scopes.
+type Claims struct {
+	jwt.RegisteredClaims
+	Scope string `json:"scope,omitempty"`
+}
+
+// Scopes returns the token's scopes.
+func (c *Claims) Scopes() []string {
+	return strings.Fields(c.Scope)
+}
+
+// HasScope reports whether the token was granted scope.
+func (c *Claims) HasScope(scope string) bool {
+	return slices.Contains(c.Scopes(), scope)
+}
+
+// HasScopes reports whether the token was granted every one of scopes.
+func (c *Claims) HasScopes(scopes ...string) bool {
+	granted := c.Scopes()
+	for _, scope := range scopes {
+		if !slices.Contains(granted, scope) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,270 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrUnknownKey indicates a kid that is not in the key set.
+var ErrUnknownKey = errors.New("unknown signing key")
+
+const (
+	// DefaultJWKSRefresh is how long a fetched key set is used before it is fetched again.
+	DefaultJWKSRefresh = time.Hour
+	// DefaultJWKSTimeout bounds a key set fetch when JWKS.Client is not set.
+	DefaultJWKSTimeout = 10 * time.Second
+	// minJWKSRefresh limits refetches triggered by unknown key IDs.
+	minJWKSRefresh = time.Minute
+)
+
+// JWK is a JSON Web Key (RFC 7517) holding an RSA, P-256 or Ed25519 public key.
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid,omitempty"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// JWKSet is a JSON Web Key Set.
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// PublicKey decodes the key into an *rsa.PublicKey, *ecdsa.PublicKey or ed25519.PublicKey.
+func (k JWK) PublicKey() (any, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return nil, fmt.Errorf("invalid RSA exponent in key '%s'", k.Kid)
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		if k.Crv != "P-256" {
+			return nil, fmt.Errorf("unsupported curve '%s' in key '%s'", k.Crv, k.Kid)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		pub := &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}
+		if !pub.Curve.IsOnCurve(x, y) {
+			return nil, fmt.Errorf("invalid P-256 point in key '%s'", k.Kid)
+		}
+		return pub, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve '%s' in key '%s'", k.Crv, k.Kid)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid Ed25519 key '%s'", k.Kid)
+		}
+		return ed25519.PublicKey(x), nil
+	}
+	return nil, fmt.Errorf("unsupported key type '%s' in key '%s'", k.Kty, k.Kid)
+}
+
+// algorithm returns the JWT algorithm the key verifies.
+func (k JWK) algorithm() string {
+	switch k.Kty {
+	case "RSA":
+		return RS256
+	case "EC":
+		return ES256
+	case "OKP":
+		return EdDSA
+	}
+	return ""
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(b) == 0 {
+		return nil, fmt.Errorf("invalid base64url integer in JWK")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+// NewJWK encodes an RSA, P-256 or Ed25519 public key as a JWK.
+func NewJWK(kid string, pub any) (JWK, error) {
+	enc := base64.RawURLEncoding.EncodeToString
+	switch key := pub.(type) {
+	case *rsa.PublicKey:
+		return JWK{Kty: "RSA", Kid: kid, Use: "sig", Alg: RS256, N: enc(key.N.Bytes()), E: enc(big.NewInt(int64(key.E)).Bytes())}, nil
+	case *ecdsa.PublicKey:
+		if key.Curve != elliptic.P256() {
+			return JWK{}, fmt.Errorf("unsupported curve %s", key.Curve.Params().Name)
+		}
+		coord := func(v *big.Int) string { return enc(v.FillBytes(make([]byte, 32))) }
+		return JWK{Kty: "EC", Kid: kid, Use: "sig", Alg: ES256, Crv: "P-256", X: coord(key.X), Y: coord(key.Y)}, nil
+	case ed25519.PublicKey:
+		return JWK{Kty: "OKP", Kid: kid, Use: "sig", Alg: EdDSA, Crv: "Ed25519", X: enc(key)}, nil
+	}
+	return JWK{}, fmt.Errorf("unsupported public key type %T", pub)
+}
+
+// JWKS fetches and caches the key set published by an authorization server.
+// Its KeyFunc can be set on a Token to verify the server's access tokens.
+type JWKS struct {
+	URL     string
+	Client  *http.Client  // A client with DefaultJWKSTimeout if nil
+	Refresh time.Duration // DefaultJWKSRefresh if zero
+
+	mu          sync.Mutex
+	keys        map[string]JWK
+	fetchedAt   time.Time  // Last successful fetch
+	attemptedAt time.Time  // Last fetch, successful or not
+	inflight    *jwksFetch // Fetch in progress, shared by all callers
+}
+
+// jwksFetch lets concurrent callers wait for a single fetch of the key set.
+type jwksFetch struct {
+	done chan struct{}
+	err  error
+}
+
+// jwksClient bounds key set fetches when no Client is set.
+var jwksClient = &http.Client{Timeout: DefaultJWKSTimeout}
+
+// NewJWKS creates a key set fetched from url on first use.
+func NewJWKS(url string) *JWKS {
+	return &JWKS{URL: url}
+}
+
+// KeyFunc returns the key for kid, fetching the key set when it is stale or does
+// not know kid yet, so keys rotated in by the authorization server are picked up.
+// Fetches happen without holding up callers whose keys are cached, concurrent
+// callers share one fetch, and fetches are attempted at most once a minute
+// unless the cached set has aged past Refresh.
+func (j *JWKS) KeyFunc(alg, kid string) (any, error) {
+	key, err := j.lookup(kid)
+	if err != nil {
+		return nil, err
+	}
+	if key.algorithm() != alg || (key.Alg != "" && key.Alg != alg) {
+		return nil, fmt.Errorf("%w: key '%s' cannot verify %s tokens", ErrUnsupportedAlgorithm, kid, alg)
+	}
+	return key.PublicKey()
+}
+
+// lookup returns the key for kid, fetching the key set first when it is due.
+func (j *JWKS) lookup(kid string) (JWK, error) {
+	j.mu.Lock()
+	key, ok := j.keys[kid]
+	if !j.fetchDue(ok) {
+		j.mu.Unlock()
+		if !ok {
+			return JWK{}, fmt.Errorf("%w: '%s'", ErrUnknownKey, kid)
+		}
+		return key, nil
+	}
+
+	call := j.inflight
+	if call == nil {
+		call = &jwksFetch{done: make(chan struct{})}
+		j.inflight = call
+		j.attemptedAt = time.Now()
+		j.mu.Unlock()
+
+		keys, err := j.fetch(context.Background())
+		j.mu.Lock()
+		if err == nil {
+			j.keys, j.fetchedAt = keys, time.Now()
+		}
+		call.err = err
+		j.inflight = nil
+		close(call.done)
+	} else {
+		j.mu.Unlock()
+		<-call.done
+		j.mu.Lock()
+	}
+	// A stale but cached key keeps working while the authorization server is unreachable.
+	key, ok = j.keys[kid]
+	j.mu.Unlock()
+	switch {
+	case ok:
+		return key, nil
+	case call.err != nil:
+		return JWK{}, call.err
+	default:
+		return JWK{}, fmt.Errorf("%w: '%s'", ErrUnknownKey, kid)
+	}
+}
+
+// fetchDue reports whether the key set should be fetched for a key that is
+// cached (known) or not. j.mu must be held.
+func (j *JWKS) fetchDue(known bool) bool {
+	refresh := j.Refresh
+	if refresh <= 0 {
+		refresh = DefaultJWKSRefresh
+	}
+	// Cached keys are served while another caller refreshes the set.
+	if known && (j.inflight != nil || time.Since(j.fetchedAt) <= refresh) {
+		return false
+	}
+	if j.inflight != nil {
+		return true
+	}
+	// Unknown key IDs are attacker controlled; limit how often they cause a fetch.
+	return time.Since(j.attemptedAt) > minJWKSRefresh
+}
+
+func (j *JWKS) fetch(ctx context.Context) (map[string]JWK, error) {
+	client := j.Client
+	if client == nil {
+		client = jwksClient
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, j.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch JWKS: %s", resp.Status)
+	}
+
+	var set JWKSet
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return nil, fmt.Errorf("failed to decode JWKS: %w", err)
+	}
+	keys := make(map[string]JWK, len(set.Keys))
+	for _, key := range set.Keys {
+		if key.Use == "" || key.Use == "sig" {
+			keys[key.Kid] = key
+		}
+	}
+	return keys, nil
+}
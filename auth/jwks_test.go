@@ -0,0 +1,149 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+)
+
+func TestJWKRoundTrip(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	edPub, _, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+
+	for _, pub := range []crypto.PublicKey{&rsaKey.PublicKey, &ecKey.PublicKey, edPub} {
+		jwk, err := NewJWK("k", pub)
+		assert.NoError(t, err)
+		decoded, err := jwk.PublicKey()
+		assert.NoError(t, err)
+		assert.True(t, pub.(interface{ Equal(crypto.PublicKey) bool }).Equal(decoded))
+	}
+}
+
+func TestJWKSRotation(t *testing.T) {
+	var mu sync.Mutex
+	var set JWKSet
+	publish := func(kid string) *Token {
+		pub, priv, err := ed25519.GenerateKey(rand.Reader)
+		assert.NoError(t, err)
+		jwk, err := NewJWK(kid, pub)
+		assert.NoError(t, err)
+		mu.Lock()
+		set.Keys = append(set.Keys, jwk)
+		mu.Unlock()
+		return &Token{PrivateKey: priv, KeyID: kid}
+	}
+	fetches := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		fetches++
+		json.NewEncoder(w).Encode(set)
+	}))
+	defer srv.Close()
+
+	first := publish("k1")
+	jwks := NewJWKS(srv.URL)
+	verifier := &Token{KeyFunc: jwks.KeyFunc, Algorithms: []string{EdDSA}}
+
+	tokenString, err := first.Create("user")
+	assert.NoError(t, err)
+	claims, err := verifier.Verify(tokenString)
+	assert.NoError(t, err)
+	assert.Equal(t, "user", claims.Subject)
+	assert.Equal(t, 1, fetches)
+
+	// Known keys are served from the cache; an unknown kid is only refetched
+	// once the minimum refresh interval has passed.
+	_, err = verifier.Verify(tokenString)
+	assert.NoError(t, err)
+	second := publish("k2")
+	tokenString, err = second.Create("user")
+	assert.NoError(t, err)
+	_, err = verifier.Verify(tokenString)
+	assert.IsError(t, err, ErrUnknownKey)
+	assert.Equal(t, 1, fetches)
+
+	jwks.attemptedAt = jwks.attemptedAt.Add(-2 * minJWKSRefresh)
+	_, err = verifier.Verify(tokenString)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, fetches)
+}
+
+func TestJWKSConcurrentFetch(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	jwk, err := NewJWK("k1", pub)
+	assert.NoError(t, err)
+
+	var mu sync.Mutex
+	fetches := 0
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		fetches++
+		mu.Unlock()
+		<-release
+		json.NewEncoder(w).Encode(JWKSet{Keys: []JWK{jwk}})
+	}))
+	defer srv.Close()
+
+	jwks := NewJWKS(srv.URL)
+	verifier := &Token{KeyFunc: jwks.KeyFunc, Algorithms: []string{EdDSA}}
+	tokenString, err := (&Token{PrivateKey: priv, KeyID: "k1"}).Create("user")
+	assert.NoError(t, err)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := verifier.Verify(tokenString)
+			errs <- err
+		}()
+	}
+	close(release)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, 1, fetches, "concurrent callers share one fetch")
+
+	// Unknown key IDs do not trigger a fetch each.
+	for i := 0; i < 5; i++ {
+		_, err := jwks.KeyFunc(EdDSA, "forged")
+		assert.IsError(t, err, ErrUnknownKey)
+	}
+	assert.Equal(t, 1, fetches)
+}
+
+func TestJWKSFetchFailureRateLimited(t *testing.T) {
+	fetches := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	jwks := NewJWKS(srv.URL)
+	_, err := jwks.KeyFunc(EdDSA, "k1")
+	assert.Error(t, err)
+	_, err = jwks.KeyFunc(EdDSA, "k2")
+	assert.IsError(t, err, ErrUnknownKey)
+	assert.Equal(t, 1, fetches)
+}
@@ -1,18 +1,76 @@
 package auth
 
 import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
 	"time"
 
-	"github.com/dgrijalva/jwt-go" // TODO: replace -- this has a security problem
+	"github.com/gomcp/keys"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// supported signing algorithms
+const (
+	HS256 = "HS256"
+	RS256 = "RS256"
+	ES256 = "ES256"
+	EdDSA = "EdDSA"
+)
+
+// DefaultTTL is how long tokens created without an explicit TTL stay valid.
+const DefaultTTL = time.Hour
+
+var (
+	// ErrInvalidToken wraps every token verification failure.
+	ErrInvalidToken = errors.New("invalid token")
+	// ErrNoToken indicates a request without an Authorization header.
+	ErrNoToken = errors.New("no token provided")
+	// ErrUnsupportedAlgorithm indicates a signing algorithm outside the allowed set.
+	ErrUnsupportedAlgorithm = errors.New("unsupported signing algorithm")
+
+	// claim validation failures, wrapped together with ErrInvalidToken
+	ErrTokenExpired     = jwt.ErrTokenExpired
+	ErrTokenNotValidYet = jwt.ErrTokenNotValidYet
+	ErrInvalidIssuer    = jwt.ErrTokenInvalidIssuer
+	ErrInvalidAudience  = jwt.ErrTokenInvalidAudience
+	ErrMissingClaim     = jwt.ErrTokenRequiredClaimMissing
 )
 
 // json web token
 type Token struct {
-	Jwt    string `json:"-"`
-	Secret []byte `json:"-"`
+	Jwt    string        `json:"-"`
+	Secret []byte        `json:"-"`
+	Keys   keys.KeyStore `json:"-"` // when set, tokens are signed with its active key and carry a kid header
+
+	// Method is the algorithm used by Create: HS256 (default) or EdDSA with Keys,
+	// or whichever algorithm matches PrivateKey.
+	Method string `json:"-"`
+	// PrivateKey signs tokens with RS256, ES256 or EdDSA when Keys is not set.
+	PrivateKey crypto.Signer `json:"-"`
+	// KeyID is written to the kid header of tokens signed with PrivateKey.
+	KeyID string `json:"-"`
+	// KeyFunc resolves the key verifying a token with the given alg and kid,
+	// e.g. from a JWKS. It takes precedence over Secret, Keys and PrivateKey.
+	KeyFunc func(alg, kid string) (any, error) `json:"-"`
+
+	// Algorithms pins the accepted signing algorithms; all supported ones if empty.
+	Algorithms []string `json:"-"`
+	// Issuer is written to created tokens and, when set, required on verified ones.
+	Issuer string `json:"-"`
+	// Audience is written to created tokens and, when set, required on verified ones.
+	Audience string `json:"-"`
+	// Leeway is the clock skew tolerated when checking exp, nbf and iat.
+	Leeway time.Duration `json:"-"`
+	// TTL is the lifetime of created tokens; DefaultTTL if zero.
+	TTL time.Duration `json:"-"`
 }
 
 func NewT() *Token {
@@ -21,74 +79,217 @@ func NewT() *Token {
 	}
 }
 
+// NewTokenWithKeys creates a Token that signs with the active symmetric key of the
+// store and verifies tokens with the key named in their kid header.
+func NewTokenWithKeys(store keys.KeyStore) *Token {
+	return &Token{Keys: store}
+}
+
+// allowedAlgorithms returns the algorithms accepted when verifying.
+func (t *Token) allowedAlgorithms() []string {
+	if len(t.Algorithms) > 0 {
+		return t.Algorithms
+	}
+	return []string{HS256, RS256, ES256, EdDSA}
+}
+
+// verificationKey resolves the key used to verify a parsed token. The key type
+// always matches the token's algorithm, so an HMAC token can never be checked
+// against a public key or vice versa.
+func (t *Token) verificationKey(token *jwt.Token) (any, error) {
+	alg := token.Method.Alg()
+	kid, _ := token.Header["kid"].(string)
+
+	if t.KeyFunc != nil {
+		return t.KeyFunc(alg, kid)
+	}
+	if t.Keys != nil {
+		if kid == "" {
+			return nil, fmt.Errorf("token has no kid header")
+		}
+		key, err := keys.Verifiable(t.Keys, kid)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case alg == HS256 && key.Algorithm == keys.AlgSymmetric:
+			return key.Material, nil
+		case alg == EdDSA && key.Algorithm == keys.AlgEd25519:
+			return key.Ed25519PublicKey()
+		}
+		return nil, fmt.Errorf("key '%s' cannot verify %s tokens", kid, alg)
+	}
+	if t.PrivateKey != nil {
+		if alg != methodFor(t.PrivateKey) {
+			return nil, fmt.Errorf("%w: %s", ErrUnsupportedAlgorithm, alg)
+		}
+		return t.PrivateKey.Public(), nil
+	}
+	if alg != HS256 || len(t.Secret) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedAlgorithm, alg)
+	}
+	return t.Secret, nil
+}
+
 // retrieve jwt token from request
 func (t *Token) Extract(rawReqToken string) (string, error) {
 	splitToken := strings.Split(rawReqToken, " ")
-	if len(splitToken) != 2 { // bearer token not in proper format
+	if len(splitToken) != 2 || !strings.EqualFold(splitToken[0], "Bearer") { // bearer token not in proper format
 		return "", fmt.Errorf("invalid token format")
 	}
 	reqToken := strings.TrimSpace(splitToken[1])
 	return reqToken, nil
 }
 
-// verify jwt token and attempt to retrieve the request payload.
+// verify jwt token and return its claims.
 //
-// use the return value to compare against the db and whether
-// they're an actual user
-func (t *Token) Verify(tokenString string) (string, error) {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (any, error) {
-		return t.Secret, nil
-	})
-	if err != nil {
-		return "", err
+// the algorithm must be one of the allowed ones, exp and iat are required,
+// nbf is checked when present, and iss and aud must match when configured.
+// use the subject to compare against the db and whether they're an actual user
+func (t *Token) Verify(tokenString string) (*Claims, error) {
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods(t.allowedAlgorithms()),
+		jwt.WithLeeway(t.Leeway),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+	}
+	if t.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(t.Issuer))
 	}
-	if !token.Valid {
-		return "", fmt.Errorf("invalid token")
+	if t.Audience != "" {
+		opts = append(opts, jwt.WithAudience(t.Audience))
 	}
-	// retrieve claims
-	claims, ok := token.Claims.(jwt.MapClaims)
-	if !ok {
-		return "", fmt.Errorf("failed to parse jwt claims")
+
+	claims := &Claims{}
+	if _, err := jwt.ParseWithClaims(tokenString, claims, t.verificationKey, opts...); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidToken, err)
 	}
-	// retrieve the payload as a string
-	data := claims["sub"].(string)
-	if data == "" {
-		return "", fmt.Errorf("no payload found in token claims")
+	// jwt.WithIssuedAt only rejects an iat in the future; it does not require one.
+	if claims.IssuedAt == nil {
+		return nil, fmt.Errorf("%w: %w: iat", ErrInvalidToken, ErrMissingClaim)
 	}
-	return data, nil
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("%w: %w: sub", ErrInvalidToken, ErrMissingClaim)
+	}
+	return claims, nil
 }
 
 // validate a request token from a given http request
-func (t *Token) Validate(r *http.Request) (string, error) {
+func (t *Token) Validate(r *http.Request) (*Claims, error) {
 	rawToken := r.Header.Get("Authorization")
 	if rawToken == "" {
-		return "", fmt.Errorf("no token provided")
+		return nil, ErrNoToken
 	}
 	token, err := t.Extract(rawToken)
 	if err != nil {
-		return "", fmt.Errorf("failed to extract token: %v", err)
+		return nil, fmt.Errorf("failed to extract token: %w", err)
+	}
+	return t.Verify(token)
+}
+
+// create a new token using a given payload string as its subject
+func (t *Token) Create(payload string, scopes ...string) (string, error) {
+	claims := &Claims{Scope: strings.Join(scopes, " ")}
+	claims.Subject = payload
+	return t.CreateWithClaims(claims)
+}
+
+// CreateWithClaims signs claims, filling in iat, exp, jti, iss and aud when they
+// are unset.
+func (t *Token) CreateWithClaims(claims *Claims) (string, error) {
+	now := time.Now()
+	ttl := t.TTL
+	if ttl <= 0 {
+		ttl = DefaultTTL
 	}
-	itemInfo, err := t.Verify(token)
+	if claims.IssuedAt == nil {
+		claims.IssuedAt = jwt.NewNumericDate(now)
+	}
+	if claims.ExpiresAt == nil {
+		claims.ExpiresAt = jwt.NewNumericDate(now.Add(ttl))
+	}
+	if claims.ID == "" {
+		claims.ID = uuid.NewString()
+	}
+	if claims.Issuer == "" {
+		claims.Issuer = t.Issuer
+	}
+	if len(claims.Audience) == 0 && t.Audience != "" {
+		claims.Audience = jwt.ClaimStrings{t.Audience}
+	}
+
+	method, key, kid, err := t.signingKey()
 	if err != nil {
 		return "", err
 	}
-	return itemInfo, nil
-}
-
-// create a new token using a given payload string
-func (t *Token) Create(payload string) (string, error) {
-	token := jwt.New(jwt.SigningMethodHS256)
-	claims := token.Claims.(jwt.MapClaims)
-	// add the payload to the claims. payloads usually have
-	// the data associated with the request
-	claims["sub"] = payload
-	// TODO: token expires in 1 hour by default.
-	// expiration times should vary depending on the request.
-	claims["exp"] = time.Now().Add(time.Hour).UTC()
-	tokenString, err := token.SignedString(t.Secret)
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	tokenString, err := token.SignedString(key)
 	if err != nil {
 		return "", err
 	}
 	t.Jwt = tokenString
 	return tokenString, nil
 }
+
+// signingKey resolves the method, key and key ID used by CreateWithClaims.
+func (t *Token) signingKey() (jwt.SigningMethod, any, string, error) {
+	alg := t.Method
+	if alg == "" {
+		alg = HS256
+		if t.PrivateKey != nil && t.Keys == nil {
+			alg = methodFor(t.PrivateKey)
+		}
+	}
+	method := jwt.GetSigningMethod(alg)
+	if method == nil {
+		return nil, nil, "", fmt.Errorf("%w: %s", ErrUnsupportedAlgorithm, alg)
+	}
+
+	switch {
+	case t.Keys != nil && alg == HS256:
+		key, err := t.Keys.Active(keys.AlgSymmetric)
+		if err != nil {
+			return nil, nil, "", err
+		}
+		return method, key.Material, key.ID, nil
+	case t.Keys != nil && alg == EdDSA:
+		key, err := t.Keys.Active(keys.AlgEd25519)
+		if err != nil {
+			return nil, nil, "", err
+		}
+		priv, err := key.Ed25519PrivateKey()
+		if err != nil {
+			return nil, nil, "", err
+		}
+		return method, priv, key.ID, nil
+	case t.Keys != nil:
+		return nil, nil, "", fmt.Errorf("%w: %s with a keystore", ErrUnsupportedAlgorithm, alg)
+	case t.PrivateKey != nil:
+		if alg != methodFor(t.PrivateKey) {
+			return nil, nil, "", fmt.Errorf("%w: %s with a %T key", ErrUnsupportedAlgorithm, alg, t.PrivateKey)
+		}
+		return method, t.PrivateKey, t.KeyID, nil
+	case alg == HS256:
+		if len(t.Secret) == 0 {
+			return nil, nil, "", fmt.Errorf("no secret to sign the token with")
+		}
+		return method, t.Secret, "", nil
+	}
+	return nil, nil, "", fmt.Errorf("%w: %s without a private key", ErrUnsupportedAlgorithm, alg)
+}
+
+// methodFor returns the algorithm matching a private key's type.
+func methodFor(key crypto.Signer) string {
+	switch key.(type) {
+	case *rsa.PrivateKey:
+		return RS256
+	case *ecdsa.PrivateKey:
+		return ES256
+	case ed25519.PrivateKey:
+		return EdDSA
+	}
+	return ""
+}
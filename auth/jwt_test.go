@@ -1,13 +1,24 @@
 package auth
 
 import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
 	"testing"
+	"time"
+
+	"github.com/gomcp/keys"
 
 	"github.com/alecthomas/assert/v2"
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 )
 
 func TestTokenCreation(t *testing.T) {
+	t.Setenv("GOMCP_SECRET", "test-secret")
 	tok := NewT()
 	userID := uuid.NewString()
 	tokenString, err := tok.Create(userID)
@@ -20,6 +31,7 @@ func TestTokenCreation(t *testing.T) {
 }
 
 func TestTokenVerification(t *testing.T) {
+	t.Setenv("GOMCP_SECRET", "test-secret")
 	tok := NewT()
 	userID := uuid.NewString()
 	tokenString, err := tok.Create(userID)
@@ -27,16 +39,17 @@ func TestTokenVerification(t *testing.T) {
 		t.Fatalf("create token failed: %v", err)
 	}
 
-	id, err := tok.Verify(tokenString)
+	claims, err := tok.Verify(tokenString)
 	if err != nil {
 		t.Fatalf("token validation failed: %v", err)
 	}
 
-	assert.NotEqual(t, "", id)
-	assert.Equal(t, userID, id)
+	assert.NotEqual(t, "", claims.Subject)
+	assert.Equal(t, userID, claims.Subject)
 }
 
 func TestTokenExtraction(t *testing.T) {
+	t.Setenv("GOMCP_SECRET", "test-secret")
 	tok := NewT()
 	userID := uuid.NewString()
 	rawToken, err := tok.Create(userID)
@@ -51,3 +64,181 @@ func TestTokenExtraction(t *testing.T) {
 	assert.NotContains(t, tokenString, "Bearer")
 	assert.NotEqual(t, "", tokenString)
 }
+
+func TestTokenKeyRotation(t *testing.T) {
+	store := keys.NewMemoryStore()
+	_, err := keys.Rotate(store, keys.AlgSymmetric, time.Hour)
+	assert.NoError(t, err)
+
+	tok := NewTokenWithKeys(store)
+	userID := uuid.NewString()
+	tokenString, err := tok.Create(userID)
+	assert.NoError(t, err)
+
+	// Tokens issued before a rotation verify during the grace window.
+	_, err = keys.Rotate(store, keys.AlgSymmetric, time.Hour)
+	assert.NoError(t, err)
+	claims, err := tok.Verify(tokenString)
+	assert.NoError(t, err)
+	assert.Equal(t, userID, claims.Subject)
+
+	// Tokens without a kid are refused once keys are in use.
+	legacy := &Token{Secret: []byte("secret")}
+	legacyString, err := legacy.Create(userID)
+	assert.NoError(t, err)
+	_, err = tok.Verify(legacyString)
+	assert.Error(t, err)
+}
+
+func TestTokenClaimsValidation(t *testing.T) {
+	tok := &Token{Secret: []byte("secret"), Issuer: "https://issuer.example", Audience: "https://mcp.example"}
+
+	t.Run("Scopes", func(t *testing.T) {
+		tokenString, err := tok.Create("user", "tools:read", "tools:call")
+		assert.NoError(t, err)
+		claims, err := tok.Verify(tokenString)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"tools:read", "tools:call"}, claims.Scopes())
+		assert.True(t, claims.HasScopes("tools:call", "tools:read"))
+		assert.False(t, claims.HasScope("admin"))
+		assert.Equal(t, "https://issuer.example", claims.Issuer)
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		claims := &Claims{}
+		claims.Subject = "user"
+		claims.IssuedAt = jwt.NewNumericDate(time.Now().Add(-2 * time.Hour))
+		claims.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Minute))
+		tokenString, err := tok.CreateWithClaims(claims)
+		assert.NoError(t, err)
+
+		_, err = tok.Verify(tokenString)
+		assert.IsError(t, err, ErrTokenExpired)
+		assert.IsError(t, err, ErrInvalidToken)
+
+		// Within the tolerated clock skew the token is still accepted.
+		lenient := *tok
+		lenient.Leeway = 5 * time.Minute
+		_, err = lenient.Verify(tokenString)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Not Yet Valid", func(t *testing.T) {
+		claims := &Claims{}
+		claims.Subject = "user"
+		claims.NotBefore = jwt.NewNumericDate(time.Now().Add(time.Hour))
+		tokenString, err := tok.CreateWithClaims(claims)
+		assert.NoError(t, err)
+		_, err = tok.Verify(tokenString)
+		assert.IsError(t, err, ErrTokenNotValidYet)
+	})
+
+	t.Run("Wrong Issuer And Audience", func(t *testing.T) {
+		other := &Token{Secret: []byte("secret"), Issuer: "https://evil.example", Audience: "https://other.example"}
+		tokenString, err := other.Create("user")
+		assert.NoError(t, err)
+
+		_, err = tok.Verify(tokenString)
+		assert.IsError(t, err, ErrInvalidIssuer)
+
+		sameIssuer := &Token{Secret: []byte("secret"), Issuer: tok.Issuer, Audience: "https://other.example"}
+		tokenString, err = sameIssuer.Create("user")
+		assert.NoError(t, err)
+		_, err = tok.Verify(tokenString)
+		assert.IsError(t, err, ErrInvalidAudience)
+	})
+
+	t.Run("Missing Subject", func(t *testing.T) {
+		tokenString, err := tok.CreateWithClaims(&Claims{})
+		assert.NoError(t, err)
+		_, err = tok.Verify(tokenString)
+		assert.IsError(t, err, ErrMissingClaim)
+	})
+
+	t.Run("Missing Expiry", func(t *testing.T) {
+		raw, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "user"}).SignedString(tok.Secret)
+		assert.NoError(t, err)
+		_, err = tok.Verify(raw)
+		assert.IsError(t, err, ErrMissingClaim)
+	})
+
+	t.Run("Missing Issued At", func(t *testing.T) {
+		raw, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+			"sub": "user",
+			"iss": tok.Issuer,
+			"aud": tok.Audience,
+			"exp": time.Now().Add(time.Hour).Unix(),
+		}).SignedString(tok.Secret)
+		assert.NoError(t, err)
+		_, err = tok.Verify(raw)
+		assert.IsError(t, err, ErrMissingClaim)
+	})
+}
+
+func TestTokenAlgorithms(t *testing.T) {
+	t.Run("Pinned Algorithms", func(t *testing.T) {
+		tok := &Token{Secret: []byte("secret"), Algorithms: []string{RS256}}
+		tokenString, err := tok.Create("user")
+		assert.NoError(t, err)
+		_, err = tok.Verify(tokenString)
+		assert.IsError(t, err, ErrInvalidToken)
+	})
+
+	t.Run("None Rejected", func(t *testing.T) {
+		tok := &Token{Secret: []byte("secret")}
+		raw, err := jwt.NewWithClaims(jwt.SigningMethodNone, jwt.MapClaims{
+			"sub": "user",
+			"exp": time.Now().Add(time.Hour).Unix(),
+			"iat": time.Now().Unix(),
+		}).SignedString(jwt.UnsafeAllowNoneSignatureType)
+		assert.NoError(t, err)
+		_, err = tok.Verify(raw)
+		assert.Error(t, err)
+	})
+
+	t.Run("Public Keys", func(t *testing.T) {
+		rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+		assert.NoError(t, err)
+		ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		assert.NoError(t, err)
+		_, edKey, err := ed25519.GenerateKey(rand.Reader)
+		assert.NoError(t, err)
+
+		for alg, key := range map[string]crypto.Signer{RS256: rsaKey, ES256: ecKey, EdDSA: edKey} {
+			tok := &Token{PrivateKey: key, KeyID: "k1"}
+			tokenString, err := tok.Create("user")
+			assert.NoError(t, err)
+
+			parsed, _, err := jwt.NewParser().ParseUnverified(tokenString, &Claims{})
+			assert.NoError(t, err)
+			assert.Equal(t, alg, parsed.Method.Alg())
+			assert.Equal(t, "k1", parsed.Header["kid"])
+
+			claims, err := tok.Verify(tokenString)
+			assert.NoError(t, err)
+			assert.Equal(t, "user", claims.Subject)
+
+			// An HMAC token signed with the public key bytes must not verify.
+			verifier := &Token{KeyFunc: func(alg, kid string) (any, error) { return key.Public(), nil }}
+			forged, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+				"sub": "user",
+				"exp": time.Now().Add(time.Hour).Unix(),
+			}).SignedString([]byte("public key bytes"))
+			assert.NoError(t, err)
+			_, err = verifier.Verify(forged)
+			assert.Error(t, err)
+		}
+	})
+
+	t.Run("EdDSA With Keys", func(t *testing.T) {
+		store := keys.NewMemoryStore()
+		_, err := keys.Rotate(store, keys.AlgEd25519, time.Hour)
+		assert.NoError(t, err)
+		tok := &Token{Keys: store, Method: EdDSA}
+		tokenString, err := tok.Create("user")
+		assert.NoError(t, err)
+		claims, err := tok.Verify(tokenString)
+		assert.NoError(t, err)
+		assert.Equal(t, "user", claims.Subject)
+	})
+}
@@ -0,0 +1,343 @@
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	mcpctx "github.com/gomcp/context"
+)
+
+// errNotContext indicates a file that does not decode as a context.
+var errNotContext = errors.New("not a context")
+
+// FileStore keeps each context in its own JSON file named after the context ID,
+// next to a JSON Lines file holding its history. Other files may share the
+// directory; List skips any JSON file that does not hold the context it is named
+// after. Watch only reports changes made through this store, not edits to the files.
+type FileStore struct {
+	mu       sync.RWMutex
+	dir      string
+	watchers watchers
+	closed   bool
+}
+
+// OpenFileStore opens a store in dir, creating the directory if needed.
+func OpenFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create context directory: %w", err)
+	}
+	return &FileStore{dir: dir}, nil
+}
+
+func (s *FileStore) Get(ctx context.Context, id string) (*mcpctx.Context, error) {
+	path, err := s.path(id)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		return nil, ErrClosed
+	}
+	return readContextFile(path)
+}
+
+func (s *FileStore) Put(ctx context.Context, c *mcpctx.Context) error {
+	path, err := s.path(c.ID)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return ErrClosed
+	}
+	current, err := readContextFile(path)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return err
+	}
+	stored, event, err := putState(c, current)
+	if err != nil {
+		return err
+	}
+	if err := s.commit(path, stored, event); err != nil {
+		return err
+	}
+	s.watchers.publish(Event{Type: EventPut, ID: c.ID, Context: stored, Time: event.Time})
+	return nil
+}
+
+func (s *FileStore) Apply(ctx context.Context, update mcpctx.ContextUpdate) (*mcpctx.Context, error) {
+	path, err := s.path(update.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil, ErrClosed
+	}
+	current, err := readContextFile(path)
+	if err != nil {
+		return nil, err
+	}
+	updated, event, err := applyUpdate(current, update)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.commit(path, updated, event); err != nil {
+		return nil, err
+	}
+
+	published, err := cloneContext(updated)
+	if err != nil {
+		return nil, err
+	}
+	s.watchers.publish(Event{Type: EventUpdate, ID: update.ID, Context: published, Update: event.Update, Time: event.Time})
+	return updated, nil
+}
+
+func (s *FileStore) History(ctx context.Context, id string) ([]mcpctx.ContextEvent, error) {
+	path, err := s.path(id)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		return nil, ErrClosed
+	}
+	return readHistoryFile(historyPath(path))
+}
+
+func (s *FileStore) GetVersion(ctx context.Context, id string, version int64) (*mcpctx.Context, error) {
+	events, err := s.History(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return mcpctx.Rebuild(events, version)
+}
+
+func (s *FileStore) Revert(ctx context.Context, id string, version int64, author string) (*mcpctx.Context, error) {
+	path, err := s.path(id)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil, ErrClosed
+	}
+	current, err := readContextFile(path)
+	if err != nil {
+		return nil, err
+	}
+	events, err := readHistoryFile(historyPath(path))
+	if err != nil {
+		return nil, err
+	}
+	reverted, event, err := revertState(events, current, version, author)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.commit(path, reverted, event); err != nil {
+		return nil, err
+	}
+
+	published, err := cloneContext(reverted)
+	if err != nil {
+		return nil, err
+	}
+	s.watchers.publish(Event{Type: EventRevert, ID: id, Context: published, Time: event.Time})
+	return reverted, nil
+}
+
+func (s *FileStore) List(ctx context.Context, filter Filter) ([]*mcpctx.Context, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		return nil, ErrClosed
+	}
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list contexts: %w", err)
+	}
+
+	var list []*mcpctx.Context
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		c, err := readContextFile(filepath.Join(s.dir, entry.Name()))
+		if errors.Is(err, errNotContext) || errors.Is(err, ErrNotFound) {
+			continue // Another file sharing the directory, or deleted since listing it
+		}
+		if err != nil {
+			return nil, err
+		}
+		if c.ID != strings.TrimSuffix(entry.Name(), ".json") {
+			continue // Not a context written by this store
+		}
+		if filter.Match(c) {
+			list = append(list, c)
+		}
+	}
+	return sortAndLimit(list, filter.Limit), nil
+}
+
+func (s *FileStore) Delete(ctx context.Context, id string) error {
+	path, err := s.path(id)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return ErrClosed
+	}
+	c, err := readContextFile(path)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("failed to delete context: %w", err)
+	}
+	if err := os.Remove(historyPath(path)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to delete context history: %w", err)
+	}
+	s.watchers.publish(Event{Type: EventDelete, ID: id, Context: c, Time: time.Now()})
+	return nil
+}
+
+func (s *FileStore) Watch(ctx context.Context, filter Filter) (<-chan Event, error) {
+	return s.watchers.subscribe(ctx, filter)
+}
+
+func (s *FileStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	s.watchers.close()
+	return nil
+}
+
+// path returns the file for a context, rejecting IDs that would escape the directory.
+func (s *FileStore) path(id string) (string, error) {
+	if err := checkID(id); err != nil {
+		return "", err
+	}
+	if id == "." || id == ".." || strings.ContainsAny(id, `/\`) || strings.ContainsRune(id, 0) {
+		return "", fmt.Errorf("%w: %q", ErrInvalidID, id)
+	}
+	return filepath.Join(s.dir, id+".json"), nil
+}
+
+// commit appends the event to the history before writing the new state, so every
+// stored version can be rebuilt.
+func (s *FileStore) commit(path string, c *mcpctx.Context, event mcpctx.ContextEvent) error {
+	if err := appendHistoryFile(historyPath(path), event); err != nil {
+		return err
+	}
+	return writeContextFile(path, c)
+}
+
+func historyPath(path string) string {
+	return strings.TrimSuffix(path, ".json") + ".history.jsonl"
+}
+
+func readHistoryFile(path string) ([]mcpctx.ContextEvent, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read context history: %w", err)
+	}
+	defer f.Close()
+
+	var events []mcpctx.ContextEvent
+	dec := json.NewDecoder(f)
+	for dec.More() {
+		var event mcpctx.ContextEvent
+		if err := dec.Decode(&event); err != nil {
+			return nil, fmt.Errorf("failed to decode context history %s: %w", filepath.Base(path), err)
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+func appendHistoryFile(path string, event mcpctx.ContextEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode context event: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to write context history: %w", err)
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write context history: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write context history: %w", err)
+	}
+	return nil
+}
+
+func readContextFile(path string) (*mcpctx.Context, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read context: %w", err)
+	}
+	var c mcpctx.Context
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("failed to decode context %s: %w: %w", filepath.Base(path), errNotContext, err)
+	}
+	return &c, nil
+}
+
+// writeContextFile writes through a temporary file so readers never see a partial context.
+func writeContextFile(path string, c *mcpctx.Context) error {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("failed to encode context: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".context-*")
+	if err != nil {
+		return fmt.Errorf("failed to write context: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write context: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write context: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write context: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write context: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,189 @@
+package backend
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	mcpctx "github.com/gomcp/context"
+)
+
+// MemoryStore keeps contexts in memory. It is the default store and suits tests
+// and short-lived processes.
+type MemoryStore struct {
+	mu       sync.RWMutex
+	contexts map[string]*mcpctx.Context
+	history  map[string][]mcpctx.ContextEvent
+	watchers watchers
+	closed   bool
+}
+
+// NewMemoryStore returns an empty in-memory store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		contexts: make(map[string]*mcpctx.Context),
+		history:  make(map[string][]mcpctx.ContextEvent),
+	}
+}
+
+func (s *MemoryStore) Get(ctx context.Context, id string) (*mcpctx.Context, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		return nil, ErrClosed
+	}
+	c, ok := s.contexts[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return cloneContext(c)
+}
+
+func (s *MemoryStore) Put(ctx context.Context, c *mcpctx.Context) error {
+	if err := checkID(c.ID); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return ErrClosed
+	}
+	stored, event, err := putState(c, s.contexts[c.ID])
+	if err != nil {
+		return err
+	}
+	s.contexts[c.ID] = stored
+	s.history[c.ID] = append(s.history[c.ID], event)
+
+	published, err := cloneContext(stored)
+	if err != nil {
+		return err
+	}
+	s.watchers.publish(Event{Type: EventPut, ID: c.ID, Context: published, Time: event.Time})
+	return nil
+}
+
+func (s *MemoryStore) Apply(ctx context.Context, update mcpctx.ContextUpdate) (*mcpctx.Context, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil, ErrClosed
+	}
+	current, ok := s.contexts[update.ID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	updated, event, err := applyUpdate(current, update)
+	if err != nil {
+		return nil, err
+	}
+	s.contexts[update.ID] = updated
+	s.history[update.ID] = append(s.history[update.ID], event)
+
+	published, err := cloneContext(updated)
+	if err != nil {
+		return nil, err
+	}
+	s.watchers.publish(Event{Type: EventUpdate, ID: update.ID, Context: published, Update: event.Update, Time: event.Time})
+	return cloneContext(updated)
+}
+
+func (s *MemoryStore) History(ctx context.Context, id string) ([]mcpctx.ContextEvent, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		return nil, ErrClosed
+	}
+	events, ok := s.history[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return cloneEvents(events)
+}
+
+func (s *MemoryStore) GetVersion(ctx context.Context, id string, version int64) (*mcpctx.Context, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		return nil, ErrClosed
+	}
+	events, ok := s.history[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return mcpctx.Rebuild(events, version)
+}
+
+func (s *MemoryStore) Revert(ctx context.Context, id string, version int64, author string) (*mcpctx.Context, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil, ErrClosed
+	}
+	current, ok := s.contexts[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	reverted, event, err := revertState(s.history[id], current, version, author)
+	if err != nil {
+		return nil, err
+	}
+	s.contexts[id] = reverted
+	s.history[id] = append(s.history[id], event)
+
+	published, err := cloneContext(reverted)
+	if err != nil {
+		return nil, err
+	}
+	s.watchers.publish(Event{Type: EventRevert, ID: id, Context: published, Time: event.Time})
+	return cloneContext(reverted)
+}
+
+func (s *MemoryStore) List(ctx context.Context, filter Filter) ([]*mcpctx.Context, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		return nil, ErrClosed
+	}
+	var list []*mcpctx.Context
+	for _, c := range s.contexts {
+		if !filter.Match(c) {
+			continue
+		}
+		clone, err := cloneContext(c)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, clone)
+	}
+	return sortAndLimit(list, filter.Limit), nil
+}
+
+func (s *MemoryStore) Delete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return ErrClosed
+	}
+	c, ok := s.contexts[id]
+	if !ok {
+		return ErrNotFound
+	}
+	delete(s.contexts, id)
+	delete(s.history, id)
+	s.watchers.publish(Event{Type: EventDelete, ID: id, Context: c, Time: time.Now()})
+	return nil
+}
+
+func (s *MemoryStore) Watch(ctx context.Context, filter Filter) (<-chan Event, error) {
+	return s.watchers.subscribe(ctx, filter)
+}
+
+func (s *MemoryStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	s.watchers.close()
+	return nil
+}
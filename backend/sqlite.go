@@ -0,0 +1,349 @@
+package backend
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	mcpctx "github.com/gomcp/context"
+
+	_ "modernc.org/sqlite"
+)
+
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS contexts (
+	id         TEXT PRIMARY KEY,
+	updated_at INTEGER NOT NULL,
+	archived   INTEGER NOT NULL,
+	data       BLOB NOT NULL
+);
+CREATE INDEX IF NOT EXISTS contexts_updated_at ON contexts (updated_at);
+CREATE TABLE IF NOT EXISTS context_events (
+	context_id TEXT NOT NULL,
+	version    INTEGER NOT NULL,
+	data       BLOB NOT NULL,
+	PRIMARY KEY (context_id, version)
+);
+`
+
+// SQLiteStore keeps contexts in an embedded SQLite database. It needs no cgo.
+// Watch only reports changes made through this store.
+type SQLiteStore struct {
+	db       *sql.DB
+	mu       sync.Mutex // Serializes writes so events are published in commit order
+	watchers watchers
+}
+
+// OpenSQLiteStore opens or creates the database at path. Use ":memory:" for a
+// private in-memory database.
+func OpenSQLiteStore(path string) (*SQLiteStore, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open context database: %w", err)
+	}
+	// A single connection keeps ":memory:" databases intact and avoids SQLITE_BUSY.
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create context table: %w", err)
+	}
+	return &SQLiteStore{db: db}, nil
+}
+
+func (s *SQLiteStore) Get(ctx context.Context, id string) (*mcpctx.Context, error) {
+	return getContext(ctx, s.db, id)
+}
+
+func (s *SQLiteStore) Put(ctx context.Context, c *mcpctx.Context) error {
+	if err := checkID(c.ID); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return wrapClosed(err)
+	}
+	defer tx.Rollback()
+
+	current, err := getContext(ctx, tx, c.ID)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return err
+	}
+	stored, event, err := putState(c, current)
+	if err != nil {
+		return err
+	}
+	if err := commitContext(ctx, tx, stored, event); err != nil {
+		return err
+	}
+	s.watchers.publish(Event{Type: EventPut, ID: c.ID, Context: stored, Time: event.Time})
+	return nil
+}
+
+func (s *SQLiteStore) Apply(ctx context.Context, update mcpctx.ContextUpdate) (*mcpctx.Context, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, wrapClosed(err)
+	}
+	defer tx.Rollback()
+
+	current, err := getContext(ctx, tx, update.ID)
+	if err != nil {
+		return nil, err
+	}
+	updated, event, err := applyUpdate(current, update)
+	if err != nil {
+		return nil, err
+	}
+	if err := commitContext(ctx, tx, updated, event); err != nil {
+		return nil, err
+	}
+
+	published, err := cloneContext(updated)
+	if err != nil {
+		return nil, err
+	}
+	s.watchers.publish(Event{Type: EventUpdate, ID: update.ID, Context: published, Update: event.Update, Time: event.Time})
+	return updated, nil
+}
+
+func (s *SQLiteStore) History(ctx context.Context, id string) ([]mcpctx.ContextEvent, error) {
+	return getHistory(ctx, s.db, id)
+}
+
+func (s *SQLiteStore) GetVersion(ctx context.Context, id string, version int64) (*mcpctx.Context, error) {
+	events, err := getHistory(ctx, s.db, id)
+	if err != nil {
+		return nil, err
+	}
+	return mcpctx.Rebuild(events, version)
+}
+
+func (s *SQLiteStore) Revert(ctx context.Context, id string, version int64, author string) (*mcpctx.Context, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, wrapClosed(err)
+	}
+	defer tx.Rollback()
+
+	current, err := getContext(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+	events, err := getHistory(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+	reverted, event, err := revertState(events, current, version, author)
+	if err != nil {
+		return nil, err
+	}
+	if err := commitContext(ctx, tx, reverted, event); err != nil {
+		return nil, err
+	}
+
+	published, err := cloneContext(reverted)
+	if err != nil {
+		return nil, err
+	}
+	s.watchers.publish(Event{Type: EventRevert, ID: id, Context: published, Time: event.Time})
+	return reverted, nil
+}
+
+func (s *SQLiteStore) List(ctx context.Context, filter Filter) ([]*mcpctx.Context, error) {
+	var (
+		where []string
+		args  []any
+	)
+	if len(filter.IDs) > 0 {
+		where = append(where, "id IN (?"+strings.Repeat(", ?", len(filter.IDs)-1)+")")
+		for _, id := range filter.IDs {
+			args = append(args, id)
+		}
+	}
+	if filter.Archived != nil {
+		where = append(where, "archived = ?")
+		args = append(args, *filter.Archived)
+	}
+	if !filter.UpdatedAfter.IsZero() {
+		where = append(where, "updated_at > ?")
+		args = append(args, filter.UpdatedAfter.UnixNano())
+	}
+
+	query := "SELECT data FROM contexts"
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, wrapClosed(err)
+	}
+	defer rows.Close()
+
+	// Metadata is matched here rather than in SQL since it lives in the JSON blob.
+	var list []*mcpctx.Context
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			return nil, fmt.Errorf("failed to list contexts: %w", err)
+		}
+		c, err := decodeContext(data)
+		if err != nil {
+			return nil, err
+		}
+		if filter.Match(c) {
+			list = append(list, c)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list contexts: %w", err)
+	}
+	return sortAndLimit(list, filter.Limit), nil
+}
+
+func (s *SQLiteStore) Delete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return wrapClosed(err)
+	}
+	defer tx.Rollback()
+
+	c, err := getContext(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM contexts WHERE id = ?", id); err != nil {
+		return fmt.Errorf("failed to delete context: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM context_events WHERE context_id = ?", id); err != nil {
+		return fmt.Errorf("failed to delete context history: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to delete context: %w", err)
+	}
+	s.watchers.publish(Event{Type: EventDelete, ID: id, Context: c, Time: time.Now()})
+	return nil
+}
+
+func (s *SQLiteStore) Watch(ctx context.Context, filter Filter) (<-chan Event, error) {
+	return s.watchers.subscribe(ctx, filter)
+}
+
+func (s *SQLiteStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.watchers.close()
+	return s.db.Close()
+}
+
+// queryer is satisfied by both *sql.DB and *sql.Tx.
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+func getContext(ctx context.Context, q queryer, id string) (*mcpctx.Context, error) {
+	var data []byte
+	err := q.QueryRowContext(ctx, "SELECT data FROM contexts WHERE id = ?", id).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, wrapClosed(err)
+	}
+	return decodeContext(data)
+}
+
+func putContext(ctx context.Context, q queryer, c *mcpctx.Context) error {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("failed to encode context: %w", err)
+	}
+	_, err = q.ExecContext(ctx, `INSERT INTO contexts (id, updated_at, archived, data) VALUES (?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET updated_at = excluded.updated_at, archived = excluded.archived, data = excluded.data`,
+		c.ID, c.UpdatedAt.UnixNano(), c.IsArchived, data)
+	if err != nil {
+		return wrapClosed(err)
+	}
+	return nil
+}
+
+// commitContext records the event and writes the new state, then commits tx.
+func commitContext(ctx context.Context, tx *sql.Tx, c *mcpctx.Context, event mcpctx.ContextEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode context event: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, "INSERT INTO context_events (context_id, version, data) VALUES (?, ?, ?)",
+		event.ContextID, event.Version, data); err != nil {
+		return fmt.Errorf("failed to record context event: %w", err)
+	}
+	if err := putContext(ctx, tx, c); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to save context: %w", err)
+	}
+	return nil
+}
+
+func getHistory(ctx context.Context, q queryer, id string) ([]mcpctx.ContextEvent, error) {
+	rows, err := q.QueryContext(ctx, "SELECT data FROM context_events WHERE context_id = ? ORDER BY version", id)
+	if err != nil {
+		return nil, wrapClosed(err)
+	}
+	defer rows.Close()
+
+	var events []mcpctx.ContextEvent
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			return nil, fmt.Errorf("failed to read context history: %w", err)
+		}
+		var event mcpctx.ContextEvent
+		if err := json.Unmarshal(data, &event); err != nil {
+			return nil, fmt.Errorf("failed to decode context event: %w", err)
+		}
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read context history: %w", err)
+	}
+	if len(events) == 0 {
+		return nil, ErrNotFound
+	}
+	return events, nil
+}
+
+func decodeContext(data []byte) (*mcpctx.Context, error) {
+	var c mcpctx.Context
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("failed to decode context: %w", err)
+	}
+	return &c, nil
+}
+
+// wrapClosed maps database/sql's closed error onto ErrClosed.
+func wrapClosed(err error) error {
+	if err != nil && strings.Contains(err.Error(), "sql: database is closed") {
+		return ErrClosed
+	}
+	return fmt.Errorf("context database: %w", err)
+}
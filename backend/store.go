@@ -0,0 +1,198 @@
+// Package backend provides pluggable storage for contexts. Every store implements
+// ContextStore, so the client and server can switch between them freely.
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	mcpctx "github.com/gomcp/context"
+)
+
+var (
+	// ErrNotFound indicates a context that is not in the store.
+	ErrNotFound = errors.New("context not found")
+	// ErrInvalidID indicates a missing or malformed context ID.
+	ErrInvalidID = errors.New("invalid context id")
+	// ErrClosed indicates a store that has been closed.
+	ErrClosed = errors.New("context store is closed")
+)
+
+// ContextStore persists contexts. Stores return copies, so callers may modify
+// the contexts they get without affecting stored state.
+type ContextStore interface {
+	// Get returns the context with the given ID, or ErrNotFound.
+	Get(ctx context.Context, id string) (*mcpctx.Context, error)
+	// Put creates or replaces a context as its next version.
+	Put(ctx context.Context, c *mcpctx.Context) error
+	// Apply applies an update to the stored context named by update.ID and
+	// returns the result. The read-modify-write is atomic, so an update with an
+	// ExpectedVersion fails with a *mcpctx.ConflictError if another writer got
+	// there first.
+	Apply(ctx context.Context, update mcpctx.ContextUpdate) (*mcpctx.Context, error)
+	// History returns every recorded change to a context, oldest first.
+	History(ctx context.Context, id string) ([]mcpctx.ContextEvent, error)
+	// GetVersion rebuilds a context as it was at the given version.
+	GetVersion(ctx context.Context, id string, version int64) (*mcpctx.Context, error)
+	// Revert restores a context to an earlier version. The restored state is
+	// recorded as a new version, so the history is never rewritten.
+	Revert(ctx context.Context, id string, version int64, author string) (*mcpctx.Context, error)
+	// List returns the contexts matching filter, most recently updated first.
+	List(ctx context.Context, filter Filter) ([]*mcpctx.Context, error)
+	// Delete removes a context and its history, or returns ErrNotFound.
+	Delete(ctx context.Context, id string) error
+	// Watch streams changes to contexts matching filter until ctx is done. The
+	// channel is closed when ctx is done, the store is closed, or the watcher
+	// falls too far behind; in the last case it should watch again.
+	Watch(ctx context.Context, filter Filter) (<-chan Event, error)
+	// Close releases the store's resources and ends all watches.
+	Close() error
+}
+
+// Filter selects contexts in List and Watch. Zero fields match everything.
+type Filter struct {
+	IDs          []string          // Only these contexts
+	Metadata     map[string]string // Contexts with all of these metadata entries
+	Archived     *bool             // Only archived or only active contexts
+	UpdatedAfter time.Time         // Contexts updated after this time
+	Limit        int               // Maximum number of contexts returned by List
+}
+
+// Match reports whether c is selected by the filter, ignoring Limit.
+func (f Filter) Match(c *mcpctx.Context) bool {
+	if len(f.IDs) > 0 {
+		found := false
+		for _, id := range f.IDs {
+			if id == c.ID {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	for k, v := range f.Metadata {
+		if c.Metadata[k] != v {
+			return false
+		}
+	}
+	if f.Archived != nil && c.IsArchived != *f.Archived {
+		return false
+	}
+	if !f.UpdatedAfter.IsZero() && !c.UpdatedAt.After(f.UpdatedAfter) {
+		return false
+	}
+	return true
+}
+
+// EventType describes a change to a stored context.
+type EventType string
+
+const (
+	EventPut    EventType = "put"    // Context was created or replaced
+	EventUpdate EventType = "update" // Update was applied to the context
+	EventDelete EventType = "delete" // Context was deleted
+	EventRevert EventType = "revert" // Context was restored to an earlier version
+)
+
+// Event reports a change to a stored context. Events are shared by all watchers
+// and must not be modified.
+type Event struct {
+	Type    EventType             `json:"type"`
+	ID      string                `json:"id"`
+	Context *mcpctx.Context       `json:"context,omitempty"` // State after the change; the deleted state for EventDelete
+	Update  *mcpctx.ContextUpdate `json:"update,omitempty"`  // Applied update, for EventUpdate
+	Time    time.Time             `json:"time"`
+}
+
+// cloneContext deep-copies a context so stored state is never shared with callers.
+func cloneContext(c *mcpctx.Context) (*mcpctx.Context, error) {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy context: %w", err)
+	}
+	var clone mcpctx.Context
+	if err := json.Unmarshal(data, &clone); err != nil {
+		return nil, fmt.Errorf("failed to copy context: %w", err)
+	}
+	return &clone, nil
+}
+
+// cloneEvents deep-copies a history so stored events are never shared with callers.
+func cloneEvents(events []mcpctx.ContextEvent) ([]mcpctx.ContextEvent, error) {
+	data, err := json.Marshal(events)
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy history: %w", err)
+	}
+	var clone []mcpctx.ContextEvent
+	if err := json.Unmarshal(data, &clone); err != nil {
+		return nil, fmt.Errorf("failed to copy history: %w", err)
+	}
+	return clone, nil
+}
+
+// putState prepares a copy of c as the version after current, which is nil for
+// a new context, and records it in the history.
+func putState(c, current *mcpctx.Context) (*mcpctx.Context, mcpctx.ContextEvent, error) {
+	stored, err := cloneContext(c)
+	if err != nil {
+		return nil, mcpctx.ContextEvent{}, err
+	}
+	stored.Version = 1
+	if current != nil {
+		stored.Version = current.Version + 1
+	}
+	event, err := mcpctx.NewSnapshotEvent(stored, "")
+	return stored, event, err
+}
+
+// applyUpdate applies an update to a copy of c and records it in the history.
+func applyUpdate(c *mcpctx.Context, update mcpctx.ContextUpdate) (*mcpctx.Context, mcpctx.ContextEvent, error) {
+	updated, err := cloneContext(c)
+	if err != nil {
+		return nil, mcpctx.ContextEvent{}, err
+	}
+	if err := updated.ApplyUpdate(update); err != nil {
+		return nil, mcpctx.ContextEvent{}, err
+	}
+	event, err := mcpctx.NewUpdateEvent(update, updated.Version)
+	// Replaying the update at the time it was applied expires the same blocks.
+	event.Time = updated.UpdatedAt
+	return updated, event, err
+}
+
+// revertState rebuilds the context at version from its history and records that
+// state as the version after current.
+func revertState(events []mcpctx.ContextEvent, current *mcpctx.Context, version int64, author string) (*mcpctx.Context, mcpctx.ContextEvent, error) {
+	reverted, err := mcpctx.Rebuild(events, version)
+	if err != nil {
+		return nil, mcpctx.ContextEvent{}, err
+	}
+	reverted.Version = current.Version + 1
+	reverted.UpdatedAt = time.Now()
+	event, err := mcpctx.NewSnapshotEvent(reverted, author)
+	return reverted, event, err
+}
+
+// sortAndLimit orders contexts by most recent update and applies the filter's limit.
+func sortAndLimit(contexts []*mcpctx.Context, limit int) []*mcpctx.Context {
+	sort.SliceStable(contexts, func(i, j int) bool {
+		return contexts[i].UpdatedAt.After(contexts[j].UpdatedAt)
+	})
+	if limit > 0 && len(contexts) > limit {
+		contexts = contexts[:limit]
+	}
+	return contexts
+}
+
+func checkID(id string) error {
+	if id == "" {
+		return fmt.Errorf("%w: empty", ErrInvalidID)
+	}
+	return nil
+}
@@ -0,0 +1,453 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	mcpctx "github.com/gomcp/context"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stores returns a fresh instance of every ContextStore implementation.
+func stores(t *testing.T) map[string]ContextStore {
+	file, err := OpenFileStore(filepath.Join(t.TempDir(), "contexts"))
+	require.NoError(t, err)
+	sqlite, err := OpenSQLiteStore(filepath.Join(t.TempDir(), "contexts.db"))
+	require.NoError(t, err)
+
+	all := map[string]ContextStore{
+		"memory": NewMemoryStore(),
+		"file":   file,
+		"sqlite": sqlite,
+	}
+	for _, s := range all {
+		t.Cleanup(func() { s.Close() })
+	}
+	return all
+}
+
+func forEachStore(t *testing.T, test func(t *testing.T, s ContextStore)) {
+	for name, s := range stores(t) {
+		t.Run(name, func(t *testing.T) { test(t, s) })
+	}
+}
+
+func TestStore_PutGet(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s ContextStore) {
+		ctx := context.Background()
+		c := mcpctx.NewContext(map[string]string{"project": "gomcp"})
+		c.Memory = append(c.Memory, &mcpctx.MemoryBlock{ID: "m1", Role: "user", Content: "hello"})
+		require.NoError(t, s.Put(ctx, c))
+
+		got, err := s.Get(ctx, c.ID)
+		require.NoError(t, err)
+		assert.Equal(t, c.ID, got.ID)
+		assert.Equal(t, "gomcp", got.Metadata["project"])
+		require.Len(t, got.Memory, 1)
+		assert.Equal(t, "hello", got.Memory[0].Content)
+
+		// Neither the stored nor the returned context is shared with the caller.
+		c.Metadata["project"] = "changed"
+		got.Memory[0].Content = "changed"
+		again, err := s.Get(ctx, c.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "gomcp", again.Metadata["project"])
+		assert.Equal(t, "hello", again.Memory[0].Content)
+
+		_, err = s.Get(ctx, "missing")
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.ErrorIs(t, s.Put(ctx, &mcpctx.Context{}), ErrInvalidID)
+	})
+}
+
+func TestStore_Apply(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s ContextStore) {
+		ctx := context.Background()
+		c := mcpctx.NewContext(nil)
+		require.NoError(t, s.Put(ctx, c))
+
+		archive := true
+		updated, err := s.Apply(ctx, mcpctx.ContextUpdate{
+			ID:       c.ID,
+			Metadata: map[string]string{"topic": "stores"},
+			Append:   []*mcpctx.MemoryBlock{{ID: "m1", Role: "assistant", Content: "hi"}},
+			Archive:  &archive,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "stores", updated.Metadata["topic"])
+		assert.Len(t, updated.Memory, 1)
+		assert.True(t, updated.IsArchived)
+
+		got, err := s.Get(ctx, c.ID)
+		require.NoError(t, err)
+		assert.Equal(t, updated.Metadata, got.Metadata)
+		assert.Len(t, got.Memory, 1)
+		assert.True(t, got.IsArchived)
+
+		_, err = s.Apply(ctx, mcpctx.ContextUpdate{ID: "missing"})
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+}
+
+func TestStore_List(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s ContextStore) {
+		ctx := context.Background()
+		base := time.Now().Add(-time.Hour)
+		var ids []string
+		for i, team := range []string{"a", "b", "a"} {
+			c := mcpctx.NewContext(map[string]string{"team": team})
+			c.UpdatedAt = base.Add(time.Duration(i) * time.Minute)
+			c.IsArchived = i == 1
+			require.NoError(t, s.Put(ctx, c))
+			ids = append(ids, c.ID)
+		}
+
+		all, err := s.List(ctx, Filter{})
+		require.NoError(t, err)
+		require.Len(t, all, 3)
+		assert.Equal(t, []string{ids[2], ids[1], ids[0]}, contextIDs(all), "most recently updated first")
+
+		teamA, err := s.List(ctx, Filter{Metadata: map[string]string{"team": "a"}})
+		require.NoError(t, err)
+		assert.Equal(t, []string{ids[2], ids[0]}, contextIDs(teamA))
+
+		active := false
+		unarchived, err := s.List(ctx, Filter{Archived: &active})
+		require.NoError(t, err)
+		assert.Equal(t, []string{ids[2], ids[0]}, contextIDs(unarchived))
+
+		recent, err := s.List(ctx, Filter{UpdatedAfter: base})
+		require.NoError(t, err)
+		assert.Equal(t, []string{ids[2], ids[1]}, contextIDs(recent))
+
+		byID, err := s.List(ctx, Filter{IDs: []string{ids[0], ids[1]}})
+		require.NoError(t, err)
+		assert.Equal(t, []string{ids[1], ids[0]}, contextIDs(byID))
+
+		limited, err := s.List(ctx, Filter{Limit: 1})
+		require.NoError(t, err)
+		assert.Equal(t, []string{ids[2]}, contextIDs(limited))
+	})
+}
+
+func TestStore_Delete(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s ContextStore) {
+		ctx := context.Background()
+		c := mcpctx.NewContext(nil)
+		require.NoError(t, s.Put(ctx, c))
+
+		require.NoError(t, s.Delete(ctx, c.ID))
+		_, err := s.Get(ctx, c.ID)
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.ErrorIs(t, s.Delete(ctx, c.ID), ErrNotFound)
+	})
+}
+
+func TestStore_Watch(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s ContextStore) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		events, err := s.Watch(ctx, Filter{Metadata: map[string]string{"team": "a"}})
+		require.NoError(t, err)
+
+		ignored := mcpctx.NewContext(map[string]string{"team": "b"})
+		require.NoError(t, s.Put(ctx, ignored))
+		c := mcpctx.NewContext(map[string]string{"team": "a"})
+		require.NoError(t, s.Put(ctx, c))
+		_, err = s.Apply(ctx, mcpctx.ContextUpdate{ID: c.ID, Append: []*mcpctx.MemoryBlock{{ID: "m1"}}})
+		require.NoError(t, err)
+		require.NoError(t, s.Delete(ctx, c.ID))
+
+		for _, want := range []EventType{EventPut, EventUpdate, EventDelete} {
+			event := nextEvent(t, events)
+			assert.Equal(t, want, event.Type)
+			assert.Equal(t, c.ID, event.ID)
+		}
+
+		cancel()
+		assert.Eventually(t, func() bool {
+			_, ok := <-events
+			return !ok
+		}, time.Second, 10*time.Millisecond, "channel closes when the context is done")
+	})
+}
+
+func TestStore_Close(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s ContextStore) {
+		ctx := context.Background()
+		events, err := s.Watch(ctx, Filter{})
+		require.NoError(t, err)
+
+		require.NoError(t, s.Close())
+		_, ok := <-events
+		assert.False(t, ok, "watches end when the store closes")
+
+		_, err = s.Get(ctx, "any")
+		assert.ErrorIs(t, err, ErrClosed)
+		assert.ErrorIs(t, s.Put(ctx, mcpctx.NewContext(nil)), ErrClosed)
+		_, err = s.Watch(ctx, Filter{})
+		assert.ErrorIs(t, err, ErrClosed)
+	})
+}
+
+func TestWatchers_CloseReleasesGoroutines(t *testing.T) {
+	var w watchers
+	before := runtime.NumGoroutine()
+	for i := 0; i < 10; i++ {
+		_, err := w.subscribe(context.Background(), Filter{})
+		require.NoError(t, err)
+	}
+	assert.GreaterOrEqual(t, runtime.NumGoroutine(), before+10)
+
+	w.close()
+	// Poll by hand: Eventually runs its condition on a goroutine of its own.
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.LessOrEqual(t, runtime.NumGoroutine(), before, "watches whose context is never done end with the store")
+}
+
+func TestFileStore_RejectsPathIDs(t *testing.T) {
+	s, err := OpenFileStore(t.TempDir())
+	require.NoError(t, err)
+
+	for _, id := range []string{"..", "../escape", "a/b", `a\b`} {
+		_, err := s.Get(context.Background(), id)
+		assert.ErrorIs(t, err, ErrInvalidID, id)
+		assert.ErrorIs(t, s.Put(context.Background(), &mcpctx.Context{ID: id}), ErrInvalidID, id)
+	}
+}
+
+func TestFileStore_ListSkipsOtherFiles(t *testing.T) {
+	dir := t.TempDir()
+	s, err := OpenFileStore(dir)
+	require.NoError(t, err)
+	defer s.Close()
+	c := mcpctx.NewContext(nil)
+	require.NoError(t, s.Put(context.Background(), c))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "index.json"), []byte(`{"dimensions": 8}`), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "list.json"), []byte(`[1, 2, 3]`), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "broken.json"), []byte(`{"id": `), 0o600))
+
+	list, err := s.List(context.Background(), Filter{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{c.ID}, contextIDs(list))
+}
+
+func TestSQLiteStore_Reopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "contexts.db")
+	s, err := OpenSQLiteStore(path)
+	require.NoError(t, err)
+	c := mcpctx.NewContext(map[string]string{"k": "v"})
+	require.NoError(t, s.Put(context.Background(), c))
+	require.NoError(t, s.Close())
+
+	s, err = OpenSQLiteStore(path)
+	require.NoError(t, err)
+	defer s.Close()
+	got, err := s.Get(context.Background(), c.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "v", got.Metadata["k"])
+}
+
+func TestStore_History(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s ContextStore) {
+		ctx := context.Background()
+		c := mcpctx.NewContext(map[string]string{"stage": "draft"})
+		require.NoError(t, s.Put(ctx, c))
+
+		_, err := s.Apply(ctx, mcpctx.ContextUpdate{ID: c.ID, Author: "alice", Metadata: map[string]string{"stage": "review"}})
+		require.NoError(t, err)
+		v3, err := s.Apply(ctx, mcpctx.ContextUpdate{
+			ID:     c.ID,
+			Author: "bob",
+			Append: []*mcpctx.MemoryBlock{{ID: "m1", Content: "looks good"}},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), v3.Version)
+
+		history, err := s.History(ctx, c.ID)
+		require.NoError(t, err)
+		require.Len(t, history, 3)
+		for i, event := range history {
+			assert.Equal(t, int64(i+1), event.Version)
+			assert.Equal(t, c.ID, event.ContextID)
+		}
+		assert.NotNil(t, history[0].Snapshot)
+		assert.Equal(t, "alice", history[1].Author)
+		assert.Equal(t, "bob", history[2].Author)
+
+		v1, err := s.GetVersion(ctx, c.ID, 1)
+		require.NoError(t, err)
+		assert.Equal(t, "draft", v1.Metadata["stage"])
+		assert.Empty(t, v1.Memory)
+
+		v2, err := s.GetVersion(ctx, c.ID, 2)
+		require.NoError(t, err)
+		assert.Equal(t, "review", v2.Metadata["stage"])
+		assert.Equal(t, int64(2), v2.Version)
+
+		_, err = s.GetVersion(ctx, c.ID, 9)
+		assert.ErrorIs(t, err, mcpctx.ErrVersionNotFound)
+		_, err = s.History(ctx, "missing")
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+}
+
+func TestStore_Revert(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s ContextStore) {
+		ctx := context.Background()
+		c := mcpctx.NewContext(map[string]string{"stage": "draft"})
+		require.NoError(t, s.Put(ctx, c))
+		_, err := s.Apply(ctx, mcpctx.ContextUpdate{
+			ID:       c.ID,
+			Metadata: map[string]string{"stage": "broken"},
+			Append:   []*mcpctx.MemoryBlock{{ID: "m1", Content: "mistake"}},
+		})
+		require.NoError(t, err)
+
+		reverted, err := s.Revert(ctx, c.ID, 1, "carol")
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), reverted.Version, "a revert is recorded as a new version")
+		assert.Equal(t, "draft", reverted.Metadata["stage"])
+		assert.Empty(t, reverted.Memory)
+
+		got, err := s.Get(ctx, c.ID)
+		require.NoError(t, err)
+		assert.Equal(t, reverted.Version, got.Version)
+		assert.Equal(t, "draft", got.Metadata["stage"])
+
+		history, err := s.History(ctx, c.ID)
+		require.NoError(t, err)
+		require.Len(t, history, 3)
+		assert.Equal(t, "carol", history[2].Author)
+
+		// The reverted-away version is still in the history.
+		v2, err := s.GetVersion(ctx, c.ID, 2)
+		require.NoError(t, err)
+		assert.Equal(t, "broken", v2.Metadata["stage"])
+
+		_, err = s.Revert(ctx, c.ID, 7, "carol")
+		assert.ErrorIs(t, err, mcpctx.ErrVersionNotFound)
+
+		require.NoError(t, s.Delete(ctx, c.ID))
+		_, err = s.History(ctx, c.ID)
+		assert.ErrorIs(t, err, ErrNotFound, "history is deleted with the context")
+	})
+}
+
+func TestStore_HistoryWithExpiredBlocks(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s ContextStore) {
+		ctx := context.Background()
+		c := mcpctx.NewContext(nil)
+		require.NoError(t, s.Put(ctx, c))
+		block := &mcpctx.MemoryBlock{ID: "m1", Content: "short lived"}
+		block.SetTTL(100 * time.Millisecond)
+		_, err := s.Apply(ctx, mcpctx.ContextUpdate{ID: c.ID, Append: []*mcpctx.MemoryBlock{block}})
+		require.NoError(t, err)
+		_, err = s.Apply(ctx, mcpctx.ContextUpdate{ID: c.ID, Replace: []*mcpctx.MemoryBlock{{ID: "m1", Content: "edited"}}})
+		require.NoError(t, err)
+
+		// Replaying the history after the block expired still finds it to replace.
+		time.Sleep(150 * time.Millisecond)
+		v3, err := s.GetVersion(ctx, c.ID, 3)
+		require.NoError(t, err)
+		require.Len(t, v3.Memory, 1)
+		assert.Equal(t, "edited", v3.Memory[0].Content)
+
+		reverted, err := s.Revert(ctx, c.ID, 3, "carol")
+		require.NoError(t, err)
+		assert.Equal(t, int64(4), reverted.Version)
+	})
+}
+
+func TestStore_ApplyExpectedVersion(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s ContextStore) {
+		ctx := context.Background()
+		c := mcpctx.NewContext(nil)
+		require.NoError(t, s.Put(ctx, c))
+
+		version := int64(1)
+		_, err := s.Apply(ctx, mcpctx.ContextUpdate{ID: c.ID, ExpectedVersion: &version, Append: []*mcpctx.MemoryBlock{{ID: "a"}}})
+		require.NoError(t, err)
+
+		// A second writer that read version 1 loses the race.
+		_, err = s.Apply(ctx, mcpctx.ContextUpdate{ID: c.ID, ExpectedVersion: &version, Append: []*mcpctx.MemoryBlock{{ID: "b"}}})
+		var conflict *mcpctx.ConflictError
+		require.ErrorAs(t, err, &conflict)
+		assert.Equal(t, int64(2), conflict.Actual)
+
+		got, err := s.Get(ctx, c.ID)
+		require.NoError(t, err)
+		assert.Len(t, got.Memory, 1)
+		history, err := s.History(ctx, c.ID)
+		require.NoError(t, err)
+		assert.Len(t, history, 2, "rejected updates are not recorded")
+	})
+}
+
+func TestStore_ConcurrentAppends(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s ContextStore) {
+		ctx := context.Background()
+		c := mcpctx.NewContext(nil)
+		require.NoError(t, s.Put(ctx, c))
+
+		const writers = 8
+		var wg sync.WaitGroup
+		for i := range writers {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				update := mcpctx.ContextUpdate{ID: c.ID, Append: []*mcpctx.MemoryBlock{{ID: fmt.Sprint(i)}}}
+				for {
+					current, err := s.Get(ctx, c.ID)
+					if !assert.NoError(t, err) {
+						return
+					}
+					_, err = s.Apply(ctx, update.Rebase(current))
+					if !errors.Is(err, mcpctx.ErrVersionConflict) {
+						assert.NoError(t, err)
+						return
+					}
+				}
+			}()
+		}
+		wg.Wait()
+
+		got, err := s.Get(ctx, c.ID)
+		require.NoError(t, err)
+		assert.Len(t, got.Memory, writers, "no append is lost")
+		assert.Equal(t, int64(writers+1), got.Version)
+	})
+}
+
+func nextEvent(t *testing.T, events <-chan Event) Event {
+	t.Helper()
+	select {
+	case event, ok := <-events:
+		require.True(t, ok, "watch ended early")
+		return event
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for event")
+		return Event{}
+	}
+}
+
+func contextIDs(contexts []*mcpctx.Context) []string {
+	ids := make([]string, len(contexts))
+	for i, c := range contexts {
+		ids[i] = c.ID
+	}
+	return ids
+}
@@ -0,0 +1,83 @@
+package backend
+
+import (
+	"context"
+	"sync"
+)
+
+// watchBuffer is how many events a watcher may fall behind before it is dropped.
+const watchBuffer = 64
+
+// watchers fans out events to the watches of a store.
+type watchers struct {
+	mu     sync.Mutex
+	subs   map[*watcher]struct{}
+	closed bool
+}
+
+type watcher struct {
+	filter Filter
+	ch     chan Event
+	done   chan struct{} // closed when the watcher is removed
+}
+
+// subscribe registers a watcher that is removed when ctx is done. Watchers removed
+// earlier, because the store closed or they fell behind, release their goroutine too.
+func (w *watchers) subscribe(ctx context.Context, filter Filter) (<-chan Event, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return nil, ErrClosed
+	}
+	if w.subs == nil {
+		w.subs = make(map[*watcher]struct{})
+	}
+	sub := &watcher{filter: filter, ch: make(chan Event, watchBuffer), done: make(chan struct{})}
+	w.subs[sub] = struct{}{}
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			w.mu.Lock()
+			defer w.mu.Unlock()
+			w.remove(sub)
+		case <-sub.done:
+		}
+	}()
+	return sub.ch, nil
+}
+
+// publish sends an event to every matching watcher. Watchers that cannot keep up
+// are dropped rather than blocking the store.
+func (w *watchers) publish(event Event) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for sub := range w.subs {
+		if event.Context != nil && !sub.filter.Match(event.Context) {
+			continue
+		}
+		select {
+		case sub.ch <- event:
+		default:
+			w.remove(sub)
+		}
+	}
+}
+
+// close ends every watch.
+func (w *watchers) close() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.closed = true
+	for sub := range w.subs {
+		w.remove(sub)
+	}
+}
+
+func (w *watchers) remove(sub *watcher) {
+	if _, ok := w.subs[sub]; ok {
+		delete(w.subs, sub)
+		close(sub.ch)
+		close(sub.done)
+	}
+}
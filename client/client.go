@@ -15,11 +15,13 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/gomcp/backend"
 	"github.com/gomcp/codec"
 	mcpctx "github.com/gomcp/context"
 	"github.com/gomcp/logger"
 	"github.com/gomcp/mcp"
 	"github.com/gomcp/types"
+	"github.com/gomcp/validate"
 )
 
 // MCPClient implements the MCPClient using Server-Sent Events (SSE).
@@ -38,7 +40,14 @@ type MCPClient struct {
 	headers      map[string]string
 	handlers     map[string]chan json.RawMessage
 	contexts     map[string]*mcpctx.Context
+	store        backend.ContextStore
 	state        types.ClientState
+	elicitation  ElicitationHandler
+	tools        map[string]types.ToolDescription
+	policy       *types.SecurityPolicy
+	toolMetadata map[string]types.SecurityMetadata
+	toolKeys     validate.KeyResolver
+	pinning      *toolPinning
 }
 
 // Initializes a new Client. Must be followed by a call to client.Handshake()
@@ -58,6 +67,7 @@ func NewMCPClient(serverURL *url.URL, initURL *url.URL, clientID string) *MCPCli
 		headers:      make(map[string]string),
 		handlers:     make(map[string]chan json.RawMessage),
 		contexts:     make(map[string]*mcpctx.Context),
+		tools:        make(map[string]types.ToolDescription),
 		state:        NewClientState(initURL.String()),
 	}
 }
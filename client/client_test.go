@@ -15,6 +15,7 @@ import (
 
 	"github.com/gomcp/codec"
 	mcpctx "github.com/gomcp/context"
+	"github.com/gomcp/types"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -23,6 +24,7 @@ import (
 func newMockClient() *MCPClient {
 	return &MCPClient{
 		contexts: make(map[string]*mcpctx.Context),
+		tools:    make(map[string]types.ToolDescription),
 		done:     make(chan struct{}),
 	}
 }
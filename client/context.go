@@ -2,6 +2,8 @@ package client
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"time"
 
 	mcpctx "github.com/gomcp/context"
@@ -23,8 +25,25 @@ func (c *MCPClient) handleContextUpdate(raw json.RawMessage) error {
 		c.contexts[c.clientID] = ctx
 	}
 
-	ctx.ApplyUpdate(update)
-	return nil
+	return c.applyUpdate(ctx, update)
+}
+
+// applyUpdate applies an update to the client's context, through the store if
+// one is configured. The caller must hold c.mu.
+func (c *MCPClient) applyUpdate(ctx *mcpctx.Context, update mcpctx.ContextUpdate) error {
+	if c.store != nil {
+		return c.applyStoredUpdate(ctx, update)
+	}
+	return applyLocalUpdate(ctx, update)
+}
+
+// applyLocalUpdate applies an update to the client's own copy of a context. An
+// update made against an older version is merged rather than dropped.
+func applyLocalUpdate(ctx *mcpctx.Context, update mcpctx.ContextUpdate) error {
+	if err := ctx.ApplyUpdate(update); !errors.Is(err, mcpctx.ErrVersionConflict) {
+		return err
+	}
+	return ctx.ApplyUpdate(update.Rebase(ctx))
 }
 
 func (c *MCPClient) handleContextClear(raw json.RawMessage) error {
@@ -37,7 +56,7 @@ func (c *MCPClient) handleContextClear(raw json.RawMessage) error {
 	defer c.mu.Unlock()
 	metadata := c.contexts[c.clientID].Metadata
 	c.contexts[c.clientID] = mcpctx.NewContext(metadata)
-	return nil
+	return c.persistContext()
 }
 
 func (c *MCPClient) GetClientContext() *mcpctx.Context {
@@ -50,15 +69,18 @@ func (c *MCPClient) AppendAssistantResponse(content string) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	if ctx, ok := c.contexts[c.clientID]; ok {
-		ctx.ApplyUpdate(mcpctx.ContextUpdate{
-			ID:       ctx.ID,
-			Metadata: c.contexts[c.clientID].Metadata,
+		err := c.applyUpdate(ctx, mcpctx.ContextUpdate{
+			ID: ctx.ID,
 			Append: []*mcpctx.MemoryBlock{{
 				ID:      uuid.NewString(),
 				Role:    "assistant",
 				Content: content,
 				Time:    time.Now(),
+				Kind:    mcpctx.KindMessage,
 			}},
 		})
+		if err != nil {
+			c.log.Error(fmt.Sprintf("failed to append assistant response: %v", err))
+		}
 	}
 }
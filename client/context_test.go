@@ -90,3 +90,22 @@ func TestHandleContextClear_PreservesMetadata(t *testing.T) {
 	assert.Equal(t, "value", ctx.Metadata["key"])
 	assert.Empty(t, ctx.Messages)
 }
+
+func TestHandleContextUpdate_LocalConflictMerged(t *testing.T) {
+	c := newMockClient()
+	ctx := mcpctx.NewContext(map[string]string{})
+	ctx.Version = 4
+	ctx.Memory = append(ctx.Memory, &mcpctx.MemoryBlock{ID: "m1"})
+	c.contexts[c.clientID] = ctx
+
+	stale := int64(2)
+	update := mcpctx.ContextUpdate{
+		ID:              ctx.ID,
+		ExpectedVersion: &stale,
+		Append:          []*mcpctx.MemoryBlock{{ID: "m1"}, {ID: "m2"}},
+	}
+	b, _ := json.Marshal(update)
+	assert.NoError(t, c.handleContextUpdate(b))
+	assert.Len(t, c.GetClientContext().Memory, 2, "blocks already held are not appended twice")
+	assert.Equal(t, int64(5), c.GetClientContext().Version)
+}
@@ -0,0 +1,58 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/gomcp/codec"
+	"github.com/gomcp/mcp"
+	"github.com/gomcp/validate"
+)
+
+// ElicitationHandler is called when the server asks the user for more information
+// during a request, e.g. to confirm a destructive action or pick an account.
+// Implementations typically render a form from params.RequestedSchema and return
+// the user's choice. The returned result is validated against the requested schema
+// before it is sent back to the server.
+//
+// https://modelcontextprotocol.io/specification/2025-06-18/client/elicitation
+type ElicitationHandler func(ctx context.Context, params mcp.ElicitRequestParams) (mcp.ElicitResult, error)
+
+// SetElicitationHandler registers the hook used to answer elicitation/create requests.
+// Must be called before Start() so the capability is advertised during the handshake.
+func (c *MCPClient) SetElicitationHandler(handler ElicitationHandler) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.elicitation = handler
+	if cs, ok := c.state.(*ClientState); ok && handler != nil {
+		cs.Capabilities.Elicitation = &mcp.ElicitationCapabilities{}
+	}
+}
+
+func (c *MCPClient) handleElicitation(ctx context.Context, raw json.RawMessage) (*mcp.ElicitResult, error) {
+	var params mcp.ElicitRequestParams
+	if err := json.Unmarshal(raw, &params); err != nil {
+		return nil, &codec.RPCError{Code: codec.InvalidParams, Message: fmt.Sprintf("invalid elicitation params: %v", err)}
+	}
+	if err := params.RequestedSchema.Validate(); err != nil {
+		return nil, &codec.RPCError{Code: codec.InvalidParams, Message: err.Error()}
+	}
+
+	c.mu.Lock()
+	handler := c.elicitation
+	c.mu.Unlock()
+	if handler == nil {
+		return nil, errors.New("client does not support elicitation")
+	}
+
+	result, err := handler(ctx, params)
+	if err != nil {
+		return nil, fmt.Errorf("elicitation handler failed: %w", err)
+	}
+	if err := validate.ValidateElicitationResult(params.RequestedSchema, result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
@@ -0,0 +1,129 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/gomcp/codec"
+	"github.com/gomcp/mcp"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const elicitationRequest = `{
+	"jsonrpc": "2.0",
+	"id": 7,
+	"method": "elicitation/create",
+	"params": {
+		"message": "Which account should be charged?",
+		"requestedSchema": {
+			"type": "object",
+			"properties": {"account": {"type": "string", "enum": ["personal", "work"]}},
+			"required": ["account"]
+		}
+	}
+}`
+
+// newResponseRecorder starts a server that forwards every JSON-RPC response posted by the client.
+func newResponseRecorder(t *testing.T) (*MCPClient, chan codec.JSONRPCResponse) {
+	t.Helper()
+	received := make(chan codec.JSONRPCResponse, 1)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var resp codec.JSONRPCResponse
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&resp))
+		received <- resp
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	t.Cleanup(ts.Close)
+
+	tsURL, _ := url.Parse(ts.URL)
+	c := newMockClient()
+	c.serverURL = tsURL
+	c.httpClient = ts.Client()
+	return c, received
+}
+
+func waitForResponse(t *testing.T, received chan codec.JSONRPCResponse) codec.JSONRPCResponse {
+	t.Helper()
+	select {
+	case resp := <-received:
+		return resp
+	case <-time.After(2 * time.Second):
+		t.Fatal("client did not respond to server request")
+	}
+	return codec.JSONRPCResponse{}
+}
+
+func TestHandleElicitation_Accept(t *testing.T) {
+	c, received := newResponseRecorder(t)
+	c.SetElicitationHandler(func(ctx context.Context, params mcp.ElicitRequestParams) (mcp.ElicitResult, error) {
+		assert.Equal(t, "Which account should be charged?", params.Message)
+		return mcp.ElicitResult{Action: mcp.ElicitAccept, Content: map[string]any{"account": "work"}}, nil
+	})
+
+	require.NoError(t, c.handleMessage([]byte(elicitationRequest)))
+	resp := waitForResponse(t, received)
+
+	assert.Nil(t, resp.Error)
+	assert.Equal(t, float64(7), resp.ID)
+	var result mcp.ElicitResult
+	require.NoError(t, json.Unmarshal(resp.Bytes(), &result))
+	assert.Equal(t, mcp.ElicitAccept, result.Action)
+	assert.Equal(t, "work", result.Content["account"])
+}
+
+func TestHandleElicitation_InvalidContent(t *testing.T) {
+	c, received := newResponseRecorder(t)
+	c.SetElicitationHandler(func(ctx context.Context, params mcp.ElicitRequestParams) (mcp.ElicitResult, error) {
+		return mcp.ElicitResult{Action: mcp.ElicitAccept, Content: map[string]any{"account": "shared"}}, nil
+	})
+
+	require.NoError(t, c.handleMessage([]byte(elicitationRequest)))
+	resp := waitForResponse(t, received)
+
+	require.NotNil(t, resp.Error)
+	assert.Equal(t, codec.InternalError, resp.Error.Code)
+}
+
+func TestHandleElicitation_NoHandler(t *testing.T) {
+	c, received := newResponseRecorder(t)
+
+	require.NoError(t, c.handleMessage([]byte(elicitationRequest)))
+	resp := waitForResponse(t, received)
+
+	require.NotNil(t, resp.Error)
+	assert.Contains(t, resp.Error.Message, "does not support elicitation")
+}
+
+func TestHandleElicitation_HandlerError(t *testing.T) {
+	c := newMockClient()
+	c.SetElicitationHandler(func(ctx context.Context, params mcp.ElicitRequestParams) (mcp.ElicitResult, error) {
+		return mcp.ElicitResult{}, errors.New("user closed the window")
+	})
+
+	var msg rpcMessage
+	require.NoError(t, json.Unmarshal([]byte(elicitationRequest), &msg))
+
+	_, err := c.handleElicitation(context.Background(), msg.Params)
+	assert.ErrorContains(t, err, "user closed the window")
+}
+
+func TestHandleMessage_DeliversResponse(t *testing.T) {
+	c := newMockClient()
+	c.responses = make(map[int64]chan codec.JSONRPCResponse)
+	ch := make(chan codec.JSONRPCResponse, 1)
+	c.responses[3] = ch
+
+	require.NoError(t, c.handleMessage([]byte(`{"jsonrpc":"2.0","id":3,"result":{"ok":true}}`)))
+
+	resp := <-ch
+	assert.JSONEq(t, `{"ok":true}`, string(resp.Bytes()))
+	assert.Empty(t, c.responses)
+}
@@ -12,15 +12,13 @@ func (c *MCPClient) handleMemoryAppend(raw json.RawMessage) error {
 		return err
 	}
 
-	for _, ctx := range c.contexts {
-		if ctx.ID == update.ID {
-			c.mu.Lock()
-			c.contexts[c.clientID].Memory = append(c.contexts[c.clientID].Memory, update.Append...)
-			c.mu.Unlock()
-		}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ctx, ok := c.contexts[c.clientID]
+	if !ok || ctx.ID != update.ID {
+		return nil
 	}
-
-	return nil
+	return c.applyUpdate(ctx, mcpctx.ContextUpdate{ID: ctx.ID, Append: update.Append})
 }
 
 func (c *MCPClient) handleMemoryReplace(raw json.RawMessage) error {
@@ -28,18 +26,16 @@ func (c *MCPClient) handleMemoryReplace(raw json.RawMessage) error {
 	if err := json.Unmarshal(raw, &update); err != nil {
 		return err
 	}
-
-	if ctx, ok := c.contexts[c.clientID]; ok {
-		for _, memory := range ctx.Memory {
-			for _, updatedmemory := range update.Append {
-				if memory.ID == updatedmemory.ID {
-					c.mu.Lock()
-					memory.UpdateContent(updatedmemory.Content)
-					c.mu.Unlock()
-				}
-			}
-		}
+	if len(update.Replace) == 0 {
+		// Older servers send the replacement blocks in Append.
+		update.Replace = update.Append
 	}
 
-	return nil
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ctx, ok := c.contexts[c.clientID]
+	if !ok {
+		return nil
+	}
+	return c.applyUpdate(ctx, mcpctx.ContextUpdate{ID: ctx.ID, Replace: update.Replace})
 }
@@ -2,6 +2,7 @@ package client
 
 import (
 	"encoding/json"
+	"errors"
 	"sync"
 	"testing"
 
@@ -89,3 +90,33 @@ func TestHandleMemoryReplace(t *testing.T) {
 		t.Errorf("expected content to be 'new content', got: %s", initialMemory.Content)
 	}
 }
+
+func TestHandleMemoryReplace_ReplaceField(t *testing.T) {
+	clientID := "client1"
+	contextID := "ctx1"
+	client := createTestClient(clientID, contextID)
+	client.contexts[clientID].Memory = []*mcpctx.MemoryBlock{{ID: "m1", Role: "user", Content: "old content"}}
+
+	update := mcpctx.ContextUpdate{
+		ID:      contextID,
+		Replace: []*mcpctx.MemoryBlock{{ID: "m1", Role: "assistant", Content: "new content"}},
+	}
+	raw, err := json.Marshal(update)
+	if err != nil {
+		t.Fatalf("failed to marshal update: %v", err)
+	}
+	if err := client.handleMemoryReplace(raw); err != nil {
+		t.Fatalf("handleMemoryReplace failed: %v", err)
+	}
+
+	block := client.contexts[clientID].Memory[0]
+	if block.Content != "new content" || block.Role != "assistant" {
+		t.Errorf("expected replaced assistant block, got: %+v", block)
+	}
+
+	update.Replace[0].ID = "unknown"
+	raw, _ = json.Marshal(update)
+	if err := client.handleMemoryReplace(raw); !errors.Is(err, mcpctx.ErrInvalidUpdate) {
+		t.Errorf("expected ErrInvalidUpdate for an unknown block, got: %v", err)
+	}
+}
@@ -0,0 +1,136 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gomcp/codec"
+	"github.com/gomcp/mcp"
+)
+
+// rpcMessage is used to tell server requests, notifications and
+// responses apart when they arrive over the SSE stream.
+type rpcMessage struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      any             `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *codec.RPCError `json:"error,omitempty"`
+}
+
+// handleMessage dispatches a JSON-RPC message received from the server.
+//
+// https://modelcontextprotocol.io/specification/2025-03-26/basic/transports#listening-for-messages-from-the-server
+func (c *MCPClient) handleMessage(data []byte) error {
+	var msg rpcMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return fmt.Errorf("failed to decode server message: %w", err)
+	}
+
+	switch {
+	case msg.Method != "" && msg.ID != nil:
+		// Server requests may block on user interaction (e.g. elicitation),
+		// so they must not hold up the event stream.
+		go c.handleServerRequest(msg)
+		return nil
+	case msg.Method != "":
+		return c.HandleMCPNotification(mcp.MCPNotification(msg.Method), msg.Params)
+	case msg.ID != nil:
+		return c.deliverResponse(msg)
+	default:
+		return errors.New("server message is neither a request, notification nor response")
+	}
+}
+
+// deliverResponse hands a response to the SendRequest call waiting on it.
+func (c *MCPClient) deliverResponse(msg rpcMessage) error {
+	id, ok := msg.ID.(float64)
+	if !ok {
+		return fmt.Errorf("unexpected response id type %T", msg.ID)
+	}
+
+	c.mu.Lock()
+	ch, ok := c.responses[int64(id)]
+	delete(c.responses, int64(id))
+	c.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("no pending request with id %d", int64(id))
+	}
+
+	resp := codec.NewJSONRPCResponse()
+	resp.ID = msg.ID
+	resp.Error = msg.Error
+	if msg.Result != nil {
+		resp.Result = msg.Result
+	}
+	ch <- resp
+	return nil
+}
+
+// handleServerRequest runs a server-initiated request and posts the result back.
+func (c *MCPClient) handleServerRequest(msg rpcMessage) {
+	ctx := context.Background()
+
+	var (
+		result any
+		err    error
+	)
+	switch msg.Method {
+	case mcp.MethodPing:
+		result = struct{}{}
+	case mcp.MethodElicitationCreate:
+		result, err = c.handleElicitation(ctx, msg.Params)
+	default:
+		err = &codec.RPCError{Code: codec.MethodNotFound, Message: fmt.Sprintf("method not found: %s", msg.Method)}
+	}
+
+	var rpcErr *codec.RPCError
+	if err != nil && !errors.As(err, &rpcErr) {
+		rpcErr = &codec.RPCError{Code: codec.InternalError, Message: err.Error()}
+	}
+	if err := c.respond(ctx, msg.ID, result, rpcErr); err != nil && c.log != nil {
+		c.log.Error(fmt.Sprintf("failed to respond to server request %s: %v", msg.Method, err))
+	}
+}
+
+// respond posts a JSON-RPC response for a server-initiated request.
+func (c *MCPClient) respond(ctx context.Context, id any, result any, rpcErr *codec.RPCError) error {
+	resp := codec.NewJSONRPCResponse()
+	resp.ID = id
+	if rpcErr != nil {
+		resp.Error = rpcErr
+	} else {
+		resp.Result = result
+	}
+
+	body, err := json.Marshal(resp)
+	if err != nil {
+		return fmt.Errorf("failed to marshal response: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.serverURL.String(), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Client-ID", c.clientID)
+	for k, v := range c.headers {
+		req.Header.Set(k, v)
+	}
+
+	httpResp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer httpResp.Body.Close()
+	switch httpResp.StatusCode {
+	case http.StatusOK, http.StatusAccepted, http.StatusNoContent:
+		return nil
+	default:
+		return fmt.Errorf("unexpected status posting response: %d", httpResp.StatusCode)
+	}
+}
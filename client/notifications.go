@@ -17,6 +17,8 @@ func (c *MCPClient) HandleMCPNotification(method mcp.MCPNotification, raw json.R
 		return c.handleMemoryAppend(raw)
 	case mcp.MemoryReplace:
 		return c.handleMemoryReplace(raw)
+	case mcp.ToolsListChanged:
+		return c.handleToolsListChanged()
 	// case mcp.ToolResponse:
 	// 	return c.handleToolResponse(raw)
 	// case mcp.LogEvent:
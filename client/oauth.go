@@ -0,0 +1,711 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrAuthorizationFailed is returned when the OAuth flow triggered by a 401 fails.
+	ErrAuthorizationFailed = errors.New("oauth authorization failed")
+	// ErrStateMismatch indicates an authorization callback for a different request.
+	ErrStateMismatch = errors.New("oauth state mismatch")
+)
+
+// expiryMargin refreshes access tokens shortly before they expire.
+const expiryMargin = 30 * time.Second
+
+const (
+	// DefaultAuthorizationTimeout bounds an authorization flow, including the time
+	// the user takes to sign in, when OAuthConfig.AuthorizationTimeout is not set.
+	DefaultAuthorizationTimeout = 5 * time.Minute
+	// authServerTimeout bounds requests to the authorization server when
+	// OAuthConfig.HTTPClient is not set.
+	authServerTimeout = 30 * time.Second
+)
+
+// Authorizer sends the user to the authorization server and returns the query
+// parameters of the redirect back to RedirectURL, i.e. code and state or error.
+type Authorizer interface {
+	RedirectURL() string
+	Authorize(ctx context.Context, authorizationURL string) (url.Values, error)
+}
+
+// OAuthToken holds the tokens issued for a resource, along with the client
+// registration and token endpoint needed to refresh them.
+type OAuthToken struct {
+	AccessToken   string    `json:"access_token"`
+	RefreshToken  string    `json:"refresh_token,omitempty"`
+	TokenType     string    `json:"token_type,omitempty"`
+	Scope         string    `json:"scope,omitempty"`
+	Expiry        time.Time `json:"expiry,omitempty"`
+	ClientID      string    `json:"client_id"`
+	ClientSecret  string    `json:"client_secret,omitempty"`
+	TokenEndpoint string    `json:"token_endpoint"`
+}
+
+// Expired reports whether the access token has expired or is about to.
+func (t *OAuthToken) Expired() bool {
+	return !t.Expiry.IsZero() && time.Now().Add(expiryMargin).After(t.Expiry)
+}
+
+// TokenStore persists OAuth tokens, keyed by resource URI.
+type TokenStore interface {
+	LoadToken(resource string) (*OAuthToken, error)
+	SaveToken(resource string, token *OAuthToken) error
+}
+
+// MemoryTokenStore keeps tokens in memory.
+type MemoryTokenStore struct {
+	mu     sync.Mutex
+	tokens map[string]OAuthToken
+}
+
+func NewMemoryTokenStore() *MemoryTokenStore {
+	return &MemoryTokenStore{tokens: make(map[string]OAuthToken)}
+}
+
+func (s *MemoryTokenStore) LoadToken(resource string) (*OAuthToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	token, ok := s.tokens[resource]
+	if !ok {
+		return nil, nil
+	}
+	return &token, nil
+}
+
+func (s *MemoryTokenStore) SaveToken(resource string, token *OAuthToken) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if token == nil {
+		delete(s.tokens, resource)
+	} else {
+		s.tokens[resource] = *token
+	}
+	return nil
+}
+
+// OAuthConfig configures how the client obtains access tokens for a server that
+// requires OAuth 2.1 authorization.
+//
+// https://modelcontextprotocol.io/specification/2025-06-18/basic/authorization
+type OAuthConfig struct {
+	// ClientID of a pre-registered client. If empty, the client registers itself
+	// with the authorization server (RFC 7591).
+	ClientID     string
+	ClientSecret string
+	// ClientName is sent during dynamic client registration.
+	ClientName string
+	// Scopes to request; defaults to those in the server's challenge or metadata.
+	Scopes []string
+	// Authorizer runs the browser part of the authorization code flow.
+	Authorizer Authorizer
+	// Store persists tokens; a MemoryTokenStore is used if nil.
+	Store TokenStore
+	// HTTPClient talks to the authorization server; a client with a 30 second
+	// timeout if nil.
+	HTTPClient *http.Client
+	// AuthorizationTimeout bounds an authorization flow triggered by a 401;
+	// DefaultAuthorizationTimeout if zero.
+	AuthorizationTimeout time.Duration
+}
+
+// EnableOAuth authorizes requests to the server with OAuth access tokens. When the
+// server answers 401, the client discovers its authorization server, registers if
+// needed, runs the authorization code flow with PKCE and retries the request.
+//
+// The user may take longer to sign in than the client's request timeout allows,
+// so the timeout moves from the client's http.Client to each request the
+// transport sends to the server, and the flow itself is bounded separately.
+func (c *MCPClient) EnableOAuth(cfg OAuthConfig) {
+	transport := NewOAuthTransport(cfg, c.httpClient.Transport)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	transport.requestTimeout = c.httpClient.Timeout
+	c.httpClient.Transport = transport
+	c.httpClient.Timeout = 0
+	if cs, ok := c.state.(*ClientState); ok {
+		cs.httpClient.Transport = transport
+		cs.httpClient.Timeout = 0
+	}
+}
+
+// OAuthTransport is an http.RoundTripper that adds bearer tokens to requests and
+// runs the OAuth flow when the server asks for authorization.
+type OAuthTransport struct {
+	cfg            OAuthConfig
+	base           http.RoundTripper
+	requestTimeout time.Duration // bounds each request to the server, if set
+
+	mu      sync.Mutex                  // serializes authorization flows and token refreshes
+	clients map[string]registeredClient // registered clients by registration endpoint
+}
+
+// registeredClient holds the credentials issued by dynamic client registration.
+type registeredClient struct {
+	id, secret string
+}
+
+// NewOAuthTransport wraps base, or http.DefaultTransport if nil.
+func NewOAuthTransport(cfg OAuthConfig, base http.RoundTripper) *OAuthTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if cfg.Store == nil {
+		cfg.Store = NewMemoryTokenStore()
+	}
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = &http.Client{Timeout: authServerTimeout}
+	}
+	if cfg.AuthorizationTimeout <= 0 {
+		cfg.AuthorizationTimeout = DefaultAuthorizationTimeout
+	}
+	return &OAuthTransport{cfg: cfg, base: base, clients: make(map[string]registeredClient)}
+}
+
+func (t *OAuthTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := t.validToken(req.Context(), req.URL)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := t.send(withBearer(req, token))
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+	if req.Body != nil && req.GetBody == nil {
+		return resp, nil // the request cannot be replayed
+	}
+
+	challenge := parseBearerChallenge(resp.Header.Get("WWW-Authenticate"))
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	ctx, cancel := context.WithTimeout(req.Context(), t.cfg.AuthorizationTimeout)
+	token, err = t.authorize(ctx, req.URL, challenge, token)
+	cancel()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrAuthorizationFailed, err)
+	}
+	retry := req.Clone(req.Context())
+	if req.GetBody != nil {
+		if retry.Body, err = req.GetBody(); err != nil {
+			return nil, err
+		}
+	}
+	return t.send(withBearer(retry, token))
+}
+
+// send passes a request to the base transport, bounded by the request timeout
+// until its response body is closed, as http.Client.Timeout would.
+func (t *OAuthTransport) send(req *http.Request) (*http.Response, error) {
+	if t.requestTimeout <= 0 {
+		return t.base.RoundTrip(req)
+	}
+	ctx, cancel := context.WithTimeout(req.Context(), t.requestTimeout)
+	resp, err := t.base.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// cancelOnClose releases a request's timeout once its response body is closed.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// withBearer returns a copy of req carrying the access token, if there is one.
+func withBearer(req *http.Request, token *OAuthToken) *http.Request {
+	if token == nil {
+		return req
+	}
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+token.AccessToken)
+	return req
+}
+
+// validToken returns the stored token for a request URL, refreshing it if it
+// expired. It returns nil if there is no usable token.
+func (t *OAuthTransport) validToken(ctx context.Context, u *url.URL) (*OAuthToken, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	resource := canonicalResource(u)
+	token, err := t.cfg.Store.LoadToken(resource)
+	if err != nil || token == nil {
+		return nil, err
+	}
+	if !token.Expired() {
+		return token, nil
+	}
+	if token.RefreshToken == "" {
+		return nil, nil
+	}
+	refreshed, err := t.refresh(ctx, resource, token)
+	if err != nil {
+		// The server will answer 401 and a new authorization flow starts.
+		return nil, nil
+	}
+	return refreshed, nil
+}
+
+func (t *OAuthTransport) refresh(ctx context.Context, resource string, token *OAuthToken) (*OAuthToken, error) {
+	form := url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {token.RefreshToken},
+		"resource":      {resource},
+	}
+	refreshed, err := t.requestToken(ctx, token.TokenEndpoint, token.ClientID, token.ClientSecret, form)
+	if err != nil {
+		return nil, err
+	}
+	if refreshed.RefreshToken == "" {
+		refreshed.RefreshToken = token.RefreshToken // the old one stays valid unless rotated
+	}
+	if err := t.cfg.Store.SaveToken(resource, refreshed); err != nil {
+		return nil, err
+	}
+	return refreshed, nil
+}
+
+// authorize obtains a new token after the server rejected stale. If another request
+// already replaced stale while waiting for the lock, that token is used instead.
+func (t *OAuthTransport) authorize(ctx context.Context, serverURL *url.URL, challenge map[string]string, stale *OAuthToken) (*OAuthToken, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	prm, err := t.fetchResourceMetadata(ctx, serverURL, challenge["resource_metadata"])
+	if err != nil {
+		return nil, err
+	}
+	// Metadata for another resource must not be used, or a server could obtain
+	// tokens meant for a different one (RFC 9728 section 3.3).
+	resource := canonicalResource(serverURL)
+	if prm.Resource == "" {
+		return nil, errors.New("protected resource metadata has no resource")
+	}
+	if prmURL, err := url.Parse(prm.Resource); err != nil || canonicalResource(prmURL) != resource {
+		return nil, fmt.Errorf("protected resource metadata is for resource '%s', expected '%s'", prm.Resource, resource)
+	}
+
+	// Endpoints of the same server share a token, which may have been obtained
+	// by another request in the meantime.
+	if current, err := t.cfg.Store.LoadToken(resource); err == nil && current != nil && !current.Expired() &&
+		(stale == nil || current.AccessToken != stale.AccessToken) {
+		return current, nil
+	}
+	if t.cfg.Authorizer == nil {
+		return nil, errors.New("no authorizer configured")
+	}
+	if len(prm.AuthorizationServers) == 0 {
+		return nil, errors.New("protected resource metadata lists no authorization servers")
+	}
+	asm, err := t.fetchServerMetadata(ctx, prm.AuthorizationServers[0])
+	if err != nil {
+		return nil, err
+	}
+	if len(asm.CodeChallengeMethodsSupported) > 0 && !slices.Contains(asm.CodeChallengeMethodsSupported, "S256") {
+		return nil, errors.New("authorization server does not support PKCE with S256")
+	}
+
+	clientID, clientSecret := t.cfg.ClientID, t.cfg.ClientSecret
+	if clientID == "" {
+		if clientID, clientSecret, err = t.register(ctx, asm); err != nil {
+			return nil, err
+		}
+	}
+
+	scopes := t.cfg.Scopes
+	if len(scopes) == 0 {
+		if scope := challenge["scope"]; scope != "" {
+			scopes = strings.Fields(scope)
+		} else {
+			scopes = prm.ScopesSupported
+		}
+	}
+
+	verifier, err := randomString(32)
+	if err != nil {
+		return nil, err
+	}
+	state, err := randomString(16)
+	if err != nil {
+		return nil, err
+	}
+	challengeSum := sha256.Sum256([]byte(verifier))
+	redirectURL := t.cfg.Authorizer.RedirectURL()
+
+	authURL, err := url.Parse(asm.AuthorizationEndpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid authorization endpoint: %w", err)
+	}
+	query := authURL.Query()
+	query.Set("response_type", "code")
+	query.Set("client_id", clientID)
+	query.Set("redirect_uri", redirectURL)
+	query.Set("code_challenge", base64.RawURLEncoding.EncodeToString(challengeSum[:]))
+	query.Set("code_challenge_method", "S256")
+	query.Set("state", state)
+	query.Set("resource", resource)
+	if len(scopes) > 0 {
+		query.Set("scope", strings.Join(scopes, " "))
+	}
+	authURL.RawQuery = query.Encode()
+
+	callback, err := t.cfg.Authorizer.Authorize(ctx, authURL.String())
+	if err != nil {
+		return nil, err
+	}
+	if errCode := callback.Get("error"); errCode != "" {
+		return nil, fmt.Errorf("authorization denied: %s %s", errCode, callback.Get("error_description"))
+	}
+	if callback.Get("state") != state {
+		return nil, ErrStateMismatch
+	}
+	if callback.Get("code") == "" {
+		return nil, errors.New("authorization callback has no code")
+	}
+
+	token, err := t.requestToken(ctx, asm.TokenEndpoint, clientID, clientSecret, url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {callback.Get("code")},
+		"redirect_uri":  {redirectURL},
+		"code_verifier": {verifier},
+		"resource":      {resource},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := t.cfg.Store.SaveToken(resource, token); err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+// protectedResourceMetadata is the subset of RFC 9728 metadata used by the client.
+type protectedResourceMetadata struct {
+	Resource             string   `json:"resource"`
+	AuthorizationServers []string `json:"authorization_servers"`
+	ScopesSupported      []string `json:"scopes_supported"`
+}
+
+// authorizationServerMetadata is the subset of RFC 8414 metadata used by the client.
+type authorizationServerMetadata struct {
+	Issuer                        string   `json:"issuer"`
+	AuthorizationEndpoint         string   `json:"authorization_endpoint"`
+	TokenEndpoint                 string   `json:"token_endpoint"`
+	RegistrationEndpoint          string   `json:"registration_endpoint"`
+	CodeChallengeMethodsSupported []string `json:"code_challenge_methods_supported"`
+}
+
+// fetchResourceMetadata loads the metadata named in the challenge, falling back to
+// the well-known locations for the server URL.
+func (t *OAuthTransport) fetchResourceMetadata(ctx context.Context, serverURL *url.URL, metadataURL string) (*protectedResourceMetadata, error) {
+	candidates := []string{metadataURL}
+	if metadataURL == "" {
+		candidates = wellKnownURLs(serverURL, "oauth-protected-resource")
+	}
+	var prm protectedResourceMetadata
+	if err := t.getFirstJSON(ctx, candidates, &prm); err != nil {
+		return nil, fmt.Errorf("failed to fetch protected resource metadata: %w", err)
+	}
+	return &prm, nil
+}
+
+// fetchServerMetadata loads RFC 8414 metadata, falling back to OpenID Connect discovery.
+func (t *OAuthTransport) fetchServerMetadata(ctx context.Context, issuer string) (*authorizationServerMetadata, error) {
+	issuerURL, err := url.Parse(issuer)
+	if err != nil {
+		return nil, fmt.Errorf("invalid authorization server '%s': %w", issuer, err)
+	}
+	candidates := append(wellKnownURLs(issuerURL, "oauth-authorization-server"), wellKnownURLs(issuerURL, "openid-configuration")...)
+	var asm authorizationServerMetadata
+	if err := t.getFirstJSON(ctx, candidates, &asm); err != nil {
+		return nil, fmt.Errorf("failed to fetch authorization server metadata: %w", err)
+	}
+	if strings.TrimSuffix(asm.Issuer, "/") != strings.TrimSuffix(issuer, "/") {
+		return nil, fmt.Errorf("authorization server metadata is for issuer '%s', expected '%s'", asm.Issuer, issuer)
+	}
+	if asm.AuthorizationEndpoint == "" || asm.TokenEndpoint == "" {
+		return nil, errors.New("authorization server metadata lacks authorization or token endpoint")
+	}
+	return &asm, nil
+}
+
+// register performs dynamic client registration (RFC 7591) as a public client.
+// The registered client is reused for later flows with the same authorization
+// server, so re-authorizing does not create a new client each time.
+func (t *OAuthTransport) register(ctx context.Context, asm *authorizationServerMetadata) (string, string, error) {
+	if asm.RegistrationEndpoint == "" {
+		return "", "", errors.New("no client ID configured and the authorization server does not support registration")
+	}
+	if client, ok := t.clients[asm.RegistrationEndpoint]; ok {
+		return client.id, client.secret, nil
+	}
+	name := t.cfg.ClientName
+	if name == "" {
+		name = "gomcp client"
+	}
+	body, err := json.Marshal(map[string]any{
+		"client_name":                name,
+		"redirect_uris":              []string{t.cfg.Authorizer.RedirectURL()},
+		"grant_types":                []string{"authorization_code", "refresh_token"},
+		"response_types":             []string{"code"},
+		"token_endpoint_auth_method": "none",
+	})
+	if err != nil {
+		return "", "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, asm.RegistrationEndpoint, bytes.NewReader(body))
+	if err != nil {
+		return "", "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := t.cfg.HTTPClient.Do(req)
+	if err != nil {
+		return "", "", fmt.Errorf("client registration failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return "", "", fmt.Errorf("client registration failed with status %d: %s", resp.StatusCode, msg)
+	}
+	var registered struct {
+		ClientID     string `json:"client_id"`
+		ClientSecret string `json:"client_secret"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&registered); err != nil || registered.ClientID == "" {
+		return "", "", fmt.Errorf("invalid client registration response: %v", err)
+	}
+	t.clients[asm.RegistrationEndpoint] = registeredClient{id: registered.ClientID, secret: registered.ClientSecret}
+	return registered.ClientID, registered.ClientSecret, nil
+}
+
+// requestToken posts a token request and returns the issued token.
+func (t *OAuthTransport) requestToken(ctx context.Context, endpoint, clientID, clientSecret string, form url.Values) (*OAuthToken, error) {
+	if clientSecret == "" {
+		form.Set("client_id", clientID)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if clientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(clientID), url.QueryEscape(clientSecret))
+	}
+	resp, err := t.cfg.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		AccessToken      string `json:"access_token"`
+		TokenType        string `json:"token_type"`
+		RefreshToken     string `json:"refresh_token"`
+		ExpiresIn        int64  `json:"expires_in"`
+		Scope            string `json:"scope"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("invalid token response (status %d): %w", resp.StatusCode, err)
+	}
+	if resp.StatusCode != http.StatusOK || body.Error != "" {
+		return nil, fmt.Errorf("token request failed: %s %s", body.Error, body.ErrorDescription)
+	}
+	if body.AccessToken == "" || !strings.EqualFold(body.TokenType, "bearer") {
+		return nil, fmt.Errorf("token response has no bearer access token")
+	}
+
+	token := &OAuthToken{
+		AccessToken:   body.AccessToken,
+		RefreshToken:  body.RefreshToken,
+		TokenType:     body.TokenType,
+		Scope:         body.Scope,
+		ClientID:      clientID,
+		ClientSecret:  clientSecret,
+		TokenEndpoint: endpoint,
+	}
+	if body.ExpiresIn > 0 {
+		token.Expiry = time.Now().Add(time.Duration(body.ExpiresIn) * time.Second)
+	}
+	return token, nil
+}
+
+// getFirstJSON decodes the first of urls that answers 200 into v.
+func (t *OAuthTransport) getFirstJSON(ctx context.Context, urls []string, v any) error {
+	var lastErr error
+	for _, u := range urls {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Accept", "application/json")
+		resp, err := t.cfg.HTTPClient.Do(req)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			lastErr = fmt.Errorf("GET %s: %s", u, resp.Status)
+			continue
+		}
+		err = json.NewDecoder(resp.Body).Decode(v)
+		resp.Body.Close()
+		return err
+	}
+	return lastErr
+}
+
+// wellKnownURLs returns the well-known URLs for u, with the path inserted after
+// the well-known suffix first (RFC 8414 section 3.1), then at the root.
+func wellKnownURLs(u *url.URL, name string) []string {
+	root := url.URL{Scheme: u.Scheme, Host: u.Host, Path: "/.well-known/" + name}
+	urls := []string{}
+	if path := strings.TrimSuffix(u.Path, "/"); path != "" {
+		withPath := root
+		withPath.Path += path
+		urls = append(urls, withPath.String())
+	}
+	return append(urls, root.String())
+}
+
+// canonicalResource returns the resource URI identifying a server URL (RFC 8707):
+// no query or fragment, lower case scheme and host.
+func canonicalResource(u *url.URL) string {
+	return (&url.URL{
+		Scheme: strings.ToLower(u.Scheme),
+		Host:   strings.ToLower(u.Host),
+		Path:   strings.TrimSuffix(u.Path, "/"),
+	}).String()
+}
+
+// parseBearerChallenge returns the auth-params of a Bearer WWW-Authenticate header.
+func parseBearerChallenge(header string) map[string]string {
+	params := make(map[string]string)
+	scheme, rest, _ := strings.Cut(strings.TrimSpace(header), " ")
+	if !strings.EqualFold(scheme, "Bearer") {
+		return params
+	}
+	for rest = strings.TrimSpace(rest); rest != ""; rest = strings.TrimLeft(rest, ", ") {
+		key, value, ok := strings.Cut(rest, "=")
+		if !ok {
+			break
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		if strings.HasPrefix(value, `"`) {
+			var b strings.Builder
+			i := 1
+			for ; i < len(value) && value[i] != '"'; i++ {
+				if value[i] == '\\' && i+1 < len(value) {
+					i++
+				}
+				b.WriteByte(value[i])
+			}
+			params[key] = b.String()
+			rest = value[min(i+1, len(value)):]
+		} else {
+			token, remainder, _ := strings.Cut(value, ",")
+			params[key] = strings.TrimSpace(token)
+			rest = remainder
+		}
+	}
+	return params
+}
+
+func randomString(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate random value: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// LoopbackAuthorizer opens the authorization URL with Open, typically in a browser,
+// and receives the redirect on a local HTTP listener.
+type LoopbackAuthorizer struct {
+	Open func(authorizationURL string) error
+
+	listener net.Listener
+	server   *http.Server
+	results  chan url.Values
+}
+
+// NewLoopbackAuthorizer listens on a random loopback port for the redirect until
+// Close is called.
+func NewLoopbackAuthorizer(open func(authorizationURL string) error) (*LoopbackAuthorizer, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen for oauth callback: %w", err)
+	}
+	a := &LoopbackAuthorizer{Open: open, listener: listener, results: make(chan url.Values, 1)}
+	a.server = &http.Server{Handler: http.HandlerFunc(a.handleCallback)}
+	go a.server.Serve(listener)
+	return a, nil
+}
+
+func (a *LoopbackAuthorizer) RedirectURL() string {
+	return "http://" + a.listener.Addr().String() + "/callback"
+}
+
+// Authorize opens the authorization URL and waits for the redirect.
+func (a *LoopbackAuthorizer) Authorize(ctx context.Context, authorizationURL string) (url.Values, error) {
+	// Drop callbacks left over from an abandoned flow.
+	select {
+	case <-a.results:
+	default:
+	}
+	if err := a.Open(authorizationURL); err != nil {
+		return nil, fmt.Errorf("failed to open authorization URL: %w", err)
+	}
+	select {
+	case values := <-a.results:
+		return values, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Close stops listening for redirects.
+func (a *LoopbackAuthorizer) Close() error {
+	return a.server.Close()
+}
+
+func (a *LoopbackAuthorizer) handleCallback(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/callback" {
+		http.NotFound(w, r)
+		return
+	}
+	select {
+	case a.results <- r.URL.Query():
+	default:
+	}
+	fmt.Fprintln(w, "Authorization complete. You can close this window.")
+}
@@ -0,0 +1,399 @@
+package client
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeAuthServer is a minimal OAuth 2.1 authorization server supporting dynamic
+// client registration, the authorization code flow with PKCE and refresh tokens.
+type fakeAuthServer struct {
+	*httptest.Server
+
+	mu            sync.Mutex
+	clients       map[string]string // client_id -> redirect_uri
+	codes         map[string]url.Values
+	accessTokens  map[string]string // access token -> resource
+	refreshTokens map[string]string // refresh token -> resource
+	expiresIn     int
+	issued        int
+	registrations int
+	deny          bool
+}
+
+func newFakeAuthServer(t *testing.T) *fakeAuthServer {
+	as := &fakeAuthServer{
+		clients:       make(map[string]string),
+		codes:         make(map[string]url.Values),
+		accessTokens:  make(map[string]string),
+		refreshTokens: make(map[string]string),
+		expiresIn:     3600,
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/oauth-authorization-server", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{
+			"issuer":                           as.URL,
+			"authorization_endpoint":           as.URL + "/authorize",
+			"token_endpoint":                   as.URL + "/token",
+			"registration_endpoint":            as.URL + "/register",
+			"code_challenge_methods_supported": []string{"S256"},
+		})
+	})
+	mux.HandleFunc("/register", as.handleRegister)
+	mux.HandleFunc("/authorize", as.handleAuthorize)
+	mux.HandleFunc("/token", as.handleToken)
+	as.Server = httptest.NewServer(mux)
+	t.Cleanup(as.Close)
+	return as
+}
+
+func (as *fakeAuthServer) handleRegister(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		RedirectURIs []string `json:"redirect_uris"`
+	}
+	json.NewDecoder(r.Body).Decode(&req)
+	as.mu.Lock()
+	defer as.mu.Unlock()
+	as.registrations++
+	id := fmt.Sprintf("client-%d", as.registrations)
+	as.clients[id] = req.RedirectURIs[0]
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]string{"client_id": id})
+}
+
+func (as *fakeAuthServer) handleAuthorize(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	as.mu.Lock()
+	defer as.mu.Unlock()
+	redirect, ok := as.clients[q.Get("client_id")]
+	if !ok || redirect != q.Get("redirect_uri") || q.Get("code_challenge_method") != "S256" {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+	target, _ := url.Parse(redirect)
+	values := url.Values{"state": {q.Get("state")}}
+	if as.deny {
+		values.Set("error", "access_denied")
+	} else {
+		code := fmt.Sprintf("code-%d", len(as.codes)+1)
+		as.codes[code] = q
+		values.Set("code", code)
+	}
+	target.RawQuery = values.Encode()
+	http.Redirect(w, r, target.String(), http.StatusFound)
+}
+
+func (as *fakeAuthServer) handleToken(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	as.mu.Lock()
+	defer as.mu.Unlock()
+
+	var resource string
+	switch r.PostForm.Get("grant_type") {
+	case "authorization_code":
+		auth, ok := as.codes[r.PostForm.Get("code")]
+		delete(as.codes, r.PostForm.Get("code"))
+		sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+		if !ok || auth.Get("code_challenge") != base64.RawURLEncoding.EncodeToString(sum[:]) ||
+			auth.Get("client_id") != r.PostForm.Get("client_id") || auth.Get("redirect_uri") != r.PostForm.Get("redirect_uri") {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+		resource = auth.Get("resource")
+	case "refresh_token":
+		var ok bool
+		if resource, ok = as.refreshTokens[r.PostForm.Get("refresh_token")]; !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+	}
+
+	as.issued++
+	access, refresh := fmt.Sprintf("access-%d", as.issued), fmt.Sprintf("refresh-%d", as.issued)
+	as.accessTokens[access] = resource
+	as.refreshTokens[refresh] = resource
+	json.NewEncoder(w).Encode(map[string]any{
+		"access_token":  access,
+		"token_type":    "Bearer",
+		"refresh_token": refresh,
+		"expires_in":    as.expiresIn,
+	})
+}
+
+// resourceFor reports the resource an access token was issued for.
+func (as *fakeAuthServer) resourceFor(token string) (string, bool) {
+	as.mu.Lock()
+	defer as.mu.Unlock()
+	resource, ok := as.accessTokens[token]
+	return resource, ok
+}
+
+// newProtectedServer serves an MCP-like endpoint that accepts tokens from as.
+func newProtectedServer(t *testing.T, as *fakeAuthServer) *httptest.Server {
+	var srv *httptest.Server
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/oauth-protected-resource/mcp", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{
+			"resource":              srv.URL + "/mcp",
+			"authorization_servers": []string{as.URL},
+		})
+	})
+	mux.HandleFunc("/mcp", func(w http.ResponseWriter, r *http.Request) {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if resource, ok := as.resourceFor(token); !ok || resource != srv.URL+"/mcp" {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer resource_metadata="%s/.well-known/oauth-protected-resource/mcp", scope="mcp:tools"`, srv.URL))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		w.Write(body)
+	})
+	srv = httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// redirectAuthorizer plays the browser: it follows the authorization URL and
+// captures the redirect instead of serving a callback.
+type redirectAuthorizer struct {
+	calls int
+	state string // overrides the returned state when set
+}
+
+func (a *redirectAuthorizer) RedirectURL() string { return "http://127.0.0.1:1/callback" }
+
+func (a *redirectAuthorizer) Authorize(ctx context.Context, authorizationURL string) (url.Values, error) {
+	a.calls++
+	noRedirect := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := noRedirect.Get(authorizationURL)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	location, err := resp.Location()
+	if err != nil {
+		return nil, err
+	}
+	values := location.Query()
+	if a.state != "" {
+		values.Set("state", a.state)
+	}
+	return values, nil
+}
+
+func post(t *testing.T, client *http.Client, u, body string) (*http.Response, string) {
+	t.Helper()
+	resp, err := client.Post(u, "application/json", strings.NewReader(body))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp, string(data)
+}
+
+func TestOAuthTransport_AuthorizationCodeFlow(t *testing.T) {
+	as := newFakeAuthServer(t)
+	srv := newProtectedServer(t, as)
+	authorizer := &redirectAuthorizer{}
+	store := NewMemoryTokenStore()
+	client := &http.Client{Transport: NewOAuthTransport(OAuthConfig{Authorizer: authorizer, Store: store}, nil)}
+
+	resp, body := post(t, client, srv.URL+"/mcp", `{"method": "ping"}`)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, `{"method": "ping"}`, body, "request body should be replayed after authorization")
+	assert.Equal(t, 1, authorizer.calls)
+	assert.Equal(t, 1, as.registrations)
+
+	token, err := store.LoadToken(srv.URL + "/mcp")
+	require.NoError(t, err)
+	require.NotNil(t, token)
+	assert.Equal(t, "client-1", token.ClientID)
+
+	// Later requests reuse the stored token.
+	resp, _ = post(t, client, srv.URL+"/mcp", `{"method": "tools/list"}`)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, 1, authorizer.calls)
+}
+
+func TestOAuthTransport_ReusesRegisteredClient(t *testing.T) {
+	as := newFakeAuthServer(t)
+	first, second := newProtectedServer(t, as), newProtectedServer(t, as)
+	authorizer := &redirectAuthorizer{}
+	store := NewMemoryTokenStore()
+	client := &http.Client{Transport: NewOAuthTransport(OAuthConfig{Authorizer: authorizer, Store: store}, nil)}
+
+	for _, srv := range []*httptest.Server{first, second} {
+		resp, _ := post(t, client, srv.URL+"/mcp", `{}`)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		token, err := store.LoadToken(srv.URL + "/mcp")
+		require.NoError(t, err)
+		assert.Equal(t, "client-1", token.ClientID)
+	}
+	assert.Equal(t, 2, authorizer.calls)
+	assert.Equal(t, 1, as.registrations, "both flows should use the client registered by the first")
+}
+
+func TestOAuthTransport_Refresh(t *testing.T) {
+	as := newFakeAuthServer(t)
+	as.expiresIn = 1 // expires within the refresh margin
+	srv := newProtectedServer(t, as)
+	authorizer := &redirectAuthorizer{}
+	store := NewMemoryTokenStore()
+	client := &http.Client{Transport: NewOAuthTransport(OAuthConfig{Authorizer: authorizer, Store: store}, nil)}
+
+	resp, _ := post(t, client, srv.URL+"/mcp", `{}`)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	first, _ := store.LoadToken(srv.URL + "/mcp")
+
+	resp, _ = post(t, client, srv.URL+"/mcp", `{}`)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	second, _ := store.LoadToken(srv.URL + "/mcp")
+	assert.NotEqual(t, first.AccessToken, second.AccessToken, "expired token should be refreshed")
+	assert.Equal(t, 1, authorizer.calls, "refresh should not need the user")
+}
+
+func TestOAuthTransport_Failures(t *testing.T) {
+	t.Run("State Mismatch", func(t *testing.T) {
+		as := newFakeAuthServer(t)
+		srv := newProtectedServer(t, as)
+		client := &http.Client{Transport: NewOAuthTransport(OAuthConfig{Authorizer: &redirectAuthorizer{state: "forged"}}, nil)}
+		_, err := client.Post(srv.URL+"/mcp", "application/json", strings.NewReader(`{}`))
+		assert.ErrorIs(t, err, ErrStateMismatch)
+	})
+
+	t.Run("Access Denied", func(t *testing.T) {
+		as := newFakeAuthServer(t)
+		as.deny = true
+		srv := newProtectedServer(t, as)
+		client := &http.Client{Transport: NewOAuthTransport(OAuthConfig{Authorizer: &redirectAuthorizer{}}, nil)}
+		_, err := client.Post(srv.URL+"/mcp", "application/json", strings.NewReader(`{}`))
+		assert.ErrorIs(t, err, ErrAuthorizationFailed)
+		assert.ErrorContains(t, err, "access_denied")
+	})
+
+	t.Run("Resource Mismatch", func(t *testing.T) {
+		as := newFakeAuthServer(t)
+		var srv *httptest.Server
+		srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/metadata" {
+				json.NewEncoder(w).Encode(map[string]any{
+					"resource":              "https://other.example/mcp",
+					"authorization_servers": []string{as.URL},
+				})
+				return
+			}
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer resource_metadata="%s/metadata"`, srv.URL))
+			w.WriteHeader(http.StatusUnauthorized)
+		}))
+		defer srv.Close()
+
+		authorizer := &redirectAuthorizer{}
+		client := &http.Client{Transport: NewOAuthTransport(OAuthConfig{Authorizer: authorizer}, nil)}
+		_, err := client.Post(srv.URL+"/mcp", "application/json", strings.NewReader(`{}`))
+		assert.ErrorIs(t, err, ErrAuthorizationFailed)
+		assert.ErrorContains(t, err, "https://other.example/mcp")
+		assert.Zero(t, authorizer.calls, "no token is requested for another resource")
+	})
+
+	t.Run("No Authorizer", func(t *testing.T) {
+		as := newFakeAuthServer(t)
+		srv := newProtectedServer(t, as)
+		client := &http.Client{Transport: NewOAuthTransport(OAuthConfig{}, nil)}
+		_, err := client.Post(srv.URL+"/mcp", "application/json", strings.NewReader(`{}`))
+		assert.ErrorIs(t, err, ErrAuthorizationFailed)
+	})
+}
+
+func TestLoopbackAuthorizer(t *testing.T) {
+	as := newFakeAuthServer(t)
+	srv := newProtectedServer(t, as)
+
+	authorizer, err := NewLoopbackAuthorizer(func(authorizationURL string) error {
+		// Following the redirects lands on the loopback callback, like a browser would.
+		go http.Get(authorizationURL)
+		return nil
+	})
+	require.NoError(t, err)
+	defer authorizer.Close()
+
+	client := &http.Client{Transport: NewOAuthTransport(OAuthConfig{Authorizer: authorizer}, nil)}
+	resp, _ := post(t, client, srv.URL+"/mcp", `{}`)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestParseBearerChallenge(t *testing.T) {
+	params := parseBearerChallenge(`Bearer resource_metadata="https://mcp.example/.well-known/oauth-protected-resource", error="insufficient_scope", error_description="needs \"admin\"", scope=read`)
+	assert.Equal(t, "https://mcp.example/.well-known/oauth-protected-resource", params["resource_metadata"])
+	assert.Equal(t, "insufficient_scope", params["error"])
+	assert.Equal(t, `needs "admin"`, params["error_description"])
+	assert.Equal(t, "read", params["scope"])
+
+	assert.Empty(t, parseBearerChallenge(`Basic realm="x"`))
+}
+
+// slowAuthorizer takes its time, like a user signing in.
+type slowAuthorizer struct {
+	redirectAuthorizer
+	delay time.Duration
+}
+
+func (a *slowAuthorizer) Authorize(ctx context.Context, authorizationURL string) (url.Values, error) {
+	select {
+	case <-time.After(a.delay):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	return a.redirectAuthorizer.Authorize(ctx, authorizationURL)
+}
+
+func TestEnableOAuth_FlowOutlastsRequestTimeout(t *testing.T) {
+	as := newFakeAuthServer(t)
+	srv := newProtectedServer(t, as)
+	serverURL, _ := url.Parse(srv.URL + "/mcp")
+	c := NewMCPClient(serverURL, serverURL, "test-client")
+	c.httpClient.Timeout = 200 * time.Millisecond
+	c.EnableOAuth(OAuthConfig{Authorizer: &slowAuthorizer{delay: 400 * time.Millisecond}})
+
+	resp, body := post(t, c.httpClient, srv.URL+"/mcp", `{"ok": true}`)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, `{"ok": true}`, body)
+
+	// Requests to the server stay bounded by the original timeout.
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(400 * time.Millisecond)
+	}))
+	defer slow.Close()
+	_, err := c.httpClient.Get(slow.URL)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	_, err = (&http.Client{Transport: NewOAuthTransport(OAuthConfig{
+		Authorizer:           &slowAuthorizer{delay: time.Second},
+		AuthorizationTimeout: 100 * time.Millisecond,
+	}, nil)}).Post(srv.URL+"/mcp", "application/json", strings.NewReader(`{}`))
+	assert.ErrorIs(t, err, context.DeadlineExceeded, "the flow itself is bounded")
+}
+
+func TestEnableOAuth(t *testing.T) {
+	serverURL, _ := url.Parse("http://localhost:9090/api/mcp")
+	c := NewMCPClient(serverURL, serverURL, "test-client")
+	c.EnableOAuth(OAuthConfig{Authorizer: &redirectAuthorizer{}})
+
+	assert.IsType(t, &OAuthTransport{}, c.httpClient.Transport)
+	assert.Same(t, c.httpClient.Transport, c.state.(*ClientState).httpClient.Transport, "handshake requests should be authorized too")
+}
@@ -0,0 +1,383 @@
+package client
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gomcp/types"
+	"github.com/gomcp/validate"
+)
+
+// ErrToolBlocked is returned when calling a tool whose definition changed since it
+// was approved and has not been re-approved with ApproveTool.
+var ErrToolBlocked = errors.New("tool definition changed and awaits re-approval")
+
+// ToolPin records the fingerprint of a tool definition the user approved.
+type ToolPin struct {
+	Name        string    `json:"name"`
+	Fingerprint string    `json:"fingerprint"`
+	ApprovedAt  time.Time `json:"approved_at"`
+}
+
+// PinStore persists approved tool pins, keyed by server.
+type PinStore interface {
+	LoadPins(server string) (map[string]ToolPin, error)
+	SavePins(server string, pins map[string]ToolPin) error
+}
+
+// ToolChangeKind describes how a tool differs from its pin.
+type ToolChangeKind string
+
+const (
+	ToolAdded    ToolChangeKind = "added"    // Tool was not offered by the server before
+	ToolModified ToolChangeKind = "modified" // Tool's description, schemas, annotations or security metadata changed
+	ToolRemoved  ToolChangeKind = "removed"  // Pinned tool is no longer offered
+)
+
+// ToolChange is raised when a listing differs from the pinned tool definitions.
+type ToolChange struct {
+	Server     string                `json:"server"`
+	Kind       ToolChangeKind        `json:"kind"`
+	Name       string                `json:"name"`
+	Pinned     string                `json:"pinned,omitempty"`  // Fingerprint the user approved
+	Current    string                `json:"current,omitempty"` // Fingerprint now offered by the server
+	Tool       types.ToolDescription `json:"tool"`              // Definition now offered by the server
+	DetectedAt time.Time             `json:"detected_at"`
+}
+
+// ToolChangeHandler is called for every detected tool change. Returning true approves
+// the new definition; returning false blocks the tool until ApproveTool is called.
+// The result is ignored for removed tools, whose pins are dropped.
+type ToolChangeHandler func(ctx context.Context, change ToolChange) bool
+
+// ToolFingerprint returns the hex encoded SHA-256 hash of a tool's name, description,
+// schemas, annotations and security metadata in canonical form. Annotations and the
+// tool's claimed source feed into policy decisions, so changing them counts as a
+// modification. Signatures and integrity hashes are left out, as re-signing an
+// unchanged tool does not change what it does.
+func ToolFingerprint(tool types.ToolDescription) (string, error) {
+	security := tool.Security
+	if security != nil && security.Source == "" && security.PublicKeyID == "" && security.Version == "" {
+		security = nil
+	}
+	canonical, err := validate.CanonicalToolBytes(types.ToolDescription{
+		Name:         tool.Name,
+		Description:  tool.Description,
+		InputSchema:  tool.InputSchema,
+		OutputSchema: tool.OutputSchema,
+		Annotations:  tool.Annotations,
+		Security:     security,
+	})
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(canonical)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// toolPinning holds the pinning state of a client.
+type toolPinning struct {
+	store    PinStore
+	onChange ToolChangeHandler
+	blocked  map[string]ToolChange
+}
+
+// EnableToolPinning makes the client fingerprint every tool returned by ListTools
+// and compare it against the pins kept in store for this server. Tools seen for the
+// first time on a server are pinned without asking (trust on first use); later
+// additions and modifications are passed to onChange. Without a handler, added tools
+// are pinned and modified tools are blocked.
+func (c *MCPClient) EnableToolPinning(store PinStore, onChange ToolChangeHandler) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.pinning = &toolPinning{
+		store:    store,
+		onChange: onChange,
+		blocked:  make(map[string]ToolChange),
+	}
+}
+
+// BlockedTools returns the pending changes of tools that await re-approval.
+func (c *MCPClient) BlockedTools() []ToolChange {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.pinning == nil {
+		return nil
+	}
+	changes := make([]ToolChange, 0, len(c.pinning.blocked))
+	for _, change := range c.pinning.blocked {
+		changes = append(changes, change)
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Name < changes[j].Name })
+	return changes
+}
+
+// ApproveTool pins the current definition of a blocked tool and allows calls to it again.
+func (c *MCPClient) ApproveTool(name string) error {
+	c.mu.Lock()
+	if c.pinning == nil {
+		c.mu.Unlock()
+		return errors.New("tool pinning is not enabled")
+	}
+	change, ok := c.pinning.blocked[name]
+	store := c.pinning.store
+	c.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("tool '%s' is not awaiting approval", name)
+	}
+	if change.Current == "" {
+		return fmt.Errorf("tool '%s' cannot be approved: its definition could not be fingerprinted", name)
+	}
+
+	server := c.pinServer()
+	pins, err := store.LoadPins(server)
+	if err != nil {
+		return fmt.Errorf("failed to load tool pins: %w", err)
+	}
+	if pins == nil {
+		pins = make(map[string]ToolPin)
+	}
+	pins[name] = ToolPin{Name: name, Fingerprint: change.Current, ApprovedAt: time.Now()}
+	if err := store.SavePins(server, pins); err != nil {
+		return fmt.Errorf("failed to save tool pins: %w", err)
+	}
+
+	c.mu.Lock()
+	delete(c.pinning.blocked, name)
+	c.mu.Unlock()
+	return nil
+}
+
+// checkToolBlocked returns ErrToolBlocked if the tool awaits re-approval.
+func (c *MCPClient) checkToolBlocked(name string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.pinning == nil {
+		return nil
+	}
+	if _, blocked := c.pinning.blocked[name]; blocked {
+		return fmt.Errorf("%w: '%s'", ErrToolBlocked, name)
+	}
+	return nil
+}
+
+// pinServer identifies the server pins are stored under.
+func (c *MCPClient) pinServer() string {
+	if c.initURL != nil {
+		return c.initURL.String()
+	}
+	if c.serverURL != nil {
+		return c.serverURL.String()
+	}
+	return ""
+}
+
+// verifyPins compares a tools/list result against the stored pins, raising changes
+// through the ToolChangeHandler and blocking tools whose changes were not approved.
+// Every changed tool is blocked before any handler is asked, and a block is only
+// lifted once the change is approved, so no changed tool can be called while
+// approval is pending or after verification fails part way.
+func (c *MCPClient) verifyPins(ctx context.Context, tools []types.ToolDescription) error {
+	c.mu.Lock()
+	pinning := c.pinning
+	c.mu.Unlock()
+	if pinning == nil {
+		return nil
+	}
+
+	server := c.pinServer()
+	pins, err := pinning.store.LoadPins(server)
+	if err != nil {
+		return fmt.Errorf("failed to load tool pins: %w", err)
+	}
+	// A nil map means nothing was ever pinned for this server, while an empty
+	// one means every pinned tool was removed: new tools must then be approved.
+	firstUse := pins == nil
+	if firstUse {
+		pins = make(map[string]ToolPin)
+	}
+
+	now := time.Now()
+	offered := make(map[string]bool, len(tools))
+	blocked := make(map[string]ToolChange)
+	var changes []ToolChange
+	var fingerprintErr error
+	for _, tool := range tools {
+		offered[tool.Name] = true
+		fingerprint, err := ToolFingerprint(tool)
+		if err != nil {
+			// A definition that cannot be fingerprinted cannot be approved either.
+			blocked[tool.Name] = ToolChange{Server: server, Kind: ToolModified, Name: tool.Name, Tool: tool, DetectedAt: now}
+			if fingerprintErr == nil {
+				fingerprintErr = fmt.Errorf("failed to fingerprint tool '%s': %w", tool.Name, err)
+			}
+			continue
+		}
+
+		pin, known := pins[tool.Name]
+		if known && pin.Fingerprint == fingerprint {
+			continue
+		}
+		if !known && firstUse {
+			pins[tool.Name] = ToolPin{Name: tool.Name, Fingerprint: fingerprint, ApprovedAt: now}
+			continue
+		}
+
+		change := ToolChange{
+			Server:     server,
+			Kind:       ToolAdded,
+			Name:       tool.Name,
+			Pinned:     pin.Fingerprint,
+			Current:    fingerprint,
+			Tool:       tool,
+			DetectedAt: now,
+		}
+		if known {
+			change.Kind = ToolModified
+		}
+		blocked[tool.Name] = change
+		changes = append(changes, change)
+	}
+
+	c.mu.Lock()
+	pinning.blocked = blocked
+	c.mu.Unlock()
+	if fingerprintErr != nil {
+		return fingerprintErr
+	}
+
+	for _, change := range changes {
+		approved := change.Kind == ToolAdded
+		if pinning.onChange != nil {
+			approved = pinning.onChange(ctx, change)
+		}
+		if !approved {
+			continue
+		}
+		pins[change.Name] = ToolPin{Name: change.Name, Fingerprint: change.Current, ApprovedAt: now}
+		c.mu.Lock()
+		// The block may already be lifted, or replaced by a later listing.
+		if pending, ok := pinning.blocked[change.Name]; ok && pending.Current == change.Current {
+			delete(pinning.blocked, change.Name)
+		}
+		c.mu.Unlock()
+	}
+
+	for name, pin := range pins {
+		if offered[name] {
+			continue
+		}
+		// Forget removed tools so that they go through approval again if they return.
+		delete(pins, name)
+		if pinning.onChange != nil {
+			pinning.onChange(ctx, ToolChange{Server: server, Kind: ToolRemoved, Name: name, Pinned: pin.Fingerprint, DetectedAt: now})
+		}
+	}
+
+	if err := pinning.store.SavePins(server, pins); err != nil {
+		return fmt.Errorf("failed to save tool pins: %w", err)
+	}
+	return nil
+}
+
+// MemoryPinStore keeps tool pins in memory, e.g. for tests or short-lived clients.
+type MemoryPinStore struct {
+	mu   sync.Mutex
+	pins map[string]map[string]ToolPin
+}
+
+func NewMemoryPinStore() *MemoryPinStore {
+	return &MemoryPinStore{pins: make(map[string]map[string]ToolPin)}
+}
+
+func (s *MemoryPinStore) LoadPins(server string) (map[string]ToolPin, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return copyPins(s.pins[server]), nil
+}
+
+func (s *MemoryPinStore) SavePins(server string, pins map[string]ToolPin) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pins[server] = copyPins(pins)
+	return nil
+}
+
+// FilePinStore keeps the tool pins of all servers in a single JSON file.
+type FilePinStore struct {
+	mu   sync.Mutex
+	path string
+}
+
+func NewFilePinStore(path string) *FilePinStore {
+	return &FilePinStore{path: path}
+}
+
+func (s *FilePinStore) LoadPins(server string) (map[string]ToolPin, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	all, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+	return all[server], nil
+}
+
+func (s *FilePinStore) SavePins(server string, pins map[string]ToolPin) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	all, err := s.read()
+	if err != nil {
+		return err
+	}
+	all[server] = pins
+
+	data, err := json.MarshalIndent(all, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal tool pins: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return fmt.Errorf("failed to create pin directory: %w", err)
+	}
+	// Write to a temporary file first so a crash never leaves a truncated pin file.
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write tool pins: %w", err)
+	}
+	return os.Rename(tmp, s.path)
+}
+
+func (s *FilePinStore) read() (map[string]map[string]ToolPin, error) {
+	all := make(map[string]map[string]ToolPin)
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return all, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tool pins: %w", err)
+	}
+	if err := json.Unmarshal(data, &all); err != nil {
+		return nil, fmt.Errorf("failed to decode tool pins: %w", err)
+	}
+	return all, nil
+}
+
+func copyPins(pins map[string]ToolPin) map[string]ToolPin {
+	if pins == nil {
+		return nil
+	}
+	out := make(map[string]ToolPin, len(pins))
+	for name, pin := range pins {
+		out[name] = pin
+	}
+	return out
+}
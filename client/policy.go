@@ -0,0 +1,152 @@
+package client
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/gomcp/types"
+	"github.com/gomcp/validate"
+
+	"github.com/google/uuid"
+)
+
+// SetSecurityPolicy enables policy checks on tool calls forwarded with ExecuteToolCalls.
+// metadata maps tool names to the SecurityMetadata describing their origin.
+func (c *MCPClient) SetSecurityPolicy(policy types.SecurityPolicy, metadata map[string]types.SecurityMetadata) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.policy = &policy
+	c.toolMetadata = metadata
+}
+
+// SetToolKeys sets the keys used to verify signed tool descriptions. Signed tools
+// that fail verification are denied; with RequireSignedTools in the security policy,
+// unsigned tools are denied as well.
+func (c *MCPClient) SetToolKeys(keys validate.KeyResolver) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.toolKeys = keys
+}
+
+// ExecuteToolCalls forwards the tool calls of an LLM's assistant message to the server
+// and returns one tool result message per call, in order.
+//
+// When a security policy is set, each call is evaluated before it is sent. Denied calls
+// are never forwarded and produce a failed result carrying the reason. Calls that need
+// confirmation are put to the user through the client's ElicitationHandler; without
+// one they are denied. Tools are looked up from the last ListTools call. The calls
+// are sent as one turn (see WithTurn), so the server applies its own limits per
+// message as well.
+func (c *MCPClient) ExecuteToolCalls(ctx context.Context, message types.Message) ([]types.Message, error) {
+	engine, err := c.policyEngine(message)
+	if err != nil {
+		return nil, err
+	}
+	turn := message.ID
+	if turn == "" {
+		turn = uuid.NewString()
+	}
+	ctx = WithTurn(ctx, turn)
+
+	var budget *validate.TurnBudget
+	if engine != nil {
+		budget = engine.NewTurn()
+	}
+	results := make([]types.Message, 0, len(message.ToolCalls))
+	for _, call := range message.ToolCalls {
+		if engine != nil {
+			if reason, ok := c.checkDecision(ctx, budget.Limit(engine.EvaluateCall(call))); !ok {
+				results = append(results, newToolResultMessage(call, "", types.StatusFailed, reason))
+				continue
+			}
+			// Only calls that end up allowed count towards the per-turn limit.
+			budget.Use()
+		}
+
+		var args any
+		if len(call.Arguments) > 0 {
+			args = call.Arguments
+		}
+		result, err := c.CallTool(ctx, call.FunctionName, args)
+		if err != nil {
+			results = append(results, newToolResultMessage(call, "", types.StatusError, err.Error()))
+			continue
+		}
+		status, errMsg := types.StatusSucceeded, ""
+		if result.IsError {
+			status, errMsg = types.StatusFailed, result.Content.Text()
+		}
+		resultMsg := newToolResultMessage(call, result.Content.Text(), status, errMsg)
+		resultMsg.Blocks = result.Content
+		results = append(results, resultMsg)
+	}
+	return results, nil
+}
+
+// policyEngine returns the engine to evaluate the tool calls of message with,
+// or nil when no policy is set.
+func (c *MCPClient) policyEngine(message types.Message) (*validate.PolicyEngine, error) {
+	if len(message.ToolCalls) > 0 && message.Role != types.RoleAssistant {
+		return nil, fmt.Errorf("%w: got role '%s'", validate.ErrNotAssistantMessage, message.Role)
+	}
+
+	c.mu.Lock()
+	policy, metadata, keys := c.policy, c.toolMetadata, c.toolKeys
+	tools := make([]types.ToolDescription, 0, len(c.tools))
+	for _, tool := range c.tools {
+		tools = append(tools, tool)
+	}
+	c.mu.Unlock()
+
+	if policy == nil {
+		return nil, nil
+	}
+	return validate.NewPolicyEngine(*policy, tools, metadata).WithKeys(keys), nil
+}
+
+// checkDecision reports whether a call may proceed, asking the user when the
+// policy requires confirmation. The returned reason explains a refusal.
+func (c *MCPClient) checkDecision(ctx context.Context, decision validate.PolicyDecision) (string, bool) {
+	switch {
+	case decision.Allowed():
+		return "", true
+	case decision.NeedsConfirmation():
+		c.mu.Lock()
+		handler := c.elicitation
+		c.mu.Unlock()
+		if handler == nil {
+			return fmt.Sprintf("tool call requires confirmation (%s) but no elicitation handler is set", decision.Reason), false
+		}
+		req := validate.ConfirmationRequest(decision)
+		result, err := handler(ctx, req)
+		if err != nil {
+			return fmt.Sprintf("tool call confirmation failed: %v", err), false
+		}
+		if err := validate.ValidateElicitationResult(req.RequestedSchema, result); err != nil || !validate.Confirmed(&result) {
+			return "tool call was not confirmed by the user", false
+		}
+		return "", true
+	default:
+		return fmt.Sprintf("tool call denied by policy: %s", decision.Reason), false
+	}
+}
+
+func newToolResultMessage(call types.ToolCall, content string, status types.ExecutionStatus, errMsg string) types.Message {
+	hash := sha256.Sum256([]byte(content))
+	return types.Message{
+		ID:         uuid.NewString(),
+		Role:       types.RoleTool,
+		Content:    content,
+		Timestamp:  time.Now(),
+		ToolCallID: call.ID,
+		ToolResult: &types.ToolResultMetadata{
+			ExecutionStatus: status,
+			ErrorMessage:    errMsg,
+			OutputHash:      hex.EncodeToString(hash[:]),
+			ExecutedAt:      time.Now(),
+		},
+	}
+}
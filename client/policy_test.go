@@ -0,0 +1,126 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+
+	"github.com/gomcp/mcp"
+	"github.com/gomcp/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecuteToolCalls_PolicyBlocksBeforeSending(t *testing.T) {
+	c := newMockClient()
+	c.tools["delete_file"] = types.ToolDescription{
+		Name:        "delete_file",
+		Annotations: &types.ToolAnnotations{DestructiveHint: types.Hint(true)},
+	}
+	c.tools["run_shell"] = types.ToolDescription{Name: "run_shell"}
+	c.SetSecurityPolicy(types.SecurityPolicy{
+		DisallowedTools:  []string{"run_shell"},
+		DestructiveTools: types.PolicyConfirm,
+	}, nil)
+
+	asked := 0
+	c.SetElicitationHandler(func(ctx context.Context, params mcp.ElicitRequestParams) (mcp.ElicitResult, error) {
+		asked++
+		assert.Contains(t, params.Message, "delete_file")
+		return mcp.ElicitResult{Action: mcp.ElicitDecline}, nil
+	})
+
+	message := types.Message{
+		Role: types.RoleAssistant,
+		ToolCalls: []types.ToolCall{
+			{ID: "call_1", FunctionName: "run_shell", Arguments: json.RawMessage(`{"cmd": "ls"}`)},
+			{ID: "call_2", FunctionName: "delete_file", Arguments: json.RawMessage(`{"path": "/tmp/x"}`)},
+		},
+	}
+
+	// Neither call may reach the server, which the mock client has no connection to.
+	results, err := c.ExecuteToolCalls(context.Background(), message)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, 1, asked)
+
+	for i, result := range results {
+		assert.Equal(t, types.RoleTool, result.Role)
+		assert.Equal(t, message.ToolCalls[i].ID, result.ToolCallID)
+		require.NotNil(t, result.ToolResult)
+		assert.Equal(t, types.StatusFailed, result.ToolResult.ExecutionStatus)
+	}
+	assert.Contains(t, results[0].ToolResult.ErrorMessage, "disallowed")
+	assert.Contains(t, results[1].ToolResult.ErrorMessage, "not confirmed")
+}
+
+func TestExecuteToolCalls_RejectsUserToolCalls(t *testing.T) {
+	c := newMockClient()
+	c.SetSecurityPolicy(types.SecurityPolicy{}, nil)
+
+	_, err := c.ExecuteToolCalls(context.Background(), types.Message{
+		Role:      types.RoleUser,
+		ToolCalls: []types.ToolCall{{ID: "call_1", FunctionName: "run_shell"}},
+	})
+	assert.Error(t, err)
+}
+
+func TestExecuteToolCalls_DeclinedCallsDoNotCount(t *testing.T) {
+	c, _ := newToolsServer(t, nil, mcp.CallToolResult{Content: mcp.Contents{mcp.NewTextContent("ok")}})
+	c.tools["delete_file"] = types.ToolDescription{
+		Name:        "delete_file",
+		Annotations: &types.ToolAnnotations{DestructiveHint: types.Hint(true)},
+	}
+	c.tools["read_file"] = types.ToolDescription{
+		Name:        "read_file",
+		Annotations: &types.ToolAnnotations{ReadOnlyHint: types.Hint(true)},
+	}
+	c.SetSecurityPolicy(types.SecurityPolicy{DestructiveTools: types.PolicyConfirm, MaxToolCallsPerTurn: 1}, nil)
+	c.SetElicitationHandler(func(ctx context.Context, params mcp.ElicitRequestParams) (mcp.ElicitResult, error) {
+		return mcp.ElicitResult{Action: mcp.ElicitDecline}, nil
+	})
+
+	results, err := c.ExecuteToolCalls(context.Background(), types.Message{
+		Role: types.RoleAssistant,
+		ToolCalls: []types.ToolCall{
+			{ID: "call_1", FunctionName: "delete_file"},
+			{ID: "call_2", FunctionName: "read_file"},
+			{ID: "call_3", FunctionName: "read_file"},
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+	assert.Contains(t, results[0].ToolResult.ErrorMessage, "not confirmed")
+	assert.Equal(t, types.StatusSucceeded, results[1].ToolResult.ExecutionStatus)
+	assert.Equal(t, "ok", results[1].Content)
+	assert.Contains(t, results[2].ToolResult.ErrorMessage, "limit of 1 tool calls")
+}
+
+func TestExecuteToolCalls_SendsTurn(t *testing.T) {
+	var mu sync.Mutex
+	var turns []string
+	c, _ := newToolsServerFunc(t, nil, func(params mcp.CallToolParams) mcp.CallToolResult {
+		mu.Lock()
+		defer mu.Unlock()
+		if params.Meta != nil {
+			turns = append(turns, params.Meta.Turn)
+		}
+		return mcp.CallToolResult{Content: mcp.Contents{mcp.NewTextContent("ok")}}
+	})
+
+	message := types.Message{
+		ID:   "msg_1",
+		Role: types.RoleAssistant,
+		ToolCalls: []types.ToolCall{
+			{ID: "call_1", FunctionName: "read_file"},
+			{ID: "call_2", FunctionName: "read_file"},
+		},
+	}
+	_, err := c.ExecuteToolCalls(context.Background(), message)
+	require.NoError(t, err)
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []string{"msg_1", "msg_1"}, turns, "the server can count the calls of a message as one turn")
+}
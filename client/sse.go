@@ -20,7 +20,7 @@ func (c *MCPClient) handleSSE(event, data string) error {
 		c.serverURL = endpoint
 		close(c.endpointChan)
 	case "message":
-
+		return c.handleMessage([]byte(data))
 	default:
 		c.log.Warn(fmt.Sprintf("unknown server event: %s", event))
 	}
@@ -0,0 +1,84 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/gomcp/backend"
+	mcpctx "github.com/gomcp/context"
+)
+
+// maxConflictRetries bounds how often a conflicting context update is rebased
+// onto the stored context and retried.
+const maxConflictRetries = 3
+
+// SetContextStore persists the client's context to store. Every change the client
+// makes to its context is written through, so any backend.ContextStore can be used
+// to keep conversations across restarts.
+func (c *MCPClient) SetContextStore(store backend.ContextStore) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.store = store
+}
+
+// RestoreContext loads a previously stored context and makes it the client's
+// current context.
+func (c *MCPClient) RestoreContext(ctx context.Context, id string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.store == nil {
+		return fmt.Errorf("no context store configured")
+	}
+	stored, err := c.store.Get(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to restore context %s: %w", id, err)
+	}
+	c.contexts[c.clientID] = stored
+	return nil
+}
+
+// persistContext stores the client's current context, if there is a store, when
+// the store does not hold it yet. Changes to a stored context go through
+// applyStoredUpdate instead, so they cannot overwrite another writer's changes.
+// The caller must hold c.mu.
+func (c *MCPClient) persistContext() error {
+	current, ok := c.contexts[c.clientID]
+	if c.store == nil || !ok {
+		return nil
+	}
+	if err := c.store.Put(context.Background(), current); err != nil {
+		return fmt.Errorf("failed to persist context: %w", err)
+	}
+	return nil
+}
+
+// applyStoredUpdate applies an update to current through the store, so updates
+// from several writers sharing the store are serialized. Conflicting updates are
+// rebased onto the stored context and retried. The caller must hold c.mu.
+func (c *MCPClient) applyStoredUpdate(current *mcpctx.Context, update mcpctx.ContextUpdate) error {
+	ctx := context.Background()
+	update.ID = current.ID
+	for attempt := 0; ; attempt++ {
+		updated, err := c.store.Apply(ctx, update)
+		switch {
+		case err == nil:
+			c.contexts[c.clientID] = updated
+			return nil
+		case errors.Is(err, backend.ErrNotFound):
+			// Not stored yet: apply locally and store the result.
+			if err := applyLocalUpdate(current, update); err != nil {
+				return err
+			}
+			return c.persistContext()
+		case errors.Is(err, mcpctx.ErrVersionConflict) && attempt < maxConflictRetries:
+			latest, err := c.store.Get(ctx, current.ID)
+			if err != nil {
+				return fmt.Errorf("failed to reload context after conflict: %w", err)
+			}
+			update = update.Rebase(latest)
+		default:
+			return fmt.Errorf("failed to apply context update: %w", err)
+		}
+	}
+}
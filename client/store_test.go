@@ -0,0 +1,118 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/gomcp/backend"
+	mcpctx "github.com/gomcp/context"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContextStore_WriteThrough(t *testing.T) {
+	store := backend.NewMemoryStore()
+	c := newMockClient()
+	c.SetContextStore(store)
+
+	update := mcpctx.ContextUpdate{
+		Metadata: map[string]string{"foo": "bar"},
+		Append:   []*mcpctx.MemoryBlock{{ID: "m1", Role: "user", Content: "hello"}},
+	}
+	b, _ := json.Marshal(update)
+	require.NoError(t, c.handleContextUpdate(b))
+	c.AppendAssistantResponse("hi there")
+
+	id := c.GetClientContext().ID
+	stored, err := store.Get(context.Background(), id)
+	require.NoError(t, err)
+	assert.Equal(t, "bar", stored.Metadata["foo"])
+	require.Len(t, stored.Memory, 2)
+	assert.Equal(t, "hi there", stored.Memory[1].Content)
+}
+
+func TestContextStore_Restore(t *testing.T) {
+	store := backend.NewMemoryStore()
+	saved := mcpctx.NewContext(map[string]string{"foo": "bar"})
+	saved.Memory = append(saved.Memory, &mcpctx.MemoryBlock{ID: "m1", Content: "remember me"})
+	require.NoError(t, store.Put(context.Background(), saved))
+
+	c := newMockClient()
+	assert.Error(t, c.RestoreContext(context.Background(), saved.ID), "no store configured")
+
+	c.SetContextStore(store)
+	require.NoError(t, c.RestoreContext(context.Background(), saved.ID))
+	restored := c.GetClientContext()
+	assert.Equal(t, saved.ID, restored.ID)
+	assert.Equal(t, "remember me", restored.Memory[0].Content)
+
+	err := c.RestoreContext(context.Background(), "missing")
+	assert.ErrorIs(t, err, backend.ErrNotFound)
+}
+
+func TestContextStore_UpdateConflictRebased(t *testing.T) {
+	store := backend.NewMemoryStore()
+	c := newMockClient()
+	c.SetContextStore(store)
+
+	first := mcpctx.ContextUpdate{Append: []*mcpctx.MemoryBlock{{ID: "m1", Content: "first"}}}
+	b, _ := json.Marshal(first)
+	require.NoError(t, c.handleContextUpdate(b))
+	local := c.GetClientContext()
+
+	// Another agent sharing the store appends in the meantime.
+	_, err := store.Apply(context.Background(), mcpctx.ContextUpdate{
+		ID:     local.ID,
+		Append: []*mcpctx.MemoryBlock{{ID: "m2", Content: "other agent"}},
+	})
+	require.NoError(t, err)
+
+	// This update was made against the version the client last saw.
+	stale := local.Version
+	update := mcpctx.ContextUpdate{
+		ExpectedVersion: &stale,
+		Append:          []*mcpctx.MemoryBlock{{ID: "m3", Content: "mine"}},
+	}
+	b, _ = json.Marshal(update)
+	require.NoError(t, c.handleContextUpdate(b))
+
+	stored, err := store.Get(context.Background(), local.ID)
+	require.NoError(t, err)
+	assert.Len(t, stored.Memory, 3, "no append is lost")
+	assert.Equal(t, stored.Version, c.GetClientContext().Version)
+	assert.Equal(t, "mine", c.GetClientContext().Memory[2].Content)
+}
+
+func TestContextStore_AppendsKeepOtherWriters(t *testing.T) {
+	store := backend.NewMemoryStore()
+	c := newMockClient()
+	c.SetContextStore(store)
+
+	first := mcpctx.ContextUpdate{Append: []*mcpctx.MemoryBlock{{ID: "m1", Content: "first"}}}
+	b, _ := json.Marshal(first)
+	require.NoError(t, c.handleContextUpdate(b))
+	id := c.GetClientContext().ID
+
+	// Another agent sharing the store appends after the client last saw the context.
+	_, err := store.Apply(context.Background(), mcpctx.ContextUpdate{
+		ID:     id,
+		Append: []*mcpctx.MemoryBlock{{ID: "m2", Content: "other agent"}},
+	})
+	require.NoError(t, err)
+
+	b, _ = json.Marshal(mcpctx.ContextUpdate{ID: id, Append: []*mcpctx.MemoryBlock{{ID: "m3", Content: "appended"}}})
+	require.NoError(t, c.handleMemoryAppend(b))
+	b, _ = json.Marshal(mcpctx.ContextUpdate{ID: id, Replace: []*mcpctx.MemoryBlock{{ID: "m1", Content: "replaced"}}})
+	require.NoError(t, c.handleMemoryReplace(b))
+	c.AppendAssistantResponse("hi there")
+
+	stored, err := store.Get(context.Background(), id)
+	require.NoError(t, err)
+	require.Len(t, stored.Memory, 4, "no append is lost")
+	assert.Equal(t, "replaced", stored.Memory[0].Content)
+	assert.Equal(t, "other agent", stored.Memory[1].Content)
+	assert.Equal(t, "hi there", stored.Memory[3].Content)
+	assert.Equal(t, stored.Version, c.GetClientContext().Version)
+}
@@ -0,0 +1,164 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/gomcp/mcp"
+	"github.com/gomcp/types"
+	"github.com/gomcp/validate"
+)
+
+// ErrNoStructuredContent is returned when a caller asks for structured output
+// from a tool result that does not carry any.
+var ErrNoStructuredContent = errors.New("tool result has no structured content")
+
+// ErrUnknownTool is returned when a tool is not in the server's tool listing.
+var ErrUnknownTool = errors.New("tool not offered by the server")
+
+type turnKey struct{}
+
+// WithTurn marks the tool calls made with ctx as belonging to one assistant turn,
+// so a server enforcing MaxToolCallsPerTurn counts them together. ExecuteToolCalls
+// marks its calls with the ID of the assistant message.
+func WithTurn(ctx context.Context, turn string) context.Context {
+	return context.WithValue(ctx, turnKey{}, turn)
+}
+
+func turnFrom(ctx context.Context) string {
+	turn, _ := ctx.Value(turnKey{}).(string)
+	return turn
+}
+
+// ListTools requests the tools offered by the server and caches their descriptions
+// so later tool calls can be validated against them. With tool pinning enabled,
+// the listing is checked against the pinned definitions (see EnableToolPinning).
+//
+// https://modelcontextprotocol.io/specification/2025-06-18/server/tools#listing-tools
+func (c *MCPClient) ListTools(ctx context.Context) ([]types.ToolDescription, error) {
+	resp, err := c.SendRequest(ctx, mcp.MethodToolsList, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var result types.ListToolsResult
+	if err := json.Unmarshal(resp.Bytes(), &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal tools/list result: %w", err)
+	}
+
+	c.mu.Lock()
+	c.tools = make(map[string]types.ToolDescription, len(result.Tools))
+	for _, tool := range result.Tools {
+		c.tools[tool.Name] = tool
+	}
+	c.mu.Unlock()
+
+	if err := c.verifyPins(ctx, result.Tools); err != nil {
+		return nil, err
+	}
+	return result.Tools, nil
+}
+
+// handleToolsListChanged refreshes the tool cache, and with it the tool pins,
+// after the server announced a change. The refresh runs in the background since
+// its response arrives over the same event stream as the notification.
+func (c *MCPClient) handleToolsListChanged() error {
+	go func() {
+		if _, err := c.ListTools(context.Background()); err != nil && c.log != nil {
+			c.log.Error(fmt.Sprintf("failed to refresh tools after list change: %v", err))
+		}
+	}()
+	return nil
+}
+
+// GetTool returns the cached description of a tool from the last ListTools call.
+func (c *MCPClient) GetTool(name string) (types.ToolDescription, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	tool, ok := c.tools[name]
+	return tool, ok
+}
+
+// CallTool invokes a tool on the server with the given arguments.
+// args may be any value that marshals to a JSON object, or nil. Tools blocked
+// by tool pinning are refused with ErrToolBlocked. A turn set on ctx with WithTurn
+// is sent along with the call.
+//
+// https://modelcontextprotocol.io/specification/2025-06-18/server/tools#calling-tools
+func (c *MCPClient) CallTool(ctx context.Context, name string, args any) (*mcp.CallToolResult, error) {
+	if err := c.checkToolBlocked(name); err != nil {
+		return nil, err
+	}
+	params := mcp.CallToolParams{Name: name}
+	if turn := turnFrom(ctx); turn != "" {
+		params.Meta = &mcp.RequestMeta{Turn: turn}
+	}
+	if args != nil {
+		rawArgs, err := json.Marshal(args)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal tool arguments: %w", err)
+		}
+		params.Arguments = rawArgs
+	}
+	rawParams, err := json.Marshal(params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal tools/call params: %w", err)
+	}
+
+	resp, err := c.SendRequest(ctx, mcp.MethodToolsCall, rawParams)
+	if err != nil {
+		return nil, err
+	}
+
+	var result mcp.CallToolResult
+	if err := json.Unmarshal(resp.Bytes(), &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal tools/call result: %w", err)
+	}
+	return &result, nil
+}
+
+// CallToolInto invokes a tool and decodes its structured content into out,
+// which must be a pointer. The content is validated against the tool's
+// OutputSchema; tools missing from the cached listing are looked up with
+// ListTools first, and ErrUnknownTool is returned if the server does not
+// offer the tool.
+func (c *MCPClient) CallToolInto(ctx context.Context, name string, args any, out any) (*mcp.CallToolResult, error) {
+	tool, ok := c.GetTool(name)
+	if !ok {
+		if _, err := c.ListTools(ctx); err != nil {
+			return nil, fmt.Errorf("failed to list tools: %w", err)
+		}
+		if tool, ok = c.GetTool(name); !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownTool, name)
+		}
+	}
+
+	result, err := c.CallTool(ctx, name, args)
+	if err != nil {
+		return nil, err
+	}
+	if result.IsError {
+		return result, fmt.Errorf("tool '%s' reported an error", name)
+	}
+	if err := DecodeStructuredContent(result, &tool, out); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// DecodeStructuredContent validates the structured content of a tool result
+// against the tool's OutputSchema and unmarshals it into out.
+func DecodeStructuredContent(result *mcp.CallToolResult, tool *types.ToolDescription, out any) error {
+	if len(result.StructuredContent) == 0 {
+		return ErrNoStructuredContent
+	}
+	if _, err := validate.ValidateStructuredContent(result.StructuredContent, tool); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(result.StructuredContent, out); err != nil {
+		return fmt.Errorf("failed to decode structured content: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/gomcp/codec"
+	"github.com/gomcp/mcp"
+	"github.com/gomcp/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var forecastTool = types.ToolDescription{
+	Name: "forecast",
+	OutputSchema: json.RawMessage(`{
+		"type": "object",
+		"properties": {"high": {"type": "number"}, "low": {"type": "number"}},
+		"required": ["high", "low"]
+	}`),
+}
+
+type forecast struct {
+	High float64 `json:"high"`
+	Low  float64 `json:"low"`
+}
+
+func TestDecodeStructuredContent(t *testing.T) {
+	t.Run("Valid Content", func(t *testing.T) {
+		result := &mcp.CallToolResult{StructuredContent: json.RawMessage(`{"high": 25, "low": 12.5}`)}
+
+		var out forecast
+		require.NoError(t, DecodeStructuredContent(result, &forecastTool, &out))
+		assert.Equal(t, forecast{High: 25, Low: 12.5}, out)
+	})
+
+	t.Run("Schema Violation", func(t *testing.T) {
+		result := &mcp.CallToolResult{StructuredContent: json.RawMessage(`{"high": 25}`)}
+
+		var out forecast
+		err := DecodeStructuredContent(result, &forecastTool, &out)
+		assert.ErrorContains(t, err, "low is required")
+	})
+
+	t.Run("No Structured Content", func(t *testing.T) {
+		result := &mcp.CallToolResult{Content: mcp.Contents{mcp.NewTextContent("25/12")}}
+
+		var out forecast
+		assert.ErrorIs(t, DecodeStructuredContent(result, &forecastTool, &out), ErrNoStructuredContent)
+	})
+
+	t.Run("Unknown Tool Skips Schema", func(t *testing.T) {
+		result := &mcp.CallToolResult{StructuredContent: json.RawMessage(`{"high": 1, "low": 0}`)}
+
+		var out forecast
+		require.NoError(t, DecodeStructuredContent(result, &types.ToolDescription{Name: "forecast"}, &out))
+		assert.Equal(t, 1.0, out.High)
+	})
+}
+
+// newToolsServer starts a server answering tools/list with tools and tools/call
+// with result, delivering responses over the client's message handler as the
+// event stream would. It returns the client and a count of tools/list requests.
+func newToolsServer(t *testing.T, tools []types.ToolDescription, result mcp.CallToolResult) (*MCPClient, *int) {
+	t.Helper()
+	return newToolsServerFunc(t, tools, func(mcp.CallToolParams) mcp.CallToolResult { return result })
+}
+
+// newToolsServerFunc is newToolsServer with tools/call answered by call.
+func newToolsServerFunc(t *testing.T, tools []types.ToolDescription, call func(mcp.CallToolParams) mcp.CallToolResult) (*MCPClient, *int) {
+	t.Helper()
+	c := newMockClient()
+	c.responses = make(map[int64]chan codec.JSONRPCResponse)
+	c.initialized = true
+	lists := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req codec.JSONRPCRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		resp := codec.JSONRPCResponse{JSONRPC: codec.JsonRPCVersion, ID: req.ID}
+		switch req.Method {
+		case mcp.MethodToolsList:
+			lists++
+			resp.Result = types.ListToolsResult{Tools: tools}
+		case mcp.MethodToolsCall:
+			var params mcp.CallToolParams
+			require.NoError(t, json.Unmarshal(req.Params, &params))
+			resp.Result = call(params)
+		}
+		raw, err := json.Marshal(resp)
+		require.NoError(t, err)
+		w.WriteHeader(http.StatusAccepted)
+		go c.handleMessage(raw)
+	}))
+	t.Cleanup(ts.Close)
+	c.serverURL, _ = url.Parse(ts.URL)
+	c.httpClient = ts.Client()
+	return c, &lists
+}
+
+func TestCallToolInto_FetchesUnknownTool(t *testing.T) {
+	c, lists := newToolsServer(t, []types.ToolDescription{forecastTool}, mcp.CallToolResult{
+		StructuredContent: json.RawMessage(`{"high": 25}`),
+	})
+
+	var out forecast
+	_, err := c.CallToolInto(context.Background(), "forecast", nil, &out)
+	assert.ErrorContains(t, err, "low is required", "the schema is fetched and enforced")
+	assert.Equal(t, 1, *lists)
+
+	_, err = c.CallToolInto(context.Background(), "missing", nil, &out)
+	assert.ErrorIs(t, err, ErrUnknownTool)
+	assert.Equal(t, 2, *lists)
+}
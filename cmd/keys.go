@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
+
+	"github.com/gomcp/keys"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	keyringPath string
+	keyAlg      string
+	graceWindow time.Duration
+
+	keysCmd = &cobra.Command{
+		Use:   "keys",
+		Short: "manage the signing and encryption keyring",
+		Long: "Manage the encrypted keyring used to secure payloads and sign tokens.\n" +
+			"The keyring passphrase is read from the " + keys.EnvPassphrase + " env var.",
+	}
+
+	keysGenerateCmd = &cobra.Command{
+		Use:   "generate",
+		Short: "generate the first key for an algorithm",
+		Run:   runKeysGenerateCmd,
+	}
+
+	keysListCmd = &cobra.Command{
+		Use:   "list",
+		Short: "list the keys in the keyring",
+		Run:   runKeysListCmd,
+	}
+
+	keysRotateCmd = &cobra.Command{
+		Use:   "rotate",
+		Short: "replace the active key, keeping the old one for verification during a grace window",
+		Run:   runKeysRotateCmd,
+	}
+)
+
+func init() {
+	keysCmd.PersistentFlags().StringVar(&keyringPath, "keyring", defaultKeyringPath(), "path to the keyring file")
+	keysGenerateCmd.Flags().StringVar(&keyAlg, "alg", string(keys.AlgSymmetric), "key algorithm (oct-256, Ed25519 or X25519)")
+	keysRotateCmd.Flags().StringVar(&keyAlg, "alg", string(keys.AlgSymmetric), "key algorithm (oct-256, Ed25519 or X25519)")
+	keysRotateCmd.Flags().DurationVar(&graceWindow, "grace", 72*time.Hour, "how long the retired key keeps verifying")
+
+	keysCmd.AddCommand(keysGenerateCmd, keysListCmd, keysRotateCmd)
+	rootCmd.AddCommand(keysCmd)
+}
+
+func defaultKeyringPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "keyring.json"
+	}
+	return filepath.Join(home, ".gomcp", "keyring.json")
+}
+
+func openKeyring() *keys.FileStore {
+	passphrase := os.Getenv(keys.EnvPassphrase)
+	if passphrase == "" {
+		log.Fatalf("%s env var must be set", keys.EnvPassphrase)
+	}
+	store, err := keys.OpenFileStore(keyringPath, []byte(passphrase))
+	if err != nil {
+		log.Fatalf("failed to open keyring: %v", err)
+	}
+	return store
+}
+
+func parseKeyAlg() keys.Algorithm {
+	alg, err := keys.ParseAlgorithm(keyAlg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return alg
+}
+
+func runKeysGenerateCmd(cmd *cobra.Command, args []string) {
+	alg := parseKeyAlg()
+	store := openKeyring()
+
+	if current, err := store.Active(alg); err == nil {
+		log.Fatalf("keyring already has active %s key %s; use 'gomcp keys rotate' to replace it", alg, current.ID)
+	} else if !errors.Is(err, keys.ErrNoActiveKey) {
+		log.Fatal(err)
+	}
+
+	key, err := keys.Generate(alg)
+	if err != nil {
+		log.Fatalf("failed to generate key: %v", err)
+	}
+	if err := store.Put(key); err != nil {
+		log.Fatalf("failed to store key: %v", err)
+	}
+	fmt.Printf("generated %s key %s\n", key.Algorithm, key.ID)
+}
+
+func runKeysListCmd(cmd *cobra.Command, args []string) {
+	store := openKeyring()
+	list, err := store.List()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	now := time.Now()
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "KID\tALG\tCREATED\tSTATUS\tEXPIRES")
+	for _, key := range list {
+		status, expires := "active", "-"
+		if !key.Active() {
+			status = "retired"
+		} else if key.IsPublic() {
+			status = "verify-only"
+		}
+		if key.ExpiresAt != nil {
+			expires = key.ExpiresAt.Format(time.RFC3339)
+			if !key.CanVerify(now) {
+				status = "expired"
+			}
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", key.ID, key.Algorithm, key.CreatedAt.Format(time.RFC3339), status, expires)
+	}
+	w.Flush()
+}
+
+func runKeysRotateCmd(cmd *cobra.Command, args []string) {
+	alg := parseKeyAlg()
+	store := openKeyring()
+
+	key, err := keys.Rotate(store, alg, graceWindow)
+	if err != nil {
+		log.Fatalf("failed to rotate key: %v", err)
+	}
+	fmt.Printf("rotated %s key, new active key is %s\n", key.Algorithm, key.ID)
+}
@@ -49,6 +49,9 @@ type RPCError struct {
 func (r *RPCError) ErrCode() int { return r.Code }
 func (r *RPCError) Msg() string  { return r.Message }
 
+// Error allows RPCError to be returned and inspected as a Go error.
+func (r *RPCError) Error() string { return r.Message }
+
 type Notification struct {
 	JSONRPC string          `json:"jsonrpc"`
 	Method  string          `json:"method"`
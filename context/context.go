@@ -1,9 +1,13 @@
 package context
 
 import (
+	"errors"
+	"fmt"
 	"maps"
+	"slices"
 	"time"
 
+	"github.com/gomcp/mcp"
 	"github.com/gomcp/types"
 
 	"github.com/google/uuid"
@@ -18,6 +22,7 @@ type Context struct {
 	Messages       []types.Message   `json:"messages"`
 	Metadata       map[string]string `json:"metadata,omitempty"`
 	IsArchived     bool              `json:"is_archived"`
+	Version        int64             `json:"version"` // Incremented by every applied update
 	AvailableTools []types.ToolDescription
 }
 
@@ -32,12 +37,36 @@ func NewContext(metadata map[string]string) *Context {
 	}
 }
 
-// ContextUpdate represents an update request to an existing context.
+// ContextUpdate represents an update request to an existing context. Operations
+// are applied in field order; moving a block is a Remove and an Insert of the
+// same block in one update.
 type ContextUpdate struct {
-	ID       string            `json:"id"`
-	Metadata map[string]string `json:"metadata,omitempty"`
-	Append   []*MemoryBlock    `json:"append,omitempty"`
-	Archive  *bool             `json:"archive,omitempty"`
+	ID             string            `json:"id"`
+	Metadata       map[string]string `json:"metadata,omitempty"`
+	DeleteMetadata []string          `json:"delete_metadata,omitempty"` // Metadata keys to remove
+	Pin            []string          `json:"pin,omitempty"`             // IDs of memory blocks to pin
+	Unpin          []string          `json:"unpin,omitempty"`           // IDs of memory blocks to unpin, so they can be removed
+	Remove         []string          `json:"remove,omitempty"`          // IDs of memory blocks to forget
+	Replace        []*MemoryBlock    `json:"replace,omitempty"`         // New content, and any role, blocks, time, kind, tags, metadata or expiry set, for blocks with these IDs
+	Insert         []MemoryInsert    `json:"insert,omitempty"`          // Blocks to add at a position
+	Append         []*MemoryBlock    `json:"append,omitempty"`
+	Truncate       *int              `json:"truncate,omitempty"` // Drop the oldest unpinned memory blocks until N remain
+	Archive        *bool             `json:"archive,omitempty"`
+	// Messages and AvailableTools replace the context's lists when set; an empty
+	// list clears them.
+	Messages       *[]types.Message         `json:"messages,omitempty"`
+	AvailableTools *[]types.ToolDescription `json:"available_tools,omitempty"`
+	Author         string                   `json:"author,omitempty"` // Who made the change, recorded in the context's history
+	// ExpectedVersion makes the update conditional: it is only applied if the
+	// context is still at this version.
+	ExpectedVersion *int64 `json:"expected_version,omitempty"`
+}
+
+// MemoryInsert adds blocks before the block at Index, counted after any removals.
+// An Index equal to the number of blocks adds them at the end.
+type MemoryInsert struct {
+	Index  int            `json:"index"`
+	Blocks []*MemoryBlock `json:"blocks"`
 }
 
 func NewContextUpdate() ContextUpdate {
@@ -47,28 +76,303 @@ func NewContextUpdate() ContextUpdate {
 	}
 }
 
+// BlockKind says what a memory block holds.
+type BlockKind string
+
+const (
+	KindMessage     BlockKind = "message"     // A turn of the conversation
+	KindInstruction BlockKind = "instruction" // Instructions for the model, such as a system prompt
+	KindFile        BlockKind = "file"        // An attached file
+	KindSummary     BlockKind = "summary"     // A summary of blocks it replaced
+	KindScratchpad  BlockKind = "scratchpad"  // Working notes
+)
+
 // MemoryBlock represents a single unit of contextual memory within a conversation.
 type MemoryBlock struct {
-	ID      string    `json:"id"`
-	Role    string    `json:"role"` // e.g., "user", "assistant", etc.
-	Content string    `json:"content"`
-	Time    time.Time `json:"time"`
+	ID        string            `json:"id"`
+	Role      string            `json:"role"` // e.g., "user", "assistant", etc.
+	Content   string            `json:"content"`
+	Blocks    mcp.Contents      `json:"blocks,omitempty"` // Rich content (images, audio, resources) accompanying Content
+	Time      time.Time         `json:"time"`
+	Kind      BlockKind         `json:"kind,omitempty"`
+	Tags      []string          `json:"tags,omitempty"`
+	Metadata  map[string]string `json:"metadata,omitempty"`
+	ExpiresAt *time.Time        `json:"expires_at,omitempty"` // The block is dropped once expired
+	Pinned    bool              `json:"pinned,omitempty"`     // Never pruned, truncated or removed, but still expires
+	// Tokens caches the token count of Content; zero means not yet counted. It is
+	// reset when the content changes and never serialized, so a count made by
+	// another tokenizer, or sent by a peer, is not trusted.
+	Tokens int `json:"-"`
 }
 
 func (m *MemoryBlock) UpdateContent(newContent string) {
+	if m.Content != newContent {
+		m.Tokens = 0
+	}
 	m.Content = newContent
 }
 
-// ApplyUpdate modifies the context based on the update request.
-func (ctx *Context) ApplyUpdate(update ContextUpdate) {
+// SetTTL makes the block expire ttl from now.
+func (m *MemoryBlock) SetTTL(ttl time.Duration) {
+	expires := time.Now().Add(ttl)
+	m.ExpiresAt = &expires
+}
+
+// Expired reports whether the block has expired at now.
+func (m *MemoryBlock) Expired(now time.Time) bool {
+	return m.ExpiresAt != nil && !now.Before(*m.ExpiresAt)
+}
+
+// HasTag reports whether the block is tagged with tag.
+func (m *MemoryBlock) HasTag(tag string) bool {
+	return slices.Contains(m.Tags, tag)
+}
+
+// CountTokens returns the tokens in the block's content, counting them with t
+// only if the count is not cached yet. A context should be counted with a
+// single tokenizer, since the cache does not record which one was used.
+func (m *MemoryBlock) CountTokens(t Tokenizer) int {
+	if m.Tokens == 0 {
+		m.Tokens = t.CountTokens(m.Content)
+	}
+	return m.Tokens
+}
+
+var (
+	// ErrVersionConflict is matched by every *ConflictError.
+	ErrVersionConflict = errors.New("context version conflict")
+	// ErrInvalidUpdate indicates an update that cannot be applied to the context.
+	ErrInvalidUpdate = errors.New("invalid context update")
+)
+
+// ConflictError reports an update that expected a different version of the
+// context than the one it was applied to.
+type ConflictError struct {
+	ID       string
+	Expected int64
+	Actual   int64
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("%v: context %s is at version %d, expected %d", ErrVersionConflict, e.ID, e.Actual, e.Expected)
+}
+
+func (e *ConflictError) Is(target error) bool {
+	return target == ErrVersionConflict
+}
+
+// ApplyUpdate modifies the context based on the update request and moves it to
+// the next version. The update is applied entirely or not at all: it returns a
+// *ConflictError if the update expects another version, or ErrInvalidUpdate if
+// any operation does not fit the context. Blocks that have expired, and null
+// blocks, are dropped.
+func (ctx *Context) ApplyUpdate(update ContextUpdate) error {
+	return ctx.applyUpdate(update, time.Now())
+}
+
+// applyUpdate applies an update as of now, which decides the blocks that have
+// expired and becomes the context's UpdatedAt.
+func (ctx *Context) applyUpdate(update ContextUpdate, now time.Time) error {
+	if update.ExpectedVersion != nil && *update.ExpectedVersion != ctx.Version {
+		return &ConflictError{ID: ctx.ID, Expected: *update.ExpectedVersion, Actual: ctx.Version}
+	}
+	memory, err := updateMemory(ctx.Memory, update)
+	if err != nil {
+		return err
+	}
+
+	// Nothing below can fail.
+	for _, id := range update.Pin {
+		findBlock(ctx.Memory, id).Pinned = true
+	}
+	for _, id := range update.Unpin {
+		findBlock(ctx.Memory, id).Pinned = false
+	}
+	for _, replacement := range update.Replace {
+		block := findBlock(ctx.Memory, replacement.ID)
+		block.UpdateContent(replacement.Content)
+		if replacement.Role != "" {
+			block.Role = replacement.Role
+		}
+		if replacement.Blocks != nil {
+			block.Blocks = slices.Clone(replacement.Blocks)
+		}
+		if !replacement.Time.IsZero() {
+			block.Time = replacement.Time
+		}
+		if replacement.Kind != "" {
+			block.Kind = replacement.Kind
+		}
+		if replacement.Tags != nil {
+			block.Tags = slices.Clone(replacement.Tags)
+		}
+		if replacement.Metadata != nil {
+			block.Metadata = maps.Clone(replacement.Metadata)
+		}
+		if replacement.ExpiresAt != nil {
+			expires := *replacement.ExpiresAt
+			block.ExpiresAt = &expires
+		}
+	}
+	ctx.Memory = slices.DeleteFunc(memory, func(block *MemoryBlock) bool {
+		return block == nil || block.Expired(now)
+	})
 	if update.Metadata != nil {
+		if ctx.Metadata == nil {
+			ctx.Metadata = make(map[string]string, len(update.Metadata))
+		}
 		maps.Copy(ctx.Metadata, update.Metadata)
 	}
-	if update.Append != nil {
-		ctx.Memory = append(ctx.Memory, update.Append...)
+	for _, key := range update.DeleteMetadata {
+		delete(ctx.Metadata, key)
 	}
 	if update.Archive != nil {
 		ctx.IsArchived = *update.Archive
 	}
-	ctx.UpdatedAt = time.Now()
+	if update.Messages != nil {
+		ctx.Messages = nil
+		if len(*update.Messages) > 0 {
+			ctx.Messages = slices.Clone(*update.Messages)
+		}
+	}
+	if update.AvailableTools != nil {
+		ctx.AvailableTools = nil
+		if len(*update.AvailableTools) > 0 {
+			ctx.AvailableTools = slices.Clone(*update.AvailableTools)
+		}
+	}
+	ctx.UpdatedAt = now
+	ctx.Version++
+	return nil
+}
+
+// updateMemory validates the update's memory operations and returns the resulting
+// list of blocks without modifying memory.
+func updateMemory(memory []*MemoryBlock, update ContextUpdate) ([]*MemoryBlock, error) {
+	pinned := make(map[*MemoryBlock]bool)
+	for _, block := range memory {
+		if block != nil {
+			pinned[block] = block.Pinned
+		}
+	}
+	for _, pin := range []struct {
+		ids    []string
+		pinned bool
+	}{{update.Pin, true}, {update.Unpin, false}} {
+		for _, id := range pin.ids {
+			block := findBlock(memory, id)
+			if block == nil {
+				return nil, fmt.Errorf("%w: cannot pin or unpin memory block %q: not found", ErrInvalidUpdate, id)
+			}
+			pinned[block] = pin.pinned
+		}
+	}
+
+	removed := make(map[string]bool, len(update.Remove))
+	for _, id := range update.Remove {
+		block := findBlock(memory, id)
+		if block == nil {
+			return nil, fmt.Errorf("%w: cannot remove memory block %q: not found", ErrInvalidUpdate, id)
+		}
+		if pinned[block] {
+			return nil, fmt.Errorf("%w: cannot remove memory block %q: pinned", ErrInvalidUpdate, id)
+		}
+		removed[id] = true
+	}
+	for _, replacement := range update.Replace {
+		if replacement == nil {
+			return nil, fmt.Errorf("%w: cannot replace a nil memory block", ErrInvalidUpdate)
+		}
+		if findBlock(memory, replacement.ID) == nil {
+			return nil, fmt.Errorf("%w: cannot replace memory block %q: not found", ErrInvalidUpdate, replacement.ID)
+		}
+		if removed[replacement.ID] {
+			return nil, fmt.Errorf("%w: memory block %q is both removed and replaced", ErrInvalidUpdate, replacement.ID)
+		}
+	}
+
+	result := make([]*MemoryBlock, 0, len(memory)+len(update.Append))
+	for _, block := range memory {
+		// Null blocks, as decoded from a stored "memory": [null], are dropped.
+		if block != nil && !removed[block.ID] {
+			result = append(result, block)
+		}
+	}
+	for _, insert := range update.Insert {
+		if insert.Index < 0 || insert.Index > len(result) {
+			return nil, fmt.Errorf("%w: insert index %d out of range [0, %d]", ErrInvalidUpdate, insert.Index, len(result))
+		}
+		if slices.Contains(insert.Blocks, nil) {
+			return nil, fmt.Errorf("%w: cannot insert a nil memory block", ErrInvalidUpdate)
+		}
+		result = slices.Insert(result, insert.Index, insert.Blocks...)
+	}
+	if slices.Contains(update.Append, nil) {
+		return nil, fmt.Errorf("%w: cannot append a nil memory block", ErrInvalidUpdate)
+	}
+	result = append(result, update.Append...)
+	if update.Truncate != nil {
+		if *update.Truncate < 0 {
+			return nil, fmt.Errorf("%w: negative truncate %d", ErrInvalidUpdate, *update.Truncate)
+		}
+		excess := len(result) - *update.Truncate
+		result = slices.DeleteFunc(result, func(block *MemoryBlock) bool {
+			isPinned, ok := pinned[block]
+			if !ok {
+				isPinned = block.Pinned // Inserted or appended by this update
+			}
+			if excess <= 0 || isPinned {
+				return false
+			}
+			excess--
+			return true
+		})
+	}
+	return result, nil
+}
+
+func findBlock(memory []*MemoryBlock, id string) *MemoryBlock {
+	for _, block := range memory {
+		if block != nil && block.ID == id {
+			return block
+		}
+	}
+	return nil
+}
+
+// Rebase returns a copy of the update that applies on top of c's current version.
+// Memory blocks c already holds are left out, so retrying an append after a
+// conflict does not duplicate it.
+func (update ContextUpdate) Rebase(c *Context) ContextUpdate {
+	held := make(map[string]bool, len(c.Memory))
+	for _, block := range c.Memory {
+		if block != nil {
+			held[block.ID] = true
+		}
+	}
+
+	rebased := update
+	rebased.ID = c.ID
+	// Blocks another writer already forgot need not be forgotten or pinned again.
+	stillHeld := func(ids []string) []string {
+		var kept []string
+		for _, id := range ids {
+			if held[id] {
+				kept = append(kept, id)
+			}
+		}
+		return kept
+	}
+	rebased.Pin = stillHeld(update.Pin)
+	rebased.Unpin = stillHeld(update.Unpin)
+	rebased.Remove = stillHeld(update.Remove)
+	rebased.Append = nil
+	for _, block := range update.Append {
+		if block == nil || block.ID == "" || !held[block.ID] {
+			rebased.Append = append(rebased.Append, block)
+		}
+	}
+	version := c.Version
+	rebased.ExpectedVersion = &version
+	return rebased
 }
@@ -1,9 +1,13 @@
 package context
 
 import (
+	"encoding/json"
+	"errors"
 	"testing"
 	"time"
 
+	"github.com/gomcp/mcp"
+
 	"github.com/alecthomas/assert"
 	"github.com/google/uuid"
 )
@@ -77,3 +81,237 @@ func TestMemoryBlock_UpdateContent(t *testing.T) {
 
 	assert.Equal(t, "Updated content", block.Content)
 }
+
+func TestApplyUpdate_ExpectedVersion(t *testing.T) {
+	ctx := NewContext(map[string]string{})
+	ctx.Version = 3
+
+	stale := int64(2)
+	err := ctx.ApplyUpdate(ContextUpdate{ID: ctx.ID, ExpectedVersion: &stale, Append: []*MemoryBlock{{ID: "m1"}}})
+	assert.True(t, errors.Is(err, ErrVersionConflict))
+	var conflict *ConflictError
+	assert.True(t, errors.As(err, &conflict))
+	assert.Equal(t, int64(2), conflict.Expected)
+	assert.Equal(t, int64(3), conflict.Actual)
+	assert.Empty(t, ctx.Memory, "a conflicting update is not applied")
+	assert.Equal(t, int64(3), ctx.Version)
+
+	current := int64(3)
+	err = ctx.ApplyUpdate(ContextUpdate{ID: ctx.ID, ExpectedVersion: &current, Append: []*MemoryBlock{{ID: "m1"}}})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), ctx.Version)
+}
+
+func TestContextUpdate_Rebase(t *testing.T) {
+	ctx := NewContext(map[string]string{})
+	ctx.Memory = append(ctx.Memory, &MemoryBlock{ID: "m1", Content: "already applied"})
+	ctx.Version = 5
+
+	stale := int64(1)
+	update := ContextUpdate{
+		ID:              "other",
+		ExpectedVersion: &stale,
+		Metadata:        map[string]string{"foo": "bar"},
+		Append:          []*MemoryBlock{{ID: "m1"}, {ID: "m2", Content: "new"}},
+	}
+	rebased := update.Rebase(ctx)
+	assert.Equal(t, ctx.ID, rebased.ID)
+	assert.Equal(t, int64(5), *rebased.ExpectedVersion)
+	assert.Equal(t, 1, len(rebased.Append))
+	assert.Equal(t, "m2", rebased.Append[0].ID)
+	assert.Equal(t, int64(1), *update.ExpectedVersion, "the original update is unchanged")
+
+	assert.NoError(t, ctx.ApplyUpdate(rebased))
+	assert.Equal(t, 2, len(ctx.Memory))
+	assert.Equal(t, "bar", ctx.Metadata["foo"])
+
+	// A nil block is kept, so applying the rebased update rejects it.
+	invalid := ContextUpdate{ExpectedVersion: &stale, Append: []*MemoryBlock{nil}}
+	err := ctx.ApplyUpdate(invalid.Rebase(ctx))
+	assert.True(t, errors.Is(err, ErrInvalidUpdate), err)
+}
+
+func newTestMemory() *Context {
+	ctx := NewContext(map[string]string{"keep": "1", "drop": "2"})
+	for _, id := range []string{"a", "b", "c", "d"} {
+		ctx.Memory = append(ctx.Memory, &MemoryBlock{ID: id, Role: "user", Content: id})
+	}
+	return ctx
+}
+
+func memoryIDs(ctx *Context) []string {
+	ids := make([]string, len(ctx.Memory))
+	for i, block := range ctx.Memory {
+		ids[i] = block.ID
+	}
+	return ids
+}
+
+func TestApplyUpdate_MemoryOperations(t *testing.T) {
+	ctx := newTestMemory()
+	truncate := 4
+	err := ctx.ApplyUpdate(ContextUpdate{
+		ID:             ctx.ID,
+		DeleteMetadata: []string{"drop", "missing"},
+		Remove:         []string{"b"},
+		Replace:        []*MemoryBlock{{ID: "c", Role: "system", Content: "replaced"}},
+		Insert:         []MemoryInsert{{Index: 0, Blocks: []*MemoryBlock{{ID: "first"}}}, {Index: 2, Blocks: []*MemoryBlock{{ID: "middle"}}}},
+		Append:         []*MemoryBlock{{ID: "last"}},
+		Truncate:       &truncate,
+	})
+	assert.NoError(t, err)
+
+	// a, c, d -> first, a, middle, c, d, last -> truncated to the last 4.
+	assert.Equal(t, []string{"middle", "c", "d", "last"}, memoryIDs(ctx))
+	assert.Equal(t, "replaced", ctx.Memory[1].Content)
+	assert.Equal(t, "system", ctx.Memory[1].Role)
+	assert.Equal(t, map[string]string{"keep": "1"}, ctx.Metadata)
+	assert.Equal(t, int64(1), ctx.Version)
+}
+
+func TestApplyUpdate_Move(t *testing.T) {
+	ctx := newTestMemory()
+	d := ctx.Memory[3]
+	err := ctx.ApplyUpdate(ContextUpdate{
+		ID:     ctx.ID,
+		Remove: []string{"d"},
+		Insert: []MemoryInsert{{Index: 0, Blocks: []*MemoryBlock{d}}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"d", "a", "b", "c"}, memoryIDs(ctx))
+}
+
+func TestApplyUpdate_InvalidIsNotApplied(t *testing.T) {
+	negative := -1
+	tests := map[string]ContextUpdate{
+		"remove unknown":     {Remove: []string{"x"}},
+		"replace unknown":    {Replace: []*MemoryBlock{{ID: "x", Content: "new"}}},
+		"remove and replace": {Remove: []string{"a"}, Replace: []*MemoryBlock{{ID: "a"}}},
+		"insert past end":    {Remove: []string{"a"}, Insert: []MemoryInsert{{Index: 4}}},
+		"insert negative":    {Insert: []MemoryInsert{{Index: -1}}},
+		"negative truncate":  {Truncate: &negative},
+		"pin unknown":        {Pin: []string{"x"}},
+		"remove pinned":      {Pin: []string{"a"}, Remove: []string{"a"}},
+		"append nil":         {Append: []*MemoryBlock{nil}},
+		"insert nil":         {Insert: []MemoryInsert{{Index: 0, Blocks: []*MemoryBlock{nil}}}},
+		"replace nil":        {Replace: []*MemoryBlock{nil}},
+	}
+	for name, update := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := newTestMemory()
+			// Valid operations in the same update must not be applied either.
+			update.Metadata = map[string]string{"new": "value"}
+			update.Replace = append(update.Replace, &MemoryBlock{ID: "b", Content: "changed"})
+
+			err := ctx.ApplyUpdate(update)
+			assert.True(t, errors.Is(err, ErrInvalidUpdate), err)
+			assert.Equal(t, []string{"a", "b", "c", "d"}, memoryIDs(ctx))
+			assert.Equal(t, "b", ctx.Memory[1].Content)
+			assert.Equal(t, 2, len(ctx.Metadata))
+			assert.Equal(t, int64(0), ctx.Version)
+		})
+	}
+}
+
+func TestApplyUpdate_Pinning(t *testing.T) {
+	ctx := newTestMemory()
+	ctx.Memory[0].Pinned = true
+	keep := 2
+
+	err := ctx.ApplyUpdate(ContextUpdate{Remove: []string{"a"}})
+	assert.True(t, errors.Is(err, ErrInvalidUpdate), "pinned blocks cannot be removed")
+
+	assert.NoError(t, ctx.ApplyUpdate(ContextUpdate{Pin: []string{"c"}, Truncate: &keep}))
+	assert.Equal(t, []string{"a", "c"}, memoryIDs(ctx), "truncation drops the oldest unpinned blocks")
+	assert.True(t, ctx.Memory[1].Pinned)
+
+	assert.NoError(t, ctx.ApplyUpdate(ContextUpdate{Unpin: []string{"a"}, Remove: []string{"a"}}))
+	assert.Equal(t, []string{"c"}, memoryIDs(ctx))
+}
+
+func TestApplyUpdate_Expiry(t *testing.T) {
+	ctx := newTestMemory()
+	past := time.Now().Add(-time.Minute)
+	ctx.Memory[1].ExpiresAt = &past
+	ctx.Memory[2].Pinned = true
+	ctx.Memory[2].ExpiresAt = &past
+	ctx.Memory[3].SetTTL(time.Hour)
+
+	assert.NoError(t, ctx.ApplyUpdate(ContextUpdate{
+		Append: []*MemoryBlock{{ID: "e", ExpiresAt: &past}},
+	}))
+	assert.Equal(t, []string{"a", "d"}, memoryIDs(ctx), "expired blocks are dropped, pinned or not")
+	assert.False(t, ctx.Memory[1].Expired(time.Now()))
+	assert.True(t, ctx.Memory[1].Expired(time.Now().Add(2*time.Hour)))
+}
+
+func TestApplyUpdate_ReplaceBlockFields(t *testing.T) {
+	ctx := newTestMemory()
+	ctx.Memory[0].Tokens = 5
+	ctx.Memory[0].Tags = []string{"old"}
+	expires := time.Now().Add(time.Hour)
+
+	assert.NoError(t, ctx.ApplyUpdate(ContextUpdate{Replace: []*MemoryBlock{{
+		ID:        "a",
+		Content:   "a file",
+		Kind:      KindFile,
+		Tags:      []string{"attachment"},
+		Metadata:  map[string]string{"path": "notes.txt"},
+		ExpiresAt: &expires,
+		Pinned:    true,
+	}}}))
+	block := ctx.Memory[0]
+	assert.Equal(t, KindFile, block.Kind)
+	assert.True(t, block.HasTag("attachment"))
+	assert.False(t, block.HasTag("old"))
+	assert.Equal(t, "notes.txt", block.Metadata["path"])
+	assert.True(t, block.ExpiresAt.Equal(expires))
+	assert.False(t, block.Pinned, "pinning is changed with Pin and Unpin")
+	assert.Equal(t, 0, block.Tokens, "new content invalidates the token count")
+	assert.Equal(t, 2, block.CountTokens(wordTokenizer{}))
+	assert.Equal(t, 2, block.Tokens)
+}
+
+func TestMemoryBlock_TokensNotSerialized(t *testing.T) {
+	var block MemoryBlock
+	assert.NoError(t, json.Unmarshal([]byte(`{"id": "a", "content": "two words", "tokens": 1}`), &block))
+	assert.Equal(t, 2, block.CountTokens(wordTokenizer{}), "a count from the wire is not trusted")
+
+	data, err := json.Marshal(&block)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), "tokens")
+}
+
+func TestApplyUpdate_NullBlocksInMemory(t *testing.T) {
+	var ctx Context
+	assert.NoError(t, json.Unmarshal([]byte(`{"id": "a", "memory": [null, {"id": "x"}]}`), &ctx))
+	assert.NoError(t, ctx.ApplyUpdate(ContextUpdate{ID: ctx.ID}))
+	assert.Equal(t, []string{"x"}, memoryIDs(&ctx), "null blocks are dropped")
+
+	assert.NoError(t, json.Unmarshal([]byte(`{"id": "a", "memory": [null, {"id": "x"}]}`), &ctx))
+	rebased := ContextUpdate{Replace: []*MemoryBlock{{ID: "x", Content: "edited"}}, Truncate: new(int)}.Rebase(&ctx)
+	assert.NoError(t, ctx.ApplyUpdate(rebased))
+	assert.Empty(t, ctx.Memory)
+}
+
+func TestMemoryBlock_RichBlocks(t *testing.T) {
+	block := MemoryBlock{
+		ID:      "shot",
+		Role:    "user",
+		Content: "see the screenshot",
+		Blocks:  mcp.Contents{mcp.NewImageContent([]byte("png"), "image/png"), mcp.NewResourceLink("file:///notes.txt", "notes")},
+	}
+	data, err := json.Marshal(block)
+	assert.NoError(t, err)
+	var decoded MemoryBlock
+	assert.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, block.Blocks, decoded.Blocks)
+
+	ctx := NewContext(nil)
+	ctx.Memory = append(ctx.Memory, &decoded)
+	audio := mcp.Contents{mcp.NewAudioContent([]byte("wav"), "audio/wav")}
+	assert.NoError(t, ctx.ApplyUpdate(ContextUpdate{Replace: []*MemoryBlock{{ID: "shot", Content: "listen", Blocks: audio}}}))
+	assert.Equal(t, audio, ctx.Memory[0].Blocks)
+	assert.NoError(t, ctx.ApplyUpdate(ContextUpdate{Replace: []*MemoryBlock{{ID: "shot", Content: "still listen"}}}))
+	assert.Equal(t, audio, ctx.Memory[0].Blocks, "unset blocks are left unchanged")
+}
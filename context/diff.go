@@ -0,0 +1,215 @@
+package context
+
+import (
+	"bytes"
+	"encoding/json"
+	"maps"
+	"slices"
+
+	"github.com/gomcp/types"
+)
+
+// Diff returns the update that turns old into new when applied to old. Memory
+// blocks are matched by ID: blocks that disappear are removed, changed blocks are
+// replaced, and only blocks whose relative order changed are moved. Contexts with
+// missing or duplicate block IDs fall back to rewriting the whole memory.
+// Messages and AvailableTools are replaced as a whole when they differ.
+func Diff(old, new *Context) ContextUpdate {
+	update := ContextUpdate{ID: new.ID}
+
+	for _, key := range slices.Sorted(maps.Keys(new.Metadata)) {
+		if value, ok := old.Metadata[key]; !ok || value != new.Metadata[key] {
+			if update.Metadata == nil {
+				update.Metadata = make(map[string]string)
+			}
+			update.Metadata[key] = new.Metadata[key]
+		}
+	}
+	for _, key := range slices.Sorted(maps.Keys(old.Metadata)) {
+		if _, ok := new.Metadata[key]; !ok {
+			update.DeleteMetadata = append(update.DeleteMetadata, key)
+		}
+	}
+	if old.IsArchived != new.IsArchived {
+		archived := new.IsArchived
+		update.Archive = &archived
+	}
+
+	if !sameList(old.Messages, new.Messages) {
+		messages := append([]types.Message{}, new.Messages...)
+		update.Messages = &messages
+	}
+	if !sameList(old.AvailableTools, new.AvailableTools) {
+		tools := append([]types.ToolDescription{}, new.AvailableTools...)
+		update.AvailableTools = &tools
+	}
+
+	diffMemory(old.Memory, new.Memory, &update)
+	return update
+}
+
+// diffMemory adds the memory operations that turn old into new to update.
+func diffMemory(old, new []*MemoryBlock, update *ContextUpdate) {
+	if !uniqueIDs(old) || !uniqueIDs(new) {
+		// Without usable IDs blocks cannot be addressed, so replace them all.
+		for _, block := range old {
+			if block != nil && block.Pinned && block.ID != "" && !slices.Contains(update.Unpin, block.ID) {
+				update.Unpin = append(update.Unpin, block.ID)
+			}
+		}
+		if len(old) > 0 || len(new) > 0 {
+			update.Append = new
+			keep := len(new)
+			update.Truncate = &keep
+		}
+		return
+	}
+
+	newByID := make(map[string]*MemoryBlock, len(new))
+	for _, block := range new {
+		newByID[block.ID] = block
+	}
+
+	// Blocks in both that can be changed in place are candidates to stay where they
+	// are. A block whose role, time or other optional field is cleared cannot be
+	// expressed as a replacement, so it is removed and inserted again instead.
+	var oldKept []string
+	for _, block := range old {
+		if updated, ok := newByID[block.ID]; ok && replaceable(block, updated) {
+			oldKept = append(oldKept, block.ID)
+		}
+	}
+	var newKept []string
+	oldKeptSet := setOf(oldKept)
+	for _, block := range new {
+		if oldKeptSet[block.ID] {
+			newKept = append(newKept, block.ID)
+		}
+	}
+	stay := setOf(longestCommonSubsequence(oldKept, newKept))
+
+	oldByID := make(map[string]*MemoryBlock, len(old))
+	for _, block := range old {
+		oldByID[block.ID] = block
+		if !stay[block.ID] {
+			if block.Pinned {
+				update.Unpin = append(update.Unpin, block.ID)
+			}
+			update.Remove = append(update.Remove, block.ID)
+		}
+	}
+
+	// After the removals memory holds the staying blocks in new's order, so walking
+	// new and inserting every other block at its final index rebuilds it exactly.
+	for i := 0; i < len(new); {
+		block := new[i]
+		if stay[block.ID] {
+			oldBlock := oldByID[block.ID]
+			switch {
+			case block.Pinned && !oldBlock.Pinned:
+				update.Pin = append(update.Pin, block.ID)
+			case !block.Pinned && oldBlock.Pinned:
+				update.Unpin = append(update.Unpin, block.ID)
+			}
+			// Pinning is not part of a replacement.
+			replacement := *block
+			replacement.Pinned = oldBlock.Pinned
+			if !sameBlock(oldBlock, &replacement) {
+				update.Replace = append(update.Replace, block)
+			}
+			i++
+			continue
+		}
+		start := i
+		for i < len(new) && !stay[new[i].ID] {
+			i++
+		}
+		if i == len(new) {
+			update.Append = new[start:]
+		} else {
+			update.Insert = append(update.Insert, MemoryInsert{Index: start, Blocks: new[start:i]})
+		}
+	}
+}
+
+// replaceable reports whether a replacement can turn block into updated, given
+// that replacements leave an empty role, zero time, and unset blocks, kind, tags,
+// metadata and expiry unchanged.
+func replaceable(block, updated *MemoryBlock) bool {
+	return (updated.Role != "" || block.Role == "") && (!updated.Time.IsZero() || block.Time.IsZero()) &&
+		(len(updated.Blocks) > 0 || len(block.Blocks) == 0) &&
+		(updated.Kind != "" || block.Kind == "") && (len(updated.Tags) > 0 || len(block.Tags) == 0) &&
+		(len(updated.Metadata) > 0 || len(block.Metadata) == 0) && (updated.ExpiresAt != nil || block.ExpiresAt == nil)
+}
+
+// sameBlock compares everything but the cached token count.
+func sameBlock(a, b *MemoryBlock) bool {
+	sameExpiry := a.ExpiresAt == nil && b.ExpiresAt == nil ||
+		a.ExpiresAt != nil && b.ExpiresAt != nil && a.ExpiresAt.Equal(*b.ExpiresAt)
+	return a.ID == b.ID && a.Role == b.Role && a.Content == b.Content && sameList(a.Blocks, b.Blocks) && a.Time.Equal(b.Time) &&
+		a.Kind == b.Kind && slices.Equal(a.Tags, b.Tags) && maps.Equal(a.Metadata, b.Metadata) &&
+		sameExpiry && a.Pinned == b.Pinned
+}
+
+// sameList reports whether a and b encode to the same JSON, counting a nil list
+// and an empty one as the same.
+func sameList[T any](a, b []T) bool {
+	if len(a) == 0 || len(b) == 0 {
+		return len(a) == len(b)
+	}
+	encodedA, errA := json.Marshal(a)
+	encodedB, errB := json.Marshal(b)
+	return errA == nil && errB == nil && bytes.Equal(encodedA, encodedB)
+}
+
+func uniqueIDs(memory []*MemoryBlock) bool {
+	seen := make(map[string]bool, len(memory))
+	for _, block := range memory {
+		if block == nil || block.ID == "" || seen[block.ID] {
+			return false
+		}
+		seen[block.ID] = true
+	}
+	return true
+}
+
+func setOf(ids []string) map[string]bool {
+	set := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		set[id] = true
+	}
+	return set
+}
+
+// longestCommonSubsequence returns the longest sequence of IDs found in both a
+// and b in the same order.
+func longestCommonSubsequence(a, b []string) []string {
+	lengths := make([][]int, len(a)+1)
+	for i := range lengths {
+		lengths[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lengths[i][j] = lengths[i+1][j+1] + 1
+			} else {
+				lengths[i][j] = max(lengths[i+1][j], lengths[i][j+1])
+			}
+		}
+	}
+
+	var common []string
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i] == b[j]:
+			common = append(common, a[i])
+			i++
+			j++
+		case lengths[i+1][j] >= lengths[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+	return common
+}
@@ -0,0 +1,236 @@
+package context
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/gomcp/mcp"
+	"github.com/gomcp/types"
+
+	"github.com/alecthomas/assert"
+)
+
+func cloneForTest(t *testing.T, c *Context) *Context {
+	var clone Context
+	assert.NoError(t, deepCopy(c, &clone))
+	return &clone
+}
+
+func assertSameState(t *testing.T, want, got *Context) {
+	t.Helper()
+	wantMemory, _ := json.Marshal(want.Memory)
+	gotMemory, _ := json.Marshal(got.Memory)
+	assert.Equal(t, string(wantMemory), string(gotMemory))
+	assert.Equal(t, len(want.Metadata), len(got.Metadata))
+	for k, v := range want.Metadata {
+		assert.Equal(t, v, got.Metadata[k])
+	}
+	assert.Equal(t, want.IsArchived, got.IsArchived)
+	assert.True(t, sameList(want.Messages, got.Messages), "messages differ")
+	assert.True(t, sameList(want.AvailableTools, got.AvailableTools), "tools differ")
+}
+
+func TestDiff_Minimal(t *testing.T) {
+	old := newTestMemory()
+	new := cloneForTest(t, old)
+	new.Metadata["keep"] = "changed"
+	delete(new.Metadata, "drop")
+	new.Memory[2].Content = "edited"
+	new.Memory = append(new.Memory[:1], new.Memory[2:]...) // forget b
+	new.Memory = append(new.Memory, &MemoryBlock{ID: "e", Content: "appended"})
+
+	update := Diff(old, new)
+	assert.Equal(t, map[string]string{"keep": "changed"}, update.Metadata)
+	assert.Equal(t, []string{"drop"}, update.DeleteMetadata)
+	assert.Equal(t, []string{"b"}, update.Remove)
+	assert.Equal(t, 1, len(update.Replace))
+	assert.Equal(t, "c", update.Replace[0].ID)
+	assert.Equal(t, 0, len(update.Insert))
+	assert.Equal(t, 1, len(update.Append))
+	assert.Zero(t, update.Truncate)
+
+	assert.NoError(t, old.ApplyUpdate(update))
+	assertSameState(t, new, old)
+}
+
+func TestDiff_Move(t *testing.T) {
+	old := newTestMemory()
+	new := cloneForTest(t, old)
+	new.Memory = []*MemoryBlock{new.Memory[3], new.Memory[0], new.Memory[1], new.Memory[2]}
+
+	update := Diff(old, new)
+	assert.Equal(t, []string{"d"}, update.Remove, "only the block that moved is touched")
+	assert.Equal(t, []MemoryInsert{{Index: 0, Blocks: []*MemoryBlock{new.Memory[0]}}}, update.Insert)
+
+	assert.NoError(t, old.ApplyUpdate(update))
+	assertSameState(t, new, old)
+}
+
+func TestDiff_Unchanged(t *testing.T) {
+	old := newTestMemory()
+	update := Diff(old, cloneForTest(t, old))
+	assert.Equal(t, ContextUpdate{ID: old.ID}, update)
+
+	patch, err := JSONPatch(old, cloneForTest(t, old))
+	assert.NoError(t, err)
+	assert.Empty(t, patch)
+}
+
+func TestDiff_MessagesAndTools(t *testing.T) {
+	old := newTestMemory()
+	new := cloneForTest(t, old)
+	new.Messages = append(new.Messages, types.Message{ID: "m1", Role: "user", Content: "hello"})
+	new.AvailableTools = append(new.AvailableTools, types.ToolDescription{Name: "get_weather", InputSchema: json.RawMessage(`{"type":"object"}`)})
+
+	update := Diff(old, new)
+	assert.NotZero(t, update.Messages)
+	assert.NotZero(t, update.AvailableTools)
+	assert.Zero(t, update.Remove)
+	updated := cloneForTest(t, old)
+	assert.NoError(t, updated.ApplyUpdate(update))
+	assertSameState(t, new, updated)
+
+	// Clearing the lists survives the update being recorded as JSON.
+	var recorded ContextUpdate
+	assert.NoError(t, deepCopy(Diff(new, old), &recorded))
+	assert.NoError(t, updated.ApplyUpdate(recorded))
+	assert.Zero(t, updated.Messages)
+	assert.Zero(t, updated.AvailableTools)
+}
+
+func TestDiff_BlockFields(t *testing.T) {
+	old := newTestMemory()
+	old.Memory[0].Pinned = true
+	old.Memory[1].Tags = []string{"draft"}
+	new := cloneForTest(t, old)
+	new.Memory[0].Pinned = false
+	new.Memory[2].Pinned = true
+	new.Memory[2].Kind = KindScratchpad
+	new.Memory[1].Tags = nil // cannot be cleared by a replacement
+	new.Memory[3].Tokens = 7 // only a cache
+
+	update := Diff(old, new)
+	assert.Equal(t, []string{"c"}, update.Pin)
+	assert.Equal(t, []string{"a"}, update.Unpin)
+	assert.Equal(t, []string{"b"}, update.Remove)
+	assert.Equal(t, 1, len(update.Replace))
+	assert.Equal(t, "c", update.Replace[0].ID)
+
+	assert.NoError(t, old.ApplyUpdate(update))
+	new.Memory[3].Tokens = 0
+	assertSameState(t, new, old)
+}
+
+func TestDiff_DuplicateIDsRewriteMemory(t *testing.T) {
+	old := newTestMemory()
+	old.Memory = append(old.Memory, &MemoryBlock{ID: "a", Content: "duplicate"})
+	new := newTestMemory()
+	new.Memory = new.Memory[1:]
+
+	update := Diff(old, new)
+	assert.NotZero(t, update.Truncate)
+	assert.NoError(t, old.ApplyUpdate(update))
+	assertSameState(t, new, old)
+}
+
+func randomContext(r *rand.Rand, ids []string) *Context {
+	ctx := NewContext(map[string]string{})
+	for _, key := range []string{"a", "b", "c", "d"} {
+		if r.Intn(2) == 0 {
+			ctx.Metadata[key] = fmt.Sprint(r.Intn(3))
+		}
+	}
+	ctx.IsArchived = r.Intn(2) == 0
+	for _, i := range r.Perm(len(ids)) {
+		if r.Intn(3) == 0 {
+			continue
+		}
+		block := &MemoryBlock{ID: ids[i], Content: fmt.Sprint(r.Intn(2))}
+		if r.Intn(2) == 0 {
+			block.Role = []string{"user", "assistant"}[r.Intn(2)]
+		}
+		if r.Intn(2) == 0 {
+			block.Time = time.Unix(int64(r.Intn(2)), 0).UTC()
+		}
+		if r.Intn(2) == 0 {
+			block.Tags = []string{fmt.Sprint(r.Intn(2))}
+		}
+		if r.Intn(3) == 0 {
+			block.Blocks = mcp.Contents{mcp.NewResourceLink(fmt.Sprintf("file:///%d", r.Intn(2)), "file")}
+		}
+		block.Pinned = r.Intn(4) == 0
+		ctx.Memory = append(ctx.Memory, block)
+	}
+	for i := r.Intn(3); i > 0; i-- {
+		ctx.Messages = append(ctx.Messages, types.Message{ID: fmt.Sprint(r.Intn(2)), Role: "user", Content: fmt.Sprint(r.Intn(2))})
+	}
+	for i := r.Intn(3); i > 0; i-- {
+		ctx.AvailableTools = append(ctx.AvailableTools, types.ToolDescription{Name: fmt.Sprint(r.Intn(2)), InputSchema: json.RawMessage(`{}`)})
+	}
+	return ctx
+}
+
+func TestDiff_RoundTrip(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	ids := []string{"a", "b", "c", "d", "e", "f", "g", "h"}
+	for i := 0; i < 500; i++ {
+		old := randomContext(r, ids)
+		new := randomContext(r, ids)
+		new.ID = old.ID
+
+		updated := cloneForTest(t, old)
+		assert.NoError(t, updated.ApplyUpdate(Diff(old, new)), "iteration %d", i)
+		assertSameState(t, new, updated)
+
+		patch, err := JSONPatch(old, new)
+		assert.NoError(t, err)
+		patched, err := ApplyJSONPatch(old, patch)
+		assert.NoError(t, err, "iteration %d", i)
+		want, _ := json.Marshal(new)
+		got, _ := json.Marshal(patched)
+		assert.Equal(t, string(want), string(got), "iteration %d", i)
+	}
+}
+
+func TestJSONPatch_Operations(t *testing.T) {
+	old := newTestMemory()
+	new := cloneForTest(t, old)
+	new.Metadata["path/key"] = "escaped"
+	new.Memory = new.Memory[1:]
+	new.Memory[0].Content = "edited"
+
+	patch, err := JSONPatch(old, new)
+	assert.NoError(t, err)
+	var ops []string
+	for _, op := range patch {
+		ops = append(ops, op.Op+" "+op.Path)
+	}
+	assert.Equal(t, []string{
+		"remove /memory/0",
+		"replace /memory/0", // b, now first
+		"add /metadata/path~1key",
+	}, ops)
+}
+
+func TestApplyJSONPatch_Invalid(t *testing.T) {
+	ctx := newTestMemory()
+	_, err := ApplyJSONPatch(ctx, []PatchOperation{
+		{Op: "replace", Path: "/metadata/keep", Value: json.RawMessage(`"changed"`)},
+		{Op: "remove", Path: "/memory/9"},
+	})
+	assert.True(t, errors.Is(err, ErrInvalidPatch))
+	assert.Equal(t, "1", ctx.Metadata["keep"], "the context is not modified")
+
+	patched, err := ApplyJSONPatch(ctx, []PatchOperation{
+		{Op: "test", Path: "/memory/0/id", Value: json.RawMessage(`"a"`)},
+		{Op: "move", From: "/memory/0", Path: "/memory/-"},
+		{Op: "copy", From: "/metadata/keep", Path: "/metadata/copied"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "a", patched.Memory[3].ID)
+	assert.Equal(t, "1", patched.Metadata["copied"])
+}
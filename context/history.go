@@ -0,0 +1,112 @@
+package context
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	// ErrVersionNotFound indicates a version that is not in a context's history.
+	ErrVersionNotFound = errors.New("context version not found")
+	// ErrInvalidHistory indicates a history that cannot be replayed.
+	ErrInvalidHistory = errors.New("invalid context history")
+)
+
+// ContextEvent is one entry in a context's append-only history. Each event moves
+// the context to the next version, either by applying an update or by replacing
+// the whole state with a snapshot (on creation, replacement and revert).
+type ContextEvent struct {
+	ContextID string         `json:"context_id"`
+	Version   int64          `json:"version"`
+	Author    string         `json:"author,omitempty"`
+	Time      time.Time      `json:"time"`
+	Update    *ContextUpdate `json:"update,omitempty"`   // Change applied at this version
+	Snapshot  *Context       `json:"snapshot,omitempty"` // Full state at this version
+}
+
+// NewUpdateEvent records an applied update as the given version.
+func NewUpdateEvent(update ContextUpdate, version int64) (ContextEvent, error) {
+	var recorded ContextUpdate
+	if err := deepCopy(update, &recorded); err != nil {
+		return ContextEvent{}, err
+	}
+	return ContextEvent{
+		ContextID: update.ID,
+		Version:   version,
+		Author:    update.Author,
+		Time:      time.Now(),
+		Update:    &recorded,
+	}, nil
+}
+
+// NewSnapshotEvent records the full state of c, which must already carry its new
+// version.
+func NewSnapshotEvent(c *Context, author string) (ContextEvent, error) {
+	var snapshot Context
+	if err := deepCopy(c, &snapshot); err != nil {
+		return ContextEvent{}, err
+	}
+	return ContextEvent{
+		ContextID: c.ID,
+		Version:   c.Version,
+		Author:    author,
+		Time:      time.Now(),
+		Snapshot:  &snapshot,
+	}, nil
+}
+
+// Rebuild replays a history, oldest event first, and returns the context as it
+// was at version. The history must start with a snapshot.
+func Rebuild(events []ContextEvent, version int64) (*Context, error) {
+	var state *Context
+	for i, event := range events {
+		if event.Version > version {
+			break
+		}
+		switch {
+		case event.Snapshot != nil:
+			state = new(Context)
+			if err := deepCopy(event.Snapshot, state); err != nil {
+				return nil, err
+			}
+		case event.Update != nil && state != nil:
+			// Copy the update so replacements cannot modify blocks in the history.
+			var update ContextUpdate
+			if err := deepCopy(event.Update, &update); err != nil {
+				return nil, err
+			}
+			// The recorded update was already checked against its expected version.
+			update.ExpectedVersion = nil
+			// Blocks expire as they did when the update was first applied.
+			if err := state.applyUpdate(update, event.Time); err != nil {
+				return nil, fmt.Errorf("%w: event %d: %w", ErrInvalidHistory, i, err)
+			}
+		default:
+			return nil, fmt.Errorf("%w: event %d at version %d", ErrInvalidHistory, i, event.Version)
+		}
+		state.Version = event.Version
+	}
+	if state == nil || state.Version != version {
+		return nil, fmt.Errorf("%w: %d", ErrVersionNotFound, version)
+	}
+
+	// Updates share memory blocks with the history, so hand back an independent copy.
+	var rebuilt Context
+	if err := deepCopy(state, &rebuilt); err != nil {
+		return nil, err
+	}
+	return &rebuilt, nil
+}
+
+func deepCopy(src, dst any) error {
+	data, err := json.Marshal(src)
+	if err != nil {
+		return fmt.Errorf("failed to copy context: %w", err)
+	}
+	if err := json.Unmarshal(data, dst); err != nil {
+		return fmt.Errorf("failed to copy context: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package context
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/alecthomas/assert"
+)
+
+func TestRebuild(t *testing.T) {
+	ctx := NewContext(map[string]string{"foo": "bar"})
+	ctx.Version = 1
+	created, err := NewSnapshotEvent(ctx, "alice")
+	assert.NoError(t, err)
+
+	update := ContextUpdate{
+		ID:       ctx.ID,
+		Author:   "bob",
+		Metadata: map[string]string{"foo": "baz"},
+		Append:   []*MemoryBlock{{ID: "m1", Content: "hello"}},
+	}
+	ctx.ApplyUpdate(update)
+	assert.Equal(t, int64(2), ctx.Version)
+	updated, err := NewUpdateEvent(update, ctx.Version)
+	assert.NoError(t, err)
+	assert.Equal(t, "bob", updated.Author)
+
+	// Recorded events do not share state with the caller.
+	update.Append[0].Content = "changed"
+	events := []ContextEvent{created, updated}
+
+	v1, err := Rebuild(events, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "bar", v1.Metadata["foo"])
+	assert.Empty(t, v1.Memory)
+
+	v2, err := Rebuild(events, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), v2.Version)
+	assert.Equal(t, "baz", v2.Metadata["foo"])
+	assert.Equal(t, "hello", v2.Memory[0].Content)
+	assert.True(t, updated.Time.Equal(v2.UpdatedAt))
+
+	_, err = Rebuild(events, 3)
+	assert.True(t, errors.Is(err, ErrVersionNotFound))
+	_, err = Rebuild(events[1:], 2)
+	assert.True(t, errors.Is(err, ErrInvalidHistory))
+}
+
+func TestApplyUpdate_Version(t *testing.T) {
+	ctx := &Context{}
+	ctx.ApplyUpdate(ContextUpdate{Metadata: map[string]string{"foo": "bar"}})
+	assert.Equal(t, int64(1), ctx.Version)
+	assert.Equal(t, "bar", ctx.Metadata["foo"], "metadata is merged into a nil map")
+}
@@ -0,0 +1,360 @@
+package context
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/gomcp/types"
+
+	"github.com/google/uuid"
+)
+
+// Lineage metadata set by Fork.
+const (
+	MetadataParentID    = "parent_id"    // ID of the context a fork was made from
+	MetadataForkVersion = "fork_version" // Version of the parent when the fork was made
+)
+
+// ErrMergeConflict is matched by every *MergeError.
+var ErrMergeConflict = errors.New("context merge conflict")
+
+// Fork copies ctx into a new context that records its lineage, so parallel
+// branches of a conversation can later be merged back with Merge.
+func Fork(ctx *Context) (*Context, error) {
+	var fork Context
+	if err := deepCopy(ctx, &fork); err != nil {
+		return nil, err
+	}
+	fork.ID = uuid.NewString()
+	fork.CreatedAt = time.Now()
+	fork.UpdatedAt = fork.CreatedAt
+	fork.Version = 0
+	if fork.Metadata == nil {
+		fork.Metadata = make(map[string]string)
+	}
+	fork.Metadata[MetadataParentID] = ctx.ID
+	fork.Metadata[MetadataForkVersion] = strconv.FormatInt(ctx.Version, 10)
+	return &fork, nil
+}
+
+// MemoryOrder decides how merged memory blocks are ordered.
+type MemoryOrder int
+
+const (
+	OrderAppend        MemoryOrder = iota // Base blocks, then blocks added by a, then by b
+	OrderChronological                    // All blocks by MemoryBlock.Time, oldest first
+)
+
+// Resolution decides which branch wins when both changed the same thing.
+type Resolution int
+
+const (
+	LastWriterWins Resolution = iota // The branch updated most recently wins
+	PreferA                          // Branch a always wins
+	PreferB                          // Branch b always wins
+)
+
+// MergeStrategy configures Merge. The zero value appends new blocks, drops
+// blocks added by both branches with the same ID, and lets the most recently
+// updated branch win conflicts.
+type MergeStrategy struct {
+	Order          MemoryOrder
+	KeepDuplicates bool // Keep both blocks when the branches add blocks with the same ID
+	Resolve        Resolution
+	FailOnConflict bool // Return a *MergeError instead of resolving conflicts
+}
+
+// MergeConflict describes one thing both branches changed differently.
+type MergeConflict struct {
+	Kind   string `json:"kind"` // "metadata", "memory" or "tool"
+	Key    string `json:"key"`  // Metadata key, memory block ID or tool name
+	A      string `json:"a"`    // Value in branch a, empty if removed
+	B      string `json:"b"`    // Value in branch b, empty if removed
+	Winner string `json:"winner"`
+}
+
+// MergeResult is the merged context and every conflict that was resolved.
+type MergeResult struct {
+	Context   *Context        `json:"context"`
+	Conflicts []MergeConflict `json:"conflicts,omitempty"`
+}
+
+// MergeError reports conflicts a merge was not allowed to resolve.
+type MergeError struct {
+	Conflicts []MergeConflict
+}
+
+func (e *MergeError) Error() string {
+	return fmt.Sprintf("%v: %d conflicts", ErrMergeConflict, len(e.Conflicts))
+}
+
+func (e *MergeError) Is(target error) bool {
+	return target == ErrMergeConflict
+}
+
+// Merge combines two branches a and b forked from base with a three-way merge.
+// Changes made by only one branch are kept; changes made by both are resolved by
+// the strategy and reported. The result continues base as its next version with
+// base's lineage. None of the inputs are modified.
+func Merge(base, a, b *Context, strategy MergeStrategy) (*MergeResult, error) {
+	m := merger{strategy: strategy, winner: "b"}
+	switch {
+	case strategy.Resolve == PreferA, strategy.Resolve == LastWriterWins && a.UpdatedAt.After(b.UpdatedAt):
+		m.winner = "a"
+	}
+
+	var merged Context
+	if err := deepCopy(base, &merged); err != nil {
+		return nil, err
+	}
+	merged.Metadata = m.metadata(base, a, b)
+	merged.IsArchived = m.archived(base, a, b)
+
+	merged.Memory = m.memory(base.Memory, a.Memory, b.Memory)
+	merged.Messages = m.messages(base.Messages, a.Messages, b.Messages)
+	merged.AvailableTools = m.tools(base.AvailableTools, a.AvailableTools, b.AvailableTools)
+
+	if strategy.FailOnConflict && len(m.conflicts) > 0 {
+		return nil, &MergeError{Conflicts: m.conflicts}
+	}
+	// The merged blocks still belong to the branches, so return an independent copy.
+	var result Context
+	if err := deepCopy(&merged, &result); err != nil {
+		return nil, err
+	}
+	result.UpdatedAt = time.Now()
+	result.Version = base.Version + 1
+	return &MergeResult{Context: &result, Conflicts: m.conflicts}, nil
+}
+
+type merger struct {
+	strategy  MergeStrategy
+	winner    string // "a" or "b"
+	conflicts []MergeConflict
+}
+
+// pick chooses between two conflicting values and records the conflict.
+func pick[T any](m *merger, kind, key string, a, b T, describe func(T) string) T {
+	m.conflicts = append(m.conflicts, MergeConflict{Kind: kind, Key: key, A: describe(a), B: describe(b), Winner: m.winner})
+	if m.winner == "a" {
+		return a
+	}
+	return b
+}
+
+func (m *merger) metadata(base, a, b *Context) map[string]string {
+	keys := make(map[string]bool)
+	for _, c := range []*Context{base, a, b} {
+		for key := range c.Metadata {
+			keys[key] = true
+		}
+	}
+
+	merged := make(map[string]string)
+	for _, key := range slices.Sorted(maps.Keys(keys)) {
+		if key == MetadataParentID || key == MetadataForkVersion {
+			// The merged context keeps base's lineage, not the branches'.
+			if value, ok := base.Metadata[key]; ok {
+				merged[key] = value
+			}
+			continue
+		}
+		type entry struct {
+			value string
+			ok    bool
+		}
+		var baseEntry, aEntry, bEntry entry
+		baseEntry.value, baseEntry.ok = base.Metadata[key]
+		aEntry.value, aEntry.ok = a.Metadata[key]
+		bEntry.value, bEntry.ok = b.Metadata[key]
+
+		result := aEntry
+		switch {
+		case aEntry == bEntry, bEntry != baseEntry && aEntry == baseEntry:
+			result = bEntry
+		case aEntry != baseEntry && bEntry != baseEntry:
+			result = pick(m, "metadata", key, aEntry, bEntry, func(e entry) string { return e.value })
+		}
+		if result.ok {
+			merged[key] = result.value
+		}
+	}
+	if len(merged) == 0 && base.Metadata == nil {
+		return nil
+	}
+	return merged
+}
+
+func (m *merger) archived(base, a, b *Context) bool {
+	switch {
+	case a.IsArchived == b.IsArchived:
+		return a.IsArchived
+	case a.IsArchived != base.IsArchived:
+		return a.IsArchived
+	default:
+		return b.IsArchived
+	}
+}
+
+func (m *merger) memory(base, a, b []*MemoryBlock) []*MemoryBlock {
+	baseByID := blocksByID(base)
+	aByID := blocksByID(a)
+	bByID := blocksByID(b)
+	describe := func(block *MemoryBlock) string {
+		if block == nil {
+			return ""
+		}
+		return block.Content
+	}
+
+	var merged []*MemoryBlock
+	for _, block := range base {
+		inA, inB := aByID[block.ID], bByID[block.ID]
+		changedA := inA == nil || !sameBlock(block, inA)
+		changedB := inB == nil || !sameBlock(block, inB)
+
+		var result *MemoryBlock
+		switch {
+		case !changedA:
+			result = inB
+		case !changedB:
+			result = inA
+		case inA != nil && inB != nil && sameBlock(inA, inB):
+			result = inA
+		case inA == nil && inB == nil:
+			result = nil
+		case inA == nil || inB == nil:
+			// Removed on one side and edited on the other: keep the edit rather
+			// than lose it, and report it.
+			winner := "a"
+			result = inA
+			if result == nil {
+				result, winner = inB, "b"
+			}
+			m.conflicts = append(m.conflicts, MergeConflict{Kind: "memory", Key: block.ID, A: describe(inA), B: describe(inB), Winner: winner})
+		default:
+			result = pick(m, "memory", block.ID, inA, inB, describe)
+		}
+		if result != nil {
+			merged = append(merged, result)
+		}
+	}
+
+	seen := make(map[string]*MemoryBlock)
+	for _, block := range merged {
+		seen[block.ID] = block
+	}
+	for _, branch := range [][]*MemoryBlock{a, b} {
+		for _, block := range branch {
+			if baseByID[block.ID] != nil {
+				continue
+			}
+			if existing := seen[block.ID]; existing != nil && !m.strategy.KeepDuplicates && block.ID != "" {
+				if !sameBlock(existing, block) {
+					// Both branches added a different block with this ID.
+					winner := pick(m, "memory", block.ID, existing, block, describe)
+					*existing = *winner
+				}
+				continue
+			}
+			copied := *block
+			merged = append(merged, &copied)
+			seen[block.ID] = &copied
+		}
+	}
+
+	if m.strategy.Order == OrderChronological {
+		slices.SortStableFunc(merged, func(x, y *MemoryBlock) int {
+			return x.Time.Compare(y.Time)
+		})
+	}
+	return merged
+}
+
+// messages treats each branch's messages as an append-only log after base.
+func (m *merger) messages(base, a, b []types.Message) []types.Message {
+	merged := slices.Clone(base)
+	seen := make(map[string]bool)
+	for _, message := range base {
+		seen[message.ID] = true
+	}
+	for _, branch := range [][]types.Message{a, b} {
+		for i, message := range branch {
+			if i < len(base) || (message.ID != "" && seen[message.ID] && !m.strategy.KeepDuplicates) {
+				continue
+			}
+			seen[message.ID] = true
+			merged = append(merged, message)
+		}
+	}
+	if m.strategy.Order == OrderChronological {
+		slices.SortStableFunc(merged, func(x, y types.Message) int {
+			return x.Timestamp.Compare(y.Timestamp)
+		})
+	}
+	return merged
+}
+
+// tools keeps every tool either branch knows, matched by name.
+func (m *merger) tools(base, a, b []types.ToolDescription) []types.ToolDescription {
+	index := func(tools []types.ToolDescription) map[string]types.ToolDescription {
+		byName := make(map[string]types.ToolDescription, len(tools))
+		for _, tool := range tools {
+			byName[tool.Name] = tool
+		}
+		return byName
+	}
+	baseByName, aByName, bByName := index(base), index(a), index(b)
+	describe := func(tool types.ToolDescription) string {
+		data, _ := json.Marshal(tool)
+		return string(data)
+	}
+	same := func(x, y types.ToolDescription) bool { return describe(x) == describe(y) }
+
+	var merged []types.ToolDescription
+	added := make(map[string]bool)
+	for _, tools := range [][]types.ToolDescription{base, a, b} {
+		for _, tool := range tools {
+			if added[tool.Name] {
+				continue
+			}
+			added[tool.Name] = true
+
+			baseTool, inBase := baseByName[tool.Name]
+			aTool, inA := aByName[tool.Name]
+			bTool, inB := bByName[tool.Name]
+			switch {
+			case !inA && !inB:
+			case !inA:
+				// Removed by a: keep it only if b added or changed it.
+				if !inBase || !same(bTool, baseTool) {
+					merged = append(merged, bTool)
+				}
+			case !inB:
+				if !inBase || !same(aTool, baseTool) {
+					merged = append(merged, aTool)
+				}
+			case same(aTool, bTool), inBase && same(aTool, baseTool):
+				merged = append(merged, bTool)
+			case inBase && same(bTool, baseTool):
+				merged = append(merged, aTool)
+			default:
+				merged = append(merged, pick(m, "tool", tool.Name, aTool, bTool, describe))
+			}
+		}
+	}
+	return merged
+}
+
+func blocksByID(memory []*MemoryBlock) map[string]*MemoryBlock {
+	byID := make(map[string]*MemoryBlock, len(memory))
+	for _, block := range memory {
+		byID[block.ID] = block
+	}
+	return byID
+}
@@ -0,0 +1,147 @@
+package context
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gomcp/mcp"
+	"github.com/gomcp/types"
+
+	"github.com/alecthomas/assert"
+)
+
+func forkForTest(t *testing.T, base *Context) *Context {
+	fork, err := Fork(base)
+	assert.NoError(t, err)
+	return fork
+}
+
+func TestFork(t *testing.T) {
+	base := newTestMemory()
+	base.Version = 7
+	fork := forkForTest(t, base)
+
+	assert.NotEqual(t, base.ID, fork.ID)
+	assert.Equal(t, base.ID, fork.Metadata[MetadataParentID])
+	assert.Equal(t, "7", fork.Metadata[MetadataForkVersion])
+	assert.Equal(t, memoryIDs(base), memoryIDs(fork))
+
+	fork.Memory[0].Content = "changed in fork"
+	assert.Equal(t, "a", base.Memory[0].Content, "forks do not share blocks")
+	_, ok := base.Metadata[MetadataParentID]
+	assert.False(t, ok)
+}
+
+func TestMerge_IndependentChanges(t *testing.T) {
+	base := newTestMemory()
+	base.Messages = []types.Message{{ID: "q", Content: "question"}}
+	base.AvailableTools = []types.ToolDescription{{Name: "search", InputSchema: json.RawMessage(`{}`)}}
+	a := forkForTest(t, base)
+	b := forkForTest(t, base)
+
+	assert.NoError(t, a.ApplyUpdate(ContextUpdate{
+		Metadata: map[string]string{"branch_a": "1"},
+		Remove:   []string{"b"},
+		Append:   []*MemoryBlock{{ID: "from-a", Content: "a's finding"}},
+	}))
+	a.Messages = append(a.Messages, types.Message{ID: "ra", Content: "answer a"})
+	a.AvailableTools = append(a.AvailableTools, types.ToolDescription{Name: "fetch", InputSchema: json.RawMessage(`{}`)})
+
+	assert.NoError(t, b.ApplyUpdate(ContextUpdate{
+		DeleteMetadata: []string{"drop"},
+		Replace:        []*MemoryBlock{{ID: "c", Content: "edited by b"}},
+		Append:         []*MemoryBlock{{ID: "from-b", Content: "b's finding"}},
+	}))
+	b.Messages = append(b.Messages, types.Message{ID: "rb", Content: "answer b"})
+	image := mcp.Contents{mcp.NewImageContent([]byte("png"), "image/png")}
+	assert.NoError(t, b.ApplyUpdate(ContextUpdate{Replace: []*MemoryBlock{{ID: "d", Content: "d", Blocks: image}}}))
+
+	result, err := Merge(base, a, b, MergeStrategy{})
+	assert.NoError(t, err)
+	assert.Empty(t, result.Conflicts)
+
+	merged := result.Context
+	assert.Equal(t, base.ID, merged.ID)
+	assert.Equal(t, base.Version+1, merged.Version)
+	assert.Equal(t, []string{"a", "c", "d", "from-a", "from-b"}, memoryIDs(merged))
+	assert.Equal(t, "edited by b", merged.Memory[1].Content)
+	assert.Equal(t, image, merged.Memory[2].Blocks, "changed blocks are merged")
+	assert.Equal(t, map[string]string{"keep": "1", "branch_a": "1"}, merged.Metadata, "lineage is not merged")
+	assert.Equal(t, 3, len(merged.Messages))
+	assert.Equal(t, 2, len(merged.AvailableTools))
+}
+
+func TestMerge_Chronological(t *testing.T) {
+	base := NewContext(nil)
+	a := forkForTest(t, base)
+	b := forkForTest(t, base)
+	start := time.Unix(1700000000, 0).UTC()
+	a.Memory = []*MemoryBlock{{ID: "a1", Time: start}, {ID: "a2", Time: start.Add(2 * time.Second)}}
+	b.Memory = []*MemoryBlock{{ID: "b1", Time: start.Add(time.Second)}, {ID: "b2", Time: start.Add(3 * time.Second)}}
+
+	result, err := Merge(base, a, b, MergeStrategy{Order: OrderChronological})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a1", "b1", "a2", "b2"}, memoryIDs(result.Context))
+
+	result, err = Merge(base, a, b, MergeStrategy{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a1", "a2", "b1", "b2"}, memoryIDs(result.Context))
+}
+
+func TestMerge_Dedupe(t *testing.T) {
+	base := NewContext(nil)
+	a := forkForTest(t, base)
+	b := forkForTest(t, base)
+	shared := &MemoryBlock{ID: "shared", Content: "both saw this"}
+	a.Memory = []*MemoryBlock{shared}
+	b.Memory = []*MemoryBlock{shared}
+
+	result, err := Merge(base, a, b, MergeStrategy{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"shared"}, memoryIDs(result.Context))
+	assert.Empty(t, result.Conflicts)
+
+	result, err = Merge(base, a, b, MergeStrategy{KeepDuplicates: true})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"shared", "shared"}, memoryIDs(result.Context))
+}
+
+func TestMerge_Conflicts(t *testing.T) {
+	base := newTestMemory()
+	a := forkForTest(t, base)
+	b := forkForTest(t, base)
+	a.Metadata["keep"] = "from a"
+	a.Memory[0].Content = "a's edit"
+	b.Metadata["keep"] = "from b"
+	b.Memory[0].Content = "b's edit"
+	b.Memory = b.Memory[:3] // b forgets d, which a edits
+	a.Memory[3].Content = "a's d"
+	a.UpdatedAt = time.Now().Add(time.Minute) // a wrote last
+
+	result, err := Merge(base, a, b, MergeStrategy{})
+	assert.NoError(t, err)
+	merged := result.Context
+	assert.Equal(t, "from a", merged.Metadata["keep"])
+	assert.Equal(t, "a's edit", merged.Memory[0].Content)
+	assert.Equal(t, "a's d", merged.Memory[3].Content, "an edit wins over a removal")
+	assert.Equal(t, []MergeConflict{
+		{Kind: "metadata", Key: "keep", A: "from a", B: "from b", Winner: "a"},
+		{Kind: "memory", Key: "a", A: "a's edit", B: "b's edit", Winner: "a"},
+		{Kind: "memory", Key: "d", A: "a's d", B: "", Winner: "a"},
+	}, result.Conflicts)
+
+	result, err = Merge(base, a, b, MergeStrategy{Resolve: PreferB})
+	assert.NoError(t, err)
+	assert.Equal(t, "from b", result.Context.Metadata["keep"])
+	assert.Equal(t, "b's edit", result.Context.Memory[0].Content)
+
+	_, err = Merge(base, a, b, MergeStrategy{FailOnConflict: true})
+	assert.True(t, errors.Is(err, ErrMergeConflict))
+	var mergeErr *MergeError
+	assert.True(t, errors.As(err, &mergeErr))
+	assert.Equal(t, 3, len(mergeErr.Conflicts))
+
+	assert.Equal(t, "a", base.Memory[0].Content, "inputs are not modified")
+}
@@ -0,0 +1,414 @@
+package context
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidPatch indicates a JSON Patch that cannot be applied to a context.
+var ErrInvalidPatch = errors.New("invalid json patch")
+
+// PatchOperation is a single RFC 6902 JSON Patch operation.
+//
+// https://www.rfc-editor.org/rfc/rfc6902
+type PatchOperation struct {
+	Op    string          `json:"op"` // add, remove, replace, move, copy or test
+	Path  string          `json:"path"`
+	From  string          `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// JSONPatch returns an RFC 6902 patch that turns the JSON encoding of old into
+// that of new. Memory changes are expressed with the same minimal operations as
+// Diff, so services can sync contexts by exchanging only the delta.
+func JSONPatch(old, new *Context) ([]PatchOperation, error) {
+	oldFields, err := jsonFields(old)
+	if err != nil {
+		return nil, err
+	}
+	newFields, err := jsonFields(new)
+	if err != nil {
+		return nil, err
+	}
+
+	var patch []PatchOperation
+	for _, key := range slices.Sorted(maps.Keys(mergeKeys(oldFields, newFields))) {
+		switch key {
+		case "metadata":
+			ops, err := metadataPatch(old.Metadata, new.Metadata, oldFields[key], newFields[key])
+			if err != nil {
+				return nil, err
+			}
+			patch = append(patch, ops...)
+		case "memory":
+			ops, err := memoryPatch(old.Memory, new.Memory, oldFields[key], newFields[key])
+			if err != nil {
+				return nil, err
+			}
+			patch = append(patch, ops...)
+		default:
+			patch = append(patch, valuePatch("/"+escapePointer(key), oldFields[key], newFields[key])...)
+		}
+	}
+	return patch, nil
+}
+
+// ApplyJSONPatch applies an RFC 6902 patch to the JSON encoding of c and returns
+// the resulting context. c is not modified. The patch is applied entirely or not
+// at all.
+func ApplyJSONPatch(c *Context, patch []PatchOperation) (*Context, error) {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode context: %w", err)
+	}
+	doc, err := decodeJSON(data)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, op := range patch {
+		if doc, err = applyOperation(doc, op); err != nil {
+			return nil, fmt.Errorf("%w: operation %d (%s %s): %w", ErrInvalidPatch, i, op.Op, op.Path, err)
+		}
+	}
+
+	data, err = json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode patched context: %w", err)
+	}
+	var patched Context
+	if err := json.Unmarshal(data, &patched); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidPatch, err)
+	}
+	return &patched, nil
+}
+
+func jsonFields(c *Context) (map[string]json.RawMessage, error) {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode context: %w", err)
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("failed to encode context: %w", err)
+	}
+	return fields, nil
+}
+
+func mergeKeys(a, b map[string]json.RawMessage) map[string]bool {
+	keys := make(map[string]bool, len(a)+len(b))
+	for key := range a {
+		keys[key] = true
+	}
+	for key := range b {
+		keys[key] = true
+	}
+	return keys
+}
+
+// valuePatch replaces a whole value, adding or removing it if it is only on one side.
+func valuePatch(path string, old, new json.RawMessage) []PatchOperation {
+	switch {
+	case old == nil && new == nil, bytes.Equal(old, new):
+		return nil
+	case old == nil:
+		return []PatchOperation{{Op: "add", Path: path, Value: new}}
+	case new == nil:
+		return []PatchOperation{{Op: "remove", Path: path}}
+	default:
+		return []PatchOperation{{Op: "replace", Path: path, Value: new}}
+	}
+}
+
+func metadataPatch(old, new map[string]string, oldRaw, newRaw json.RawMessage) ([]PatchOperation, error) {
+	if oldRaw == nil || newRaw == nil {
+		return valuePatch("/metadata", oldRaw, newRaw), nil
+	}
+	var patch []PatchOperation
+	for _, key := range slices.Sorted(maps.Keys(old)) {
+		if _, ok := new[key]; !ok {
+			patch = append(patch, PatchOperation{Op: "remove", Path: "/metadata/" + escapePointer(key)})
+		}
+	}
+	for _, key := range slices.Sorted(maps.Keys(new)) {
+		value, err := json.Marshal(new[key])
+		if err != nil {
+			return nil, err
+		}
+		if previous, ok := old[key]; !ok {
+			patch = append(patch, PatchOperation{Op: "add", Path: "/metadata/" + escapePointer(key), Value: value})
+		} else if previous != new[key] {
+			patch = append(patch, PatchOperation{Op: "replace", Path: "/metadata/" + escapePointer(key), Value: value})
+		}
+	}
+	return patch, nil
+}
+
+// memoryPatch replays the memory operations of Diff against block indexes.
+func memoryPatch(old, new []*MemoryBlock, oldRaw, newRaw json.RawMessage) ([]PatchOperation, error) {
+	var update ContextUpdate
+	diffMemory(old, new, &update)
+	if update.Truncate != nil || string(oldRaw) == "null" || string(newRaw) == "null" {
+		return valuePatch("/memory", oldRaw, newRaw), nil
+	}
+
+	ids := make([]string, len(old))
+	for i, block := range old {
+		ids[i] = block.ID
+	}
+	var patch []PatchOperation
+	for _, id := range update.Remove {
+		i := slices.Index(ids, id)
+		patch = append(patch, PatchOperation{Op: "remove", Path: "/memory/" + strconv.Itoa(i)})
+		ids = slices.Delete(ids, i, i+1)
+	}
+
+	add := func(op, path string, block *MemoryBlock) error {
+		value, err := json.Marshal(block)
+		if err != nil {
+			return err
+		}
+		patch = append(patch, PatchOperation{Op: op, Path: path, Value: value})
+		return nil
+	}
+	// A replace operation rewrites the whole block, so it also carries pinning.
+	changed := setOf(slices.Concat(update.Pin, update.Unpin))
+	for _, block := range update.Replace {
+		changed[block.ID] = true
+	}
+	for _, block := range new {
+		i := slices.Index(ids, block.ID)
+		if !changed[block.ID] || i < 0 {
+			continue
+		}
+		if err := add("replace", "/memory/"+strconv.Itoa(i), block); err != nil {
+			return nil, err
+		}
+	}
+	for _, insert := range update.Insert {
+		for offset, block := range insert.Blocks {
+			if err := add("add", "/memory/"+strconv.Itoa(insert.Index+offset), block); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for _, block := range update.Append {
+		if err := add("add", "/memory/-", block); err != nil {
+			return nil, err
+		}
+	}
+	return patch, nil
+}
+
+func decodeJSON(data []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var value any
+	if err := dec.Decode(&value); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidPatch, err)
+	}
+	return value, nil
+}
+
+func applyOperation(doc any, op PatchOperation) (any, error) {
+	switch op.Op {
+	case "add", "replace", "test":
+		if op.Value == nil {
+			return nil, errors.New("missing value")
+		}
+		value, err := decodeJSON(op.Value)
+		if err != nil {
+			return nil, err
+		}
+		if op.Op == "test" {
+			current, err := getPointer(doc, op.Path)
+			if err != nil {
+				return nil, err
+			}
+			if !jsonEqual(current, value) {
+				return nil, errors.New("test failed")
+			}
+			return doc, nil
+		}
+		return setPointer(doc, op.Path, value, op.Op == "replace")
+	case "remove":
+		doc, _, err := removePointer(doc, op.Path)
+		return doc, err
+	case "move":
+		if strings.HasPrefix(op.Path, op.From+"/") {
+			return nil, errors.New("cannot move a value into itself")
+		}
+		doc, value, err := removePointer(doc, op.From)
+		if err != nil {
+			return nil, err
+		}
+		return setPointer(doc, op.Path, value, false)
+	case "copy":
+		value, err := getPointer(doc, op.From)
+		if err != nil {
+			return nil, err
+		}
+		return setPointer(doc, op.Path, deepCopyJSON(value), false)
+	default:
+		return nil, fmt.Errorf("unknown operation %q", op.Op)
+	}
+}
+
+// splitPointer parses an RFC 6901 JSON Pointer into its reference tokens.
+func splitPointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("invalid pointer %q", pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+	}
+	return tokens, nil
+}
+
+func escapePointer(token string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(token)
+}
+
+func getPointer(doc any, pointer string) (any, error) {
+	tokens, err := splitPointer(pointer)
+	if err != nil {
+		return nil, err
+	}
+	for _, token := range tokens {
+		switch node := doc.(type) {
+		case map[string]any:
+			value, ok := node[token]
+			if !ok {
+				return nil, fmt.Errorf("path %s not found", pointer)
+			}
+			doc = value
+		case []any:
+			i, err := arrayIndex(token, len(node)-1)
+			if err != nil {
+				return nil, err
+			}
+			doc = node[i]
+		default:
+			return nil, fmt.Errorf("path %s not found", pointer)
+		}
+	}
+	return doc, nil
+}
+
+// setPointer adds or replaces the value at pointer and returns the updated document.
+func setPointer(doc any, pointer string, value any, replace bool) (any, error) {
+	tokens, err := splitPointer(pointer)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return value, nil
+	}
+	parentPointer := pointer[:strings.LastIndex(pointer, "/")]
+	parent, err := getPointer(doc, parentPointer)
+	if err != nil {
+		return nil, err
+	}
+	last := tokens[len(tokens)-1]
+
+	switch node := parent.(type) {
+	case map[string]any:
+		if _, ok := node[last]; replace && !ok {
+			return nil, fmt.Errorf("path %s not found", pointer)
+		}
+		node[last] = value
+		return doc, nil
+	case []any:
+		if replace {
+			i, err := arrayIndex(last, len(node)-1)
+			if err != nil {
+				return nil, err
+			}
+			node[i] = value
+			return doc, nil
+		}
+		i := len(node)
+		if last != "-" {
+			if i, err = arrayIndex(last, len(node)); err != nil {
+				return nil, err
+			}
+		}
+		return setPointer(doc, parentPointer, slices.Insert(node, i, value), true)
+	default:
+		return nil, fmt.Errorf("path %s not found", pointer)
+	}
+}
+
+// removePointer removes the value at pointer and returns the updated document and
+// the removed value.
+func removePointer(doc any, pointer string) (any, any, error) {
+	tokens, err := splitPointer(pointer)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, nil, errors.New("cannot remove the whole document")
+	}
+	parentPointer := pointer[:strings.LastIndex(pointer, "/")]
+	parent, err := getPointer(doc, parentPointer)
+	if err != nil {
+		return nil, nil, err
+	}
+	last := tokens[len(tokens)-1]
+
+	switch node := parent.(type) {
+	case map[string]any:
+		value, ok := node[last]
+		if !ok {
+			return nil, nil, fmt.Errorf("path %s not found", pointer)
+		}
+		delete(node, last)
+		return doc, value, nil
+	case []any:
+		i, err := arrayIndex(last, len(node)-1)
+		if err != nil {
+			return nil, nil, err
+		}
+		value := node[i]
+		doc, err = setPointer(doc, parentPointer, slices.Delete(slices.Clone(node), i, i+1), true)
+		return doc, value, err
+	default:
+		return nil, nil, fmt.Errorf("path %s not found", pointer)
+	}
+}
+
+func arrayIndex(token string, last int) (int, error) {
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || i > last || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+	return i, nil
+}
+
+func jsonEqual(a, b any) bool {
+	x, errA := json.Marshal(a)
+	y, errB := json.Marshal(b)
+	return errA == nil && errB == nil && bytes.Equal(x, y)
+}
+
+func deepCopyJSON(value any) any {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return value
+	}
+	clone, err := decodeJSON(data)
+	if err != nil {
+		return value
+	}
+	return clone
+}
@@ -0,0 +1,242 @@
+package context
+
+import (
+	"slices"
+	"time"
+)
+
+// Reasons a block was dropped by a Pruner.
+const (
+	DropWindow     = "window"      // Older than the sliding window
+	DropToolOutput = "tool_output" // Old tool output, dropped before other blocks
+	DropScore      = "score"       // Lowest priority score
+	DropAge        = "age"         // Oldest block
+	DropExpired    = "expired"     // Past its expiry, dropped regardless of the budget
+)
+
+// Pruner trims a context's memory to fit a token budget. By default it is a
+// sliding window that drops the oldest blocks first; the other fields protect
+// blocks or change the order in which they are dropped. Expired blocks are
+// always dropped and pinned blocks never are.
+type Pruner struct {
+	Tokenizer Tokenizer        // Defaults to ApproxTokenizer
+	Budget    int              // Maximum tokens across memory; 0 disables the budget
+	Window    int              // Maximum number of blocks kept; 0 disables the window
+	Overhead  int              // Tokens counted per block for role and formatting
+	Now       func() time.Time // Clock used to expire blocks; defaults to time.Now
+
+	KeepSystem       bool                    // Never drop blocks with the "system" role
+	Pinned           func(*MemoryBlock) bool // Blocks that are never dropped, besides those with Pinned set
+	ToolOutputsFirst bool                    // Drop blocks with the "tool" role before any others
+	// Score ranks blocks by priority: lower scores are dropped first, ties
+	// oldest first. position is the block's index in memory of count blocks.
+	Score func(block *MemoryBlock, position, count int) float64
+
+	// Summarizer condenses the oldest blocks instead of dropping them; see Compact.
+	Summarizer    Summarizer
+	SummaryTokens int // Tokens left free for the summary when choosing what to summarize
+}
+
+// DroppedBlock describes a block removed by a Pruner.
+type DroppedBlock struct {
+	ID     string `json:"id"`
+	Role   string `json:"role"`
+	Tokens int    `json:"tokens"`
+	Reason string `json:"reason"`
+}
+
+// PruneReport describes what a Pruner removed.
+type PruneReport struct {
+	TokensBefore int            `json:"tokens_before"`
+	TokensAfter  int            `json:"tokens_after"`
+	Dropped      []DroppedBlock `json:"dropped,omitempty"`
+	OverBudget   bool           `json:"over_budget"`             // Protected blocks alone exceed the budget
+	Summary      *MemoryBlock   `json:"summary,omitempty"`       // Block that replaced the summarized blocks
+	SummaryIndex int            `json:"summary_index,omitempty"` // Position of Summary in the pruned memory
+}
+
+// Update returns the update that removes the dropped blocks from the stored
+// context and inserts the summary, for when pruning should be permanent.
+func (r *PruneReport) Update(id string) ContextUpdate {
+	update := ContextUpdate{ID: id}
+	for _, dropped := range r.Dropped {
+		update.Remove = append(update.Remove, dropped.ID)
+	}
+	if r.Summary != nil {
+		update.Insert = []MemoryInsert{{Index: r.SummaryIndex, Blocks: []*MemoryBlock{r.Summary}}}
+	}
+	return update
+}
+
+// CountTokens returns the tokens a block counts against the budget, caching the
+// count of its content in the block.
+func (p *Pruner) CountTokens(block *MemoryBlock) int {
+	return block.CountTokens(p.tokenizer()) + p.Overhead
+}
+
+// countedCopy counts the tokens of c's blocks and returns a copy of c carrying the
+// cached counts, which a copy through JSON would lose.
+func (p *Pruner) countedCopy(c *Context) (*Context, error) {
+	for _, block := range c.Memory {
+		if block != nil {
+			p.CountTokens(block)
+		}
+	}
+	var copied Context
+	if err := deepCopy(c, &copied); err != nil {
+		return nil, err
+	}
+	for i, block := range copied.Memory {
+		if block != nil {
+			block.Tokens = c.Memory[i].Tokens
+		}
+	}
+	return &copied, nil
+}
+
+// Prune returns a copy of ctx whose memory fits the pruner's limits, and a report
+// of what was dropped. ctx is not modified, except that the token counts of its
+// blocks are cached so later prunes need not count them again.
+func (p *Pruner) Prune(ctx *Context) (*Context, *PruneReport, error) {
+	pruned, err := p.countedCopy(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	memory := pruned.Memory
+	tokens := make([]int, len(memory))
+	report := &PruneReport{}
+	for i, block := range memory {
+		tokens[i] = p.CountTokens(block)
+		report.TokensBefore += tokens[i]
+	}
+	total := report.TokensBefore
+
+	dropped := make([]bool, len(memory))
+	drop := func(i int, reason string) {
+		dropped[i] = true
+		total -= tokens[i]
+		report.Dropped = append(report.Dropped, DroppedBlock{ID: memory[i].ID, Role: memory[i].Role, Tokens: tokens[i], Reason: reason})
+	}
+
+	now := p.now()
+	live := len(memory)
+	for i, block := range memory {
+		if block.Expired(now) {
+			drop(i, DropExpired)
+			live--
+		}
+	}
+
+	// Candidates in the order they are dropped; protected blocks are never candidates.
+	var candidates []int
+	for i, block := range memory {
+		if !dropped[i] && !p.protected(block) {
+			candidates = append(candidates, i)
+		}
+	}
+
+	if p.Window > 0 {
+		excess := live - p.Window
+		for _, i := range candidates {
+			if excess <= 0 {
+				break
+			}
+			drop(i, DropWindow)
+			excess--
+		}
+	}
+
+	order := p.dropOrder(memory, candidates)
+	for _, i := range order {
+		if p.Budget <= 0 || total <= p.Budget {
+			break
+		}
+		if dropped[i] {
+			continue
+		}
+		reason := DropAge
+		switch {
+		case p.ToolOutputsFirst && memory[i].Role == "tool":
+			reason = DropToolOutput
+		case p.Score != nil:
+			reason = DropScore
+		}
+		drop(i, reason)
+	}
+
+	kept := make([]*MemoryBlock, 0, len(memory)-len(report.Dropped))
+	for i, block := range memory {
+		if !dropped[i] {
+			kept = append(kept, block)
+		}
+	}
+	pruned.Memory = kept
+	report.TokensAfter = total
+	report.OverBudget = p.Budget > 0 && total > p.Budget
+	return pruned, report, nil
+}
+
+func (p *Pruner) tokenizer() Tokenizer {
+	if p.Tokenizer == nil {
+		return ApproxTokenizer{}
+	}
+	return p.Tokenizer
+}
+
+func (p *Pruner) now() time.Time {
+	if p.Now == nil {
+		return time.Now()
+	}
+	return p.Now()
+}
+
+func (p *Pruner) protected(block *MemoryBlock) bool {
+	return block.Pinned || (p.KeepSystem && block.Role == "system") || (p.Pinned != nil && p.Pinned(block))
+}
+
+// dropOrder sorts candidate indexes into the order they are dropped to meet the
+// budget: tool outputs first if enabled, then by score, then oldest first.
+func (p *Pruner) dropOrder(memory []*MemoryBlock, candidates []int) []int {
+	order := slices.Clone(candidates)
+	scores := make(map[int]float64, len(order))
+	if p.Score != nil {
+		for _, i := range order {
+			scores[i] = p.Score(memory[i], i, len(memory))
+		}
+	}
+	slices.SortStableFunc(order, func(x, y int) int {
+		if p.ToolOutputsFirst {
+			toolX, toolY := memory[x].Role == "tool", memory[y].Role == "tool"
+			if toolX != toolY {
+				if toolX {
+					return -1
+				}
+				return 1
+			}
+		}
+		if scores[x] < scores[y] {
+			return -1
+		}
+		if scores[x] > scores[y] {
+			return 1
+		}
+		return x - y
+	})
+	return order
+}
+
+// PriorityScore is a Score that favours recent blocks and conversation over tool
+// output, so a tool result is dropped before a slightly older user message.
+func PriorityScore(block *MemoryBlock, position, count int) float64 {
+	score := float64(position+1) / float64(count)
+	switch block.Role {
+	case "system":
+		score += 1
+	case "user":
+		score += 0.5
+	case "assistant":
+		score += 0.3
+	}
+	return score
+}
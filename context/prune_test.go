@@ -0,0 +1,159 @@
+package context
+
+import (
+	stdcontext "context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alecthomas/assert"
+)
+
+// wordTokenizer counts one token per word so budgets are easy to reason about.
+type wordTokenizer struct{}
+
+func (wordTokenizer) CountTokens(text string) int {
+	return len(strings.Fields(text))
+}
+
+func pruneTestContext() *Context {
+	ctx := NewContext(nil)
+	ctx.Memory = []*MemoryBlock{
+		{ID: "sys", Role: "system", Content: "you are helpful"},    // 3
+		{ID: "u1", Role: "user", Content: "find the file"},         // 3
+		{ID: "t1", Role: "tool", Content: "a b c d e f"},           // 6
+		{ID: "a1", Role: "assistant", Content: "found it"},         // 2
+		{ID: "u2", Role: "user", Content: "now summarize it"},      // 3
+		{ID: "a2", Role: "assistant", Content: "here is the gist"}, // 4
+	}
+	return ctx
+}
+
+func droppedIDs(report *PruneReport) []string {
+	var ids []string
+	for _, dropped := range report.Dropped {
+		ids = append(ids, dropped.ID)
+	}
+	return ids
+}
+
+func TestPruner_SlidingWindow(t *testing.T) {
+	ctx := pruneTestContext()
+	p := &Pruner{Tokenizer: wordTokenizer{}, Budget: 10}
+
+	pruned, report, err := p.Prune(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"sys", "u1", "t1"}, droppedIDs(report))
+	assert.Equal(t, []string{"a1", "u2", "a2"}, memoryIDs(pruned))
+	assert.Equal(t, 21, report.TokensBefore)
+	assert.Equal(t, 9, report.TokensAfter)
+	assert.False(t, report.OverBudget)
+	assert.Equal(t, 6, len(ctx.Memory), "the original is not modified")
+}
+
+func TestPruner_KeepSystemAndPinned(t *testing.T) {
+	p := &Pruner{
+		Tokenizer:  wordTokenizer{},
+		Budget:     12,
+		KeepSystem: true,
+		Pinned:     func(block *MemoryBlock) bool { return block.ID == "u1" },
+	}
+	pruned, report, err := p.Prune(pruneTestContext())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"sys", "u1", "a2"}, memoryIDs(pruned))
+	assert.Equal(t, 10, report.TokensAfter)
+
+	p.Budget = 2
+	_, report, err = p.Prune(pruneTestContext())
+	assert.NoError(t, err)
+	assert.True(t, report.OverBudget, "protected blocks alone exceed the budget")
+	assert.Equal(t, 6, report.TokensAfter)
+}
+
+func TestPruner_ToolOutputsFirst(t *testing.T) {
+	p := &Pruner{Tokenizer: wordTokenizer{}, Budget: 15, ToolOutputsFirst: true}
+	pruned, report, err := p.Prune(pruneTestContext())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"t1"}, droppedIDs(report))
+	assert.Equal(t, DropToolOutput, report.Dropped[0].Reason)
+	assert.Equal(t, 5, len(pruned.Memory))
+}
+
+func TestPruner_Window(t *testing.T) {
+	p := &Pruner{Tokenizer: wordTokenizer{}, Window: 3, KeepSystem: true}
+	pruned, report, err := p.Prune(pruneTestContext())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"sys", "u2", "a2"}, memoryIDs(pruned))
+	for _, dropped := range report.Dropped {
+		assert.Equal(t, DropWindow, dropped.Reason)
+	}
+}
+
+func TestPruner_Score(t *testing.T) {
+	p := &Pruner{Tokenizer: wordTokenizer{}, Budget: 12, Score: PriorityScore}
+	pruned, report, err := p.Prune(pruneTestContext())
+	assert.NoError(t, err)
+	// The tool output goes first despite being newer than the first user message.
+	assert.Equal(t, "t1", report.Dropped[0].ID)
+	assert.Equal(t, DropScore, report.Dropped[0].Reason)
+	assert.Equal(t, []string{"t1", "u1"}, droppedIDs(report))
+	assert.Equal(t, []string{"sys", "a1", "u2", "a2"}, memoryIDs(pruned))
+
+	update := report.Update(pruned.ID)
+	assert.Equal(t, droppedIDs(report), update.Remove)
+}
+
+func TestApproxTokenizer(t *testing.T) {
+	assert.Equal(t, 0, ApproxTokenizer{}.CountTokens(""))
+	assert.Equal(t, 3, ApproxTokenizer{}.CountTokens("hello world"))
+	assert.Equal(t, 11, ApproxTokenizer{CharsPerToken: 1}.CountTokens("hello world"))
+}
+
+func TestPruner_ExpiryAndPinnedBlocks(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	expired := now.Add(-time.Second)
+	ctx := pruneTestContext()
+	ctx.Memory[1].Pinned = true
+	ctx.Memory[4].ExpiresAt = &expired
+	p := &Pruner{Tokenizer: wordTokenizer{}, Budget: 10, Now: func() time.Time { return now }}
+
+	pruned, report, err := p.Prune(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"u2", "sys", "t1"}, droppedIDs(report))
+	assert.Equal(t, DropExpired, report.Dropped[0].Reason)
+	assert.Equal(t, []string{"u1", "a1", "a2"}, memoryIDs(pruned))
+	assert.Equal(t, 9, report.TokensAfter)
+	assert.Equal(t, 3, pruned.Memory[0].Tokens, "token counts are cached in the pruned copy")
+	assert.Equal(t, 6, len(ctx.Memory), "the original is not modified")
+}
+
+// countingTokenizer counts words and how often it was asked to.
+type countingTokenizer struct {
+	calls int
+}
+
+func (t *countingTokenizer) CountTokens(text string) int {
+	t.calls++
+	return wordTokenizer{}.CountTokens(text)
+}
+
+func TestPruner_CachesTokenCounts(t *testing.T) {
+	ctx := pruneTestContext()
+	tokenizer := &countingTokenizer{}
+	p := &Pruner{Tokenizer: tokenizer, Budget: 10}
+
+	first, _, err := p.Prune(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, len(ctx.Memory), tokenizer.calls)
+	assert.Equal(t, 3, ctx.Memory[1].Tokens, "counts are cached in the caller's blocks")
+
+	second, _, err := p.Prune(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, len(ctx.Memory), tokenizer.calls, "a second prune counts nothing again")
+	assert.Equal(t, memoryIDs(first), memoryIDs(second))
+
+	p.Summarizer = &stubSummarizer{}
+	_, _, err = p.Compact(stdcontext.Background(), ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, len(ctx.Memory)+1, tokenizer.calls, "only the summary is counted")
+}
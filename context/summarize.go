@@ -0,0 +1,165 @@
+package context
+
+import (
+	stdcontext "context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// DropSummarized is the reason recorded for blocks replaced by a summary.
+const DropSummarized = "summarized"
+
+// MetadataSummaryOf is the memory block metadata key that records a summary's
+// provenance: the comma separated IDs of the blocks it replaced.
+const MetadataSummaryOf = "summary_of"
+
+// Summarizer condenses a run of memory blocks, oldest first, into a single block.
+// Implementations typically ask a model to write the summary, through sampling
+// or an LLM API.
+type Summarizer interface {
+	Summarize(ctx stdcontext.Context, blocks []*MemoryBlock) (*MemoryBlock, error)
+}
+
+// SummarizerFunc adapts a function to the Summarizer interface.
+type SummarizerFunc func(ctx stdcontext.Context, blocks []*MemoryBlock) (*MemoryBlock, error)
+
+func (f SummarizerFunc) Summarize(ctx stdcontext.Context, blocks []*MemoryBlock) (*MemoryBlock, error) {
+	return f(ctx, blocks)
+}
+
+// SummarySources returns the IDs of the blocks a summary block replaced, or nil if
+// the block is not a summary.
+func SummarySources(block *MemoryBlock) []string {
+	sources, ok := block.Metadata[MetadataSummaryOf]
+	if !ok || sources == "" {
+		return nil
+	}
+	return strings.Split(sources, ",")
+}
+
+// Compact is like Prune, but when memory is over budget it first replaces the
+// oldest run of unprotected blocks with a summary from the pruner's Summarizer.
+// Expired blocks are dropped first. The run stops at the first protected block,
+// pinned or otherwise, and never includes the most recent block; it is only as
+// long as needed to free the excess tokens plus SummaryTokens. If the context is
+// still over budget afterwards, the remaining blocks are pruned as usual, keeping
+// the summary. Without a Summarizer or a Budget, Compact is Prune. As with Prune,
+// c is only modified by caching token counts.
+func (p *Pruner) Compact(ctx stdcontext.Context, c *Context) (*Context, *PruneReport, error) {
+	if p.Summarizer == nil || p.Budget <= 0 {
+		return p.Prune(c)
+	}
+
+	compacted, err := p.countedCopy(c)
+	if err != nil {
+		return nil, nil, err
+	}
+	// Expired blocks are dropped anyway, so they are not worth summarizing.
+	now := p.now()
+	before := 0
+	var expired []DroppedBlock
+	compacted.Memory = slices.DeleteFunc(compacted.Memory, func(block *MemoryBlock) bool {
+		tokens := p.CountTokens(block)
+		before += tokens
+		if block.Expired(now) {
+			expired = append(expired, DroppedBlock{ID: block.ID, Role: block.Role, Tokens: tokens, Reason: DropExpired})
+			return true
+		}
+		return false
+	})
+
+	tokens := make([]int, len(compacted.Memory))
+	total := 0
+	for i, block := range compacted.Memory {
+		tokens[i] = p.CountTokens(block)
+		total += tokens[i]
+	}
+	if total <= p.Budget {
+		return p.Prune(c)
+	}
+	start, end := p.summaryRange(compacted.Memory, tokens, total-p.Budget+p.SummaryTokens)
+	if start == end {
+		return p.Prune(c)
+	}
+	sources := compacted.Memory[start:end]
+	summary, err := p.Summarizer.Summarize(ctx, slices.Clone(sources))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to summarize memory: %w", err)
+	}
+	if summary == nil {
+		return nil, nil, errors.New("failed to summarize memory: summarizer returned no block")
+	}
+	summary = p.summaryBlock(summary, sources)
+	compacted.Memory = slices.Concat(compacted.Memory[:start], []*MemoryBlock{summary}, compacted.Memory[end:])
+
+	// The summary stands in for the oldest history, so it must outlive the
+	// pruning that would otherwise drop it first.
+	fallback := *p
+	fallback.Pinned = func(block *MemoryBlock) bool {
+		return block.ID == summary.ID || (p.Pinned != nil && p.Pinned(block))
+	}
+	pruned, report, err := fallback.Prune(compacted)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	summarized := make([]DroppedBlock, len(sources))
+	for i, block := range sources {
+		summarized[i] = DroppedBlock{ID: block.ID, Role: block.Role, Tokens: tokens[start+i], Reason: DropSummarized}
+	}
+	report.Dropped = slices.Concat(expired, summarized, report.Dropped)
+	report.TokensBefore = before
+	for i, block := range pruned.Memory {
+		if block.ID == summary.ID {
+			report.Summary = block
+			report.SummaryIndex = i
+		}
+	}
+	return pruned, report, nil
+}
+
+// summaryRange returns the oldest run of unprotected blocks, excluding the last
+// block, whose tokens add up to at least need.
+func (p *Pruner) summaryRange(memory []*MemoryBlock, tokens []int, need int) (int, int) {
+	start := 0
+	for start < len(memory) && p.protected(memory[start]) {
+		start++
+	}
+	end, freed := start, 0
+	for end < len(memory)-1 && freed < need && !p.protected(memory[end]) {
+		freed += tokens[end]
+		end++
+	}
+	return start, end
+}
+
+// summaryBlock fills in what the summarizer left out and records the summary's
+// provenance.
+func (p *Pruner) summaryBlock(summary *MemoryBlock, sources []*MemoryBlock) *MemoryBlock {
+	block := *summary
+	if block.ID == "" {
+		block.ID = uuid.NewString()
+	}
+	if block.Role == "" {
+		block.Role = "system"
+	}
+	if block.Kind == "" {
+		block.Kind = KindSummary
+	}
+	if block.Time.IsZero() {
+		block.Time = sources[len(sources)-1].Time
+	}
+	ids := make([]string, len(sources))
+	for i, source := range sources {
+		ids[i] = source.ID
+	}
+	block.Metadata = make(map[string]string, len(summary.Metadata)+1)
+	maps.Copy(block.Metadata, summary.Metadata)
+	block.Metadata[MetadataSummaryOf] = strings.Join(ids, ",")
+	return &block
+}
@@ -0,0 +1,105 @@
+package context
+
+import (
+	stdcontext "context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alecthomas/assert"
+)
+
+// stubSummarizer deterministically summarizes blocks as the list of their IDs,
+// which is two words for the wordTokenizer.
+type stubSummarizer struct {
+	calls int
+}
+
+func (s *stubSummarizer) Summarize(_ stdcontext.Context, blocks []*MemoryBlock) (*MemoryBlock, error) {
+	s.calls++
+	ids := make([]string, len(blocks))
+	for i, block := range blocks {
+		ids[i] = block.ID
+	}
+	return &MemoryBlock{Content: "summary: " + strings.Join(ids, ",")}, nil
+}
+
+func TestPruner_CompactSummarizesOldestRun(t *testing.T) {
+	ctx := pruneTestContext()
+	ctx.Memory[2].Time = time.Unix(1700000000, 0).UTC()
+	summarizer := &stubSummarizer{}
+	p := &Pruner{Tokenizer: wordTokenizer{}, Budget: 14, KeepSystem: true, Summarizer: summarizer}
+
+	compacted, report, err := p.Compact(stdcontext.Background(), ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, summarizer.calls)
+	assert.Equal(t, 5, len(compacted.Memory))
+	summary := compacted.Memory[1]
+	assert.Equal(t, "summary: u1,t1", summary.Content)
+	assert.Equal(t, "system", summary.Role)
+	assert.NotZero(t, summary.ID)
+	assert.True(t, summary.Time.Equal(ctx.Memory[2].Time), "the summary takes the time of the last block it replaced")
+	assert.Equal(t, []string{"u1", "t1"}, SummarySources(summary))
+	assert.Equal(t, []string{"sys", summary.ID, "a1", "u2", "a2"}, memoryIDs(compacted))
+
+	assert.Equal(t, []DroppedBlock{
+		{ID: "u1", Role: "user", Tokens: 3, Reason: DropSummarized},
+		{ID: "t1", Role: "tool", Tokens: 6, Reason: DropSummarized},
+	}, report.Dropped)
+	assert.Equal(t, 21, report.TokensBefore)
+	assert.Equal(t, 14, report.TokensAfter)
+	assert.Equal(t, 1, report.SummaryIndex)
+	assert.Equal(t, 6, len(ctx.Memory), "the original is not modified")
+
+	assert.NoError(t, ctx.ApplyUpdate(report.Update(ctx.ID)))
+	assert.Equal(t, memoryIDs(compacted), memoryIDs(ctx))
+	assert.Equal(t, summary.Content, ctx.Memory[1].Content)
+	assert.Equal(t, KindSummary, ctx.Memory[1].Kind)
+}
+
+func TestPruner_CompactPrunesAfterSummary(t *testing.T) {
+	p := &Pruner{Tokenizer: wordTokenizer{}, Budget: 5, Summarizer: &stubSummarizer{}}
+
+	compacted, report, err := p.Compact(stdcontext.Background(), pruneTestContext())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(compacted.Memory), "the summary is kept over the blocks after it")
+	assert.Equal(t, []string{"sys", "u1", "t1", "a1", "u2"}, SummarySources(compacted.Memory[0]))
+	assert.Equal(t, []string{"sys", "u1", "t1", "a1", "u2", "a2"}, droppedIDs(report))
+	assert.Equal(t, DropAge, report.Dropped[5].Reason)
+	assert.Equal(t, 2, report.TokensAfter)
+	assert.False(t, report.OverBudget)
+}
+
+func TestPruner_CompactWithinBudget(t *testing.T) {
+	summarizer := &stubSummarizer{}
+	p := &Pruner{Tokenizer: wordTokenizer{}, Budget: 21, Summarizer: summarizer}
+
+	compacted, report, err := p.Compact(stdcontext.Background(), pruneTestContext())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, summarizer.calls)
+	assert.Equal(t, 6, len(compacted.Memory))
+	assert.Empty(t, report.Dropped)
+	assert.Zero(t, report.Summary)
+
+	// Room for a summary only matters once memory is over budget.
+	p = &Pruner{Tokenizer: wordTokenizer{}, Budget: 25, SummaryTokens: 50, Summarizer: summarizer}
+	compacted, report, err = p.Compact(stdcontext.Background(), pruneTestContext())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, summarizer.calls)
+	assert.Equal(t, 6, len(compacted.Memory))
+	assert.Empty(t, report.Dropped)
+}
+
+func TestPruner_CompactSummarizerError(t *testing.T) {
+	failure := errors.New("model unavailable")
+	p := &Pruner{
+		Tokenizer: wordTokenizer{},
+		Budget:    10,
+		Summarizer: SummarizerFunc(func(stdcontext.Context, []*MemoryBlock) (*MemoryBlock, error) {
+			return nil, failure
+		}),
+	}
+	_, _, err := p.Compact(stdcontext.Background(), pruneTestContext())
+	assert.True(t, errors.Is(err, failure))
+}
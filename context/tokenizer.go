@@ -0,0 +1,127 @@
+package context
+
+import (
+	"bufio"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Tokenizer counts the tokens a model would see for a piece of text.
+type Tokenizer interface {
+	CountTokens(text string) int
+}
+
+// ApproxTokenizer estimates token counts from text length. It is fast and needs no
+// vocabulary, at the cost of accuracy.
+type ApproxTokenizer struct {
+	CharsPerToken float64 // Defaults to 4, a good fit for English text
+}
+
+func (t ApproxTokenizer) CountTokens(text string) int {
+	if text == "" {
+		return 0
+	}
+	perToken := t.CharsPerToken
+	if perToken <= 0 {
+		perToken = 4
+	}
+	return int(math.Ceil(float64(utf8.RuneCountInString(text)) / perToken))
+}
+
+// bpePretokenizer splits text into words before merging, like GPT-style
+// tokenizers do. Go's regexp has no lookahead, so whitespace runs are kept whole.
+var bpePretokenizer = regexp.MustCompile(`'(?:s|t|re|ve|m|ll|d)| ?\p{L}+| ?\p{N}+| ?[^\s\p{L}\p{N}]+|\s+`)
+
+// BPETokenizer counts tokens with byte-pair encoding over a ranked vocabulary,
+// as used by tiktoken-style models.
+type BPETokenizer struct {
+	ranks map[string]int
+}
+
+// NewBPETokenizer returns a tokenizer for a vocabulary mapping byte sequences to
+// merge ranks, lower ranks merging first.
+func NewBPETokenizer(ranks map[string]int) *BPETokenizer {
+	return &BPETokenizer{ranks: ranks}
+}
+
+// LoadBPETokenizer reads a vocabulary file in the tiktoken format: one base64
+// encoded token and its rank per line.
+func LoadBPETokenizer(path string) (*BPETokenizer, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open vocabulary: %w", err)
+	}
+	defer f.Close()
+	return ReadBPETokenizer(f)
+}
+
+// ReadBPETokenizer reads a tiktoken format vocabulary from r.
+func ReadBPETokenizer(r io.Reader) (*BPETokenizer, error) {
+	ranks := make(map[string]int)
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		token, rank, ok := strings.Cut(text, " ")
+		if !ok {
+			return nil, fmt.Errorf("invalid vocabulary line %d", line)
+		}
+		decoded, err := base64.StdEncoding.DecodeString(token)
+		if err != nil {
+			return nil, fmt.Errorf("invalid token on vocabulary line %d: %w", line, err)
+		}
+		n, err := strconv.Atoi(rank)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rank on vocabulary line %d: %w", line, err)
+		}
+		ranks[string(decoded)] = n
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read vocabulary: %w", err)
+	}
+	return NewBPETokenizer(ranks), nil
+}
+
+func (t *BPETokenizer) CountTokens(text string) int {
+	count := 0
+	for _, word := range bpePretokenizer.FindAllString(text, -1) {
+		if _, ok := t.ranks[word]; ok {
+			count++
+			continue
+		}
+		count += len(t.merge(word))
+	}
+	return count
+}
+
+// merge splits word into bytes and repeatedly joins the adjacent pair with the
+// lowest rank until no pair is in the vocabulary.
+func (t *BPETokenizer) merge(word string) []string {
+	parts := make([]string, len(word))
+	for i := range len(word) {
+		parts[i] = word[i : i+1]
+	}
+	for len(parts) > 1 {
+		best, bestRank := -1, math.MaxInt
+		for i := 0; i < len(parts)-1; i++ {
+			if rank, ok := t.ranks[parts[i]+parts[i+1]]; ok && rank < bestRank {
+				best, bestRank = i, rank
+			}
+		}
+		if best < 0 {
+			break
+		}
+		parts[best] += parts[best+1]
+		parts = append(parts[:best+1], parts[best+2:]...)
+	}
+	return parts
+}
@@ -0,0 +1,49 @@
+package context
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/assert"
+)
+
+func writeVocab(t *testing.T, tokens ...string) string {
+	var b strings.Builder
+	for rank, token := range tokens {
+		fmt.Fprintf(&b, "%s %d\n", base64.StdEncoding.EncodeToString([]byte(token)), rank)
+	}
+	path := filepath.Join(t.TempDir(), "vocab.tiktoken")
+	assert.NoError(t, os.WriteFile(path, []byte(b.String()), 0o600))
+	return path
+}
+
+func TestBPETokenizer(t *testing.T) {
+	path := writeVocab(t, "h", "e", "l", "o", " ", "w", "r", "d", "he", "ll", "hell", "hello", " w", "or", " wor", "ld", " world")
+	tokenizer, err := LoadBPETokenizer(path)
+	assert.NoError(t, err)
+
+	assert.Equal(t, 2, tokenizer.CountTokens("hello world"))
+	assert.Equal(t, 1, tokenizer.CountTokens("hell"))
+	// "held" merges the lowest ranked pairs first: he, then ld.
+	assert.Equal(t, 2, tokenizer.CountTokens("held"))
+	// Unknown bytes count one token each.
+	assert.Equal(t, 3, tokenizer.CountTokens("xyz"))
+	assert.Equal(t, 0, tokenizer.CountTokens(""))
+
+	p := &Pruner{Tokenizer: tokenizer}
+	assert.Equal(t, 2, p.CountTokens(&MemoryBlock{Content: "hello world"}))
+}
+
+func TestLoadBPETokenizer_Invalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vocab.tiktoken")
+	assert.NoError(t, os.WriteFile(path, []byte("not-base64! 1\n"), 0o600))
+	_, err := LoadBPETokenizer(path)
+	assert.Error(t, err)
+
+	_, err = LoadBPETokenizer(filepath.Join(t.TempDir(), "missing"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,44 @@
+package keys
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+const (
+	// EnvKeyring holds a JSON encoded list of keys, in the same format as a decrypted keyring file.
+	EnvKeyring = "GOMCP_KEYRING"
+	// EnvSecret holds the legacy single shared secret, also read by auth.GetSecret.
+	EnvSecret = "GOMCP_SECRET"
+	// LegacyKeyID is the ID given to the key read from EnvSecret.
+	LegacyKeyID = "default"
+)
+
+// EnvStore is a read-only keyring loaded from the environment. Keys are read from
+// GOMCP_KEYRING; if it is unset, GOMCP_SECRET becomes a symmetric key with ID "default".
+type EnvStore struct {
+	mem *MemoryStore
+}
+
+// NewEnvStore loads the keyring from the environment.
+func NewEnvStore() (*EnvStore, error) {
+	var keyring []Key
+	if raw := os.Getenv(EnvKeyring); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &keyring); err != nil {
+			return nil, fmt.Errorf("failed to decode %s: %w", EnvKeyring, err)
+		}
+	} else if secret := os.Getenv(EnvSecret); secret != "" {
+		keyring = append(keyring, Key{
+			ID:        LegacyKeyID,
+			Algorithm: AlgSymmetric,
+			Material:  []byte(secret),
+		})
+	}
+	return &EnvStore{mem: NewMemoryStore(keyring...)}, nil
+}
+
+func (s *EnvStore) Get(id string) (Key, error)        { return s.mem.Get(id) }
+func (s *EnvStore) Active(alg Algorithm) (Key, error) { return s.mem.Active(alg) }
+func (s *EnvStore) List() ([]Key, error)              { return s.mem.List() }
+func (s *EnvStore) Put(Key) error                     { return ErrReadOnly }
@@ -0,0 +1,179 @@
+package keys
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+const (
+	// EnvPassphrase holds the passphrase protecting a keyring file.
+	EnvPassphrase = "GOMCP_KEYRING_PASSPHRASE"
+
+	keyringVersion    = 1
+	keyringKDF        = "pbkdf2-sha256"
+	keyringIterations = 600_000
+	keyringSaltSize   = 16
+)
+
+// ErrWrongPassphrase indicates a keyring file that could not be decrypted.
+var ErrWrongPassphrase = errors.New("failed to decrypt keyring: wrong passphrase or corrupted file")
+
+// keyringFile is the on-disk format of a FileStore. The keyring is encrypted with
+// AES-256-GCM under a key derived from the passphrase.
+type keyringFile struct {
+	Version    int    `json:"version"`
+	KDF        string `json:"kdf"`
+	Iterations int    `json:"iterations"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// FileStore keeps a keyring in a file that is encrypted at rest. The keyring is
+// held in memory once opened and written back on every change.
+type FileStore struct {
+	mu         sync.RWMutex
+	path       string
+	passphrase []byte
+	keys       []Key
+}
+
+// OpenFileStore opens the keyring at path, creating an empty one if the file does not exist.
+func OpenFileStore(path string, passphrase []byte) (*FileStore, error) {
+	if len(passphrase) == 0 {
+		return nil, errors.New("keyring passphrase cannot be empty")
+	}
+	s := &FileStore{path: path, passphrase: passphrase}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read keyring: %w", err)
+	}
+	if s.keys, err = decryptKeyring(data, passphrase); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *FileStore) Get(id string) (Key, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return findKey(s.keys, id)
+}
+
+func (s *FileStore) Active(alg Algorithm) (Key, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return activeKey(s.keys, alg)
+}
+
+func (s *FileStore) List() ([]Key, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]Key{}, s.keys...), nil
+}
+
+func (s *FileStore) Put(key Key) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	keys := putKey(append([]Key{}, s.keys...), key)
+	if err := s.save(keys); err != nil {
+		return err
+	}
+	s.keys = keys
+	return nil
+}
+
+func (s *FileStore) save(keys []Key) error {
+	data, err := encryptKeyring(keys, s.passphrase)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return fmt.Errorf("failed to create keyring directory: %w", err)
+	}
+	// Write to a temporary file first so a crash never leaves a truncated keyring.
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write keyring: %w", err)
+	}
+	return os.Rename(tmp, s.path)
+}
+
+func encryptKeyring(keys []Key, passphrase []byte) ([]byte, error) {
+	plaintext, err := json.Marshal(keys)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal keyring: %w", err)
+	}
+	file := keyringFile{
+		Version:    keyringVersion,
+		KDF:        keyringKDF,
+		Iterations: keyringIterations,
+		Salt:       make([]byte, keyringSaltSize),
+	}
+	if _, err := io.ReadFull(rand.Reader, file.Salt); err != nil {
+		return nil, fmt.Errorf("failed to generate salt: %w", err)
+	}
+	gcm, err := keyringCipher(passphrase, file)
+	if err != nil {
+		return nil, err
+	}
+	file.Nonce = make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, file.Nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	file.Ciphertext = gcm.Seal(nil, file.Nonce, plaintext, nil)
+	return json.MarshalIndent(file, "", "  ")
+}
+
+func decryptKeyring(data, passphrase []byte) ([]Key, error) {
+	var file keyringFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to decode keyring: %w", err)
+	}
+	if file.Version != keyringVersion || file.KDF != keyringKDF {
+		return nil, fmt.Errorf("unsupported keyring format (version %d, kdf %s)", file.Version, file.KDF)
+	}
+	gcm, err := keyringCipher(passphrase, file)
+	if err != nil {
+		return nil, err
+	}
+	if len(file.Nonce) != gcm.NonceSize() {
+		return nil, ErrWrongPassphrase
+	}
+	plaintext, err := gcm.Open(nil, file.Nonce, file.Ciphertext, nil)
+	if err != nil {
+		return nil, ErrWrongPassphrase
+	}
+	var keys []Key
+	if err := json.Unmarshal(plaintext, &keys); err != nil {
+		return nil, fmt.Errorf("failed to decode keyring contents: %w", err)
+	}
+	return keys, nil
+}
+
+func keyringCipher(passphrase []byte, file keyringFile) (cipher.AEAD, error) {
+	if file.Iterations <= 0 {
+		return nil, fmt.Errorf("invalid keyring iteration count %d", file.Iterations)
+	}
+	key := pbkdf2.Key(passphrase, file.Salt, file.Iterations, 32, sha256.New)
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create AES cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,42 @@
+package keys
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keyring.json")
+	passphrase := []byte("correct horse battery staple")
+
+	store, err := OpenFileStore(path, passphrase)
+	require.NoError(t, err)
+	key, err := Rotate(store, AlgSymmetric, time.Hour)
+	require.NoError(t, err)
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), key.ID, "keyring must be encrypted at rest")
+
+	reopened, err := OpenFileStore(path, passphrase)
+	require.NoError(t, err)
+	got, err := reopened.Active(AlgSymmetric)
+	require.NoError(t, err)
+	assert.Equal(t, key.ID, got.ID)
+	assert.Equal(t, key.Material, got.Material)
+
+	_, err = OpenFileStore(path, []byte("wrong"))
+	assert.ErrorIs(t, err, ErrWrongPassphrase)
+
+	_, err = OpenFileStore(path, nil)
+	assert.Error(t, err)
+}
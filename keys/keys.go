@@ -0,0 +1,273 @@
+// Package keys manages the signing and encryption keys used by gomcp.
+//
+// Every key has an ID that travels with the data it protects (the kid of a
+// SecuredPayload or a JWT header), so receivers can pick the right key and
+// keys can be rotated without breaking data that is still in flight.
+package keys
+
+import (
+	"crypto/ecdh"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrKeyNotFound indicates no key with the requested ID exists in the store.
+	ErrKeyNotFound = errors.New("key not found")
+	// ErrKeyExpired indicates a retired key whose grace window has passed.
+	ErrKeyExpired = errors.New("key has expired")
+	// ErrNoActiveKey indicates the store holds no current key for an algorithm.
+	ErrNoActiveKey = errors.New("no active key")
+	// ErrReadOnly indicates a store that cannot be modified, such as one read from the environment.
+	ErrReadOnly = errors.New("key store is read-only")
+	// ErrWrongAlgorithm indicates a key used with an algorithm it was not generated for.
+	ErrWrongAlgorithm = errors.New("key has the wrong algorithm")
+	// ErrPublicOnly indicates a key that holds only public material was asked for its private key.
+	ErrPublicOnly = errors.New("key holds only public material")
+)
+
+// Algorithm identifies the kind of key material.
+type Algorithm string
+
+const (
+	AlgSymmetric Algorithm = "oct-256" // 32 random bytes for AES-256-GCM, HMAC-SHA256 and HS256 tokens
+	AlgEd25519   Algorithm = "Ed25519" // Ed25519 signing key; Material is the 32 byte seed, Public the 32 byte public key
+	AlgX25519    Algorithm = "X25519"  // X25519 key agreement key; Material is the 32 byte private key, Public the 32 byte public key
+)
+
+// ParseAlgorithm accepts algorithm names case-insensitively, e.g. from the command line.
+func ParseAlgorithm(name string) (Algorithm, error) {
+	for _, alg := range []Algorithm{AlgSymmetric, AlgEd25519, AlgX25519} {
+		if strings.EqualFold(name, string(alg)) {
+			return alg, nil
+		}
+	}
+	if strings.EqualFold(name, "symmetric") {
+		return AlgSymmetric, nil
+	}
+	return "", fmt.Errorf("unknown key algorithm '%s'", name)
+}
+
+// Key is a single entry in a keyring. Asymmetric keys may hold only their Public
+// material, e.g. a peer's key used to verify what it signed; such keys verify
+// but never sign, encrypt or decrypt.
+type Key struct {
+	ID        string     `json:"kid"`
+	Algorithm Algorithm  `json:"alg"`
+	Material  []byte     `json:"material,omitempty"`   // Secret key material; never log or display it
+	Public    []byte     `json:"public,omitempty"`     // Public key material of a key without Material
+	CreatedAt time.Time  `json:"created_at"`           // When the key was generated
+	RetiredAt *time.Time `json:"retired_at,omitempty"` // When a newer key replaced it; retired keys no longer sign
+	ExpiresAt *time.Time `json:"expires_at,omitempty"` // End of the grace window; expired keys no longer verify
+}
+
+// Generate creates a new key with a random ID.
+func Generate(alg Algorithm) (Key, error) {
+	var size int
+	switch alg {
+	case AlgSymmetric, AlgX25519:
+		size = 32
+	case AlgEd25519:
+		size = ed25519.SeedSize
+	default:
+		return Key{}, fmt.Errorf("cannot generate key for unknown algorithm '%s'", alg)
+	}
+
+	material := make([]byte, size)
+	if _, err := io.ReadFull(rand.Reader, material); err != nil {
+		return Key{}, fmt.Errorf("failed to generate key material: %w", err)
+	}
+	id := make([]byte, 8)
+	if _, err := io.ReadFull(rand.Reader, id); err != nil {
+		return Key{}, fmt.Errorf("failed to generate key id: %w", err)
+	}
+	return Key{
+		ID:        hex.EncodeToString(id),
+		Algorithm: alg,
+		Material:  material,
+		CreatedAt: time.Now().UTC(),
+	}, nil
+}
+
+// NewPublicKey creates a key that only holds the public material of an Ed25519 or
+// X25519 key, such as one published by a peer.
+func NewPublicKey(id string, alg Algorithm, public []byte) (Key, error) {
+	switch alg {
+	case AlgEd25519, AlgX25519:
+	default:
+		return Key{}, fmt.Errorf("%w: %s keys have no public part", ErrWrongAlgorithm, alg)
+	}
+	if len(public) != 32 {
+		return Key{}, fmt.Errorf("invalid %s public key length for key '%s'", alg, id)
+	}
+	return Key{
+		ID:        id,
+		Algorithm: alg,
+		Public:    append([]byte(nil), public...),
+		CreatedAt: time.Now().UTC(),
+	}, nil
+}
+
+// PublicOnly returns a copy of the key without its private material, fit to hand
+// to peers that verify data signed with it.
+func (k Key) PublicOnly() (Key, error) {
+	var public []byte
+	switch k.Algorithm {
+	case AlgEd25519:
+		pub, err := k.Ed25519PublicKey()
+		if err != nil {
+			return Key{}, err
+		}
+		public = pub
+	case AlgX25519:
+		pub, err := k.X25519PublicKey()
+		if err != nil {
+			return Key{}, err
+		}
+		public = pub.Bytes()
+	default:
+		return Key{}, fmt.Errorf("%w: %s keys have no public part", ErrWrongAlgorithm, k.Algorithm)
+	}
+	k.Material, k.Public = nil, public
+	return k, nil
+}
+
+// IsPublic reports whether the key holds only public material.
+func (k Key) IsPublic() bool { return len(k.Material) == 0 && len(k.Public) > 0 }
+
+// Active reports whether the key may be used to sign or encrypt new data.
+func (k Key) Active() bool { return k.RetiredAt == nil }
+
+// CanVerify reports whether the key may still be used to verify or decrypt data at the given time.
+func (k Key) CanVerify(now time.Time) bool {
+	return k.ExpiresAt == nil || now.Before(*k.ExpiresAt)
+}
+
+// Ed25519PrivateKey returns the signing key of an AlgEd25519 key.
+func (k Key) Ed25519PrivateKey() (ed25519.PrivateKey, error) {
+	if k.Algorithm != AlgEd25519 {
+		return nil, fmt.Errorf("%w: '%s' is %s, not %s", ErrWrongAlgorithm, k.ID, k.Algorithm, AlgEd25519)
+	}
+	if k.IsPublic() {
+		return nil, fmt.Errorf("%w: '%s'", ErrPublicOnly, k.ID)
+	}
+	if len(k.Material) != ed25519.SeedSize {
+		return nil, fmt.Errorf("invalid Ed25519 seed length for key '%s'", k.ID)
+	}
+	return ed25519.NewKeyFromSeed(k.Material), nil
+}
+
+// Ed25519PublicKey returns the verification key of an AlgEd25519 key.
+func (k Key) Ed25519PublicKey() (ed25519.PublicKey, error) {
+	if k.IsPublic() {
+		if k.Algorithm != AlgEd25519 {
+			return nil, fmt.Errorf("%w: '%s' is %s, not %s", ErrWrongAlgorithm, k.ID, k.Algorithm, AlgEd25519)
+		}
+		if len(k.Public) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid Ed25519 public key length for key '%s'", k.ID)
+		}
+		return ed25519.PublicKey(k.Public), nil
+	}
+	priv, err := k.Ed25519PrivateKey()
+	if err != nil {
+		return nil, err
+	}
+	return priv.Public().(ed25519.PublicKey), nil
+}
+
+// X25519PrivateKey returns the key agreement key of an AlgX25519 key.
+func (k Key) X25519PrivateKey() (*ecdh.PrivateKey, error) {
+	if k.Algorithm != AlgX25519 {
+		return nil, fmt.Errorf("%w: '%s' is %s, not %s", ErrWrongAlgorithm, k.ID, k.Algorithm, AlgX25519)
+	}
+	if k.IsPublic() {
+		return nil, fmt.Errorf("%w: '%s'", ErrPublicOnly, k.ID)
+	}
+	return ecdh.X25519().NewPrivateKey(k.Material)
+}
+
+// X25519PublicKey returns the public key agreement key of an AlgX25519 key,
+// used to encrypt data for its holder.
+func (k Key) X25519PublicKey() (*ecdh.PublicKey, error) {
+	if k.IsPublic() {
+		if k.Algorithm != AlgX25519 {
+			return nil, fmt.Errorf("%w: '%s' is %s, not %s", ErrWrongAlgorithm, k.ID, k.Algorithm, AlgX25519)
+		}
+		return ecdh.X25519().NewPublicKey(k.Public)
+	}
+	priv, err := k.X25519PrivateKey()
+	if err != nil {
+		return nil, err
+	}
+	return priv.PublicKey(), nil
+}
+
+// KeyStore holds a keyring. Implementations must be safe for concurrent use.
+type KeyStore interface {
+	// Get returns the key with the given ID, whether active or retired.
+	Get(id string) (Key, error)
+	// Active returns the newest active key for an algorithm.
+	Active(alg Algorithm) (Key, error)
+	// List returns all keys ordered by creation time.
+	List() ([]Key, error)
+	// Put adds a key or replaces the key with the same ID.
+	Put(key Key) error
+}
+
+// Verifiable returns the key with the given ID if it may still verify data now.
+func Verifiable(store KeyStore, id string) (Key, error) {
+	key, err := store.Get(id)
+	if err != nil {
+		return Key{}, err
+	}
+	if !key.CanVerify(time.Now()) {
+		return Key{}, fmt.Errorf("%w: '%s'", ErrKeyExpired, id)
+	}
+	return key, nil
+}
+
+// activeKey picks the newest active key for alg from a keyring. Public-only keys
+// cannot sign or encrypt and are never active.
+func activeKey(keys []Key, alg Algorithm) (Key, error) {
+	var active *Key
+	for i := range keys {
+		k := &keys[i]
+		if k.Algorithm == alg && k.Active() && !k.IsPublic() && (active == nil || k.CreatedAt.After(active.CreatedAt)) {
+			active = k
+		}
+	}
+	if active == nil {
+		return Key{}, fmt.Errorf("%w for algorithm %s", ErrNoActiveKey, alg)
+	}
+	return *active, nil
+}
+
+// findKey looks up a key by ID in a keyring.
+func findKey(keys []Key, id string) (Key, error) {
+	for _, k := range keys {
+		if k.ID == id {
+			return k, nil
+		}
+	}
+	return Key{}, fmt.Errorf("%w: '%s'", ErrKeyNotFound, id)
+}
+
+// putKey adds or replaces a key in a keyring, keeping it ordered by creation time.
+func putKey(keys []Key, key Key) []Key {
+	for i := range keys {
+		if keys[i].ID == key.ID {
+			keys[i] = key
+			return keys
+		}
+	}
+	keys = append(keys, key)
+	sort.SliceStable(keys, func(i, j int) bool { return keys[i].CreatedAt.Before(keys[j].CreatedAt) })
+	return keys
+}
@@ -0,0 +1,169 @@
+package keys
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerate(t *testing.T) {
+	for _, alg := range []Algorithm{AlgSymmetric, AlgEd25519, AlgX25519} {
+		t.Run(string(alg), func(t *testing.T) {
+			key, err := Generate(alg)
+			require.NoError(t, err)
+			assert.Len(t, key.ID, 16)
+			assert.Len(t, key.Material, 32)
+			assert.True(t, key.Active())
+			assert.True(t, key.CanVerify(time.Now()))
+		})
+	}
+
+	_, err := Generate("RSA")
+	assert.Error(t, err)
+
+	key, err := Generate(AlgEd25519)
+	require.NoError(t, err)
+	_, err = key.X25519PrivateKey()
+	assert.ErrorIs(t, err, ErrWrongAlgorithm)
+	_, err = key.Ed25519PublicKey()
+	assert.NoError(t, err)
+}
+
+func TestPublicKey(t *testing.T) {
+	for _, alg := range []Algorithm{AlgEd25519, AlgX25519} {
+		t.Run(string(alg), func(t *testing.T) {
+			key, err := Generate(alg)
+			require.NoError(t, err)
+			public, err := key.PublicOnly()
+			require.NoError(t, err)
+			assert.True(t, public.IsPublic())
+			assert.Empty(t, public.Material)
+			assert.Equal(t, key.ID, public.ID)
+
+			imported, err := NewPublicKey(public.ID, alg, public.Public)
+			require.NoError(t, err)
+			assert.Equal(t, public.Public, imported.Public)
+
+			store := NewMemoryStore(imported)
+			_, err = store.Active(alg)
+			assert.ErrorIs(t, err, ErrNoActiveKey, "public-only keys are never active")
+			_, err = Verifiable(store, key.ID)
+			assert.NoError(t, err)
+		})
+	}
+
+	key, err := Generate(AlgEd25519)
+	require.NoError(t, err)
+	public, err := key.PublicOnly()
+	require.NoError(t, err)
+	want, err := key.Ed25519PublicKey()
+	require.NoError(t, err)
+	got, err := public.Ed25519PublicKey()
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+	_, err = public.Ed25519PrivateKey()
+	assert.ErrorIs(t, err, ErrPublicOnly)
+
+	raw, err := json.Marshal(public)
+	require.NoError(t, err)
+	assert.NotContains(t, string(raw), "material")
+
+	_, err = NewPublicKey("k", AlgSymmetric, make([]byte, 32))
+	assert.ErrorIs(t, err, ErrWrongAlgorithm)
+	_, err = NewPublicKey("k", AlgEd25519, []byte("short"))
+	assert.Error(t, err)
+}
+
+func TestRotate(t *testing.T) {
+	store := NewMemoryStore()
+	_, err := store.Active(AlgSymmetric)
+	assert.ErrorIs(t, err, ErrNoActiveKey)
+
+	first, err := Rotate(store, AlgSymmetric, time.Hour)
+	require.NoError(t, err)
+	second, err := Rotate(store, AlgSymmetric, time.Hour)
+	require.NoError(t, err)
+
+	active, err := store.Active(AlgSymmetric)
+	require.NoError(t, err)
+	assert.Equal(t, second.ID, active.ID)
+
+	retired, err := store.Get(first.ID)
+	require.NoError(t, err)
+	assert.False(t, retired.Active())
+	require.NotNil(t, retired.ExpiresAt)
+
+	// The retired key verifies during the grace window only.
+	_, err = Verifiable(store, first.ID)
+	assert.NoError(t, err)
+	assert.False(t, retired.CanVerify(time.Now().Add(2*time.Hour)))
+
+	_, err = Rotate(store, AlgSymmetric, 0)
+	require.NoError(t, err)
+	_, err = Verifiable(store, second.ID)
+	assert.ErrorIs(t, err, ErrKeyExpired)
+
+	_, err = store.Get("missing")
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+
+	list, err := store.List()
+	require.NoError(t, err)
+	assert.Len(t, list, 3)
+}
+
+func TestRotator(t *testing.T) {
+	store := NewMemoryStore()
+	var rotated []string
+	r := &Rotator{
+		Store:     store,
+		Algorithm: AlgEd25519,
+		Interval:  24 * time.Hour,
+		Grace:     time.Hour,
+		OnRotate:  func(k Key) { rotated = append(rotated, k.ID) },
+	}
+
+	due, err := r.RotateIfDue(time.Now())
+	require.NoError(t, err)
+	assert.True(t, due, "a missing key is created")
+
+	due, err = r.RotateIfDue(time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	assert.False(t, due)
+
+	due, err = r.RotateIfDue(time.Now().Add(25 * time.Hour))
+	require.NoError(t, err)
+	assert.True(t, due)
+	assert.Len(t, rotated, 2)
+}
+
+func TestEnvStore(t *testing.T) {
+	t.Run("Legacy Secret", func(t *testing.T) {
+		t.Setenv(EnvKeyring, "")
+		t.Setenv(EnvSecret, "shh")
+		store, err := NewEnvStore()
+		require.NoError(t, err)
+
+		key, err := store.Active(AlgSymmetric)
+		require.NoError(t, err)
+		assert.Equal(t, LegacyKeyID, key.ID)
+		assert.Equal(t, []byte("shh"), key.Material)
+		assert.ErrorIs(t, store.Put(key), ErrReadOnly)
+	})
+
+	t.Run("Keyring", func(t *testing.T) {
+		key, err := Generate(AlgEd25519)
+		require.NoError(t, err)
+		raw, err := json.Marshal([]Key{key})
+		require.NoError(t, err)
+		t.Setenv(EnvKeyring, string(raw))
+
+		store, err := NewEnvStore()
+		require.NoError(t, err)
+		got, err := store.Get(key.ID)
+		require.NoError(t, err)
+		assert.Equal(t, key.Material, got.Material)
+	})
+}
@@ -0,0 +1,42 @@
+package keys
+
+import "sync"
+
+// MemoryStore keeps a keyring in memory, e.g. for tests or ephemeral processes.
+type MemoryStore struct {
+	mu   sync.RWMutex
+	keys []Key
+}
+
+func NewMemoryStore(keys ...Key) *MemoryStore {
+	s := &MemoryStore{}
+	for _, k := range keys {
+		s.keys = putKey(s.keys, k)
+	}
+	return s
+}
+
+func (s *MemoryStore) Get(id string) (Key, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return findKey(s.keys, id)
+}
+
+func (s *MemoryStore) Active(alg Algorithm) (Key, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return activeKey(s.keys, alg)
+}
+
+func (s *MemoryStore) List() ([]Key, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]Key{}, s.keys...), nil
+}
+
+func (s *MemoryStore) Put(key Key) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys = putKey(s.keys, key)
+	return nil
+}
@@ -0,0 +1,94 @@
+package keys
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Rotate generates a new active key for alg and retires the current one. The retired
+// key keeps verifying for the grace window so data signed just before the rotation
+// can still be opened; a zero grace window retires it immediately.
+func Rotate(store KeyStore, alg Algorithm, grace time.Duration) (Key, error) {
+	next, err := Generate(alg)
+	if err != nil {
+		return Key{}, err
+	}
+
+	current, err := store.Active(alg)
+	if err != nil && !errors.Is(err, ErrNoActiveKey) {
+		return Key{}, err
+	}
+	// Store the new key before retiring the old one so there is always an active key.
+	if err := store.Put(next); err != nil {
+		return Key{}, fmt.Errorf("failed to store new key: %w", err)
+	}
+	if err == nil {
+		now := next.CreatedAt
+		expires := now.Add(grace)
+		current.RetiredAt = &now
+		current.ExpiresAt = &expires
+		if err := store.Put(current); err != nil {
+			return Key{}, fmt.Errorf("failed to retire key '%s': %w", current.ID, err)
+		}
+	}
+	return next, nil
+}
+
+// Rotator rotates the active key of an algorithm on a schedule.
+type Rotator struct {
+	Store     KeyStore
+	Algorithm Algorithm
+	Interval  time.Duration // Maximum age of the active key
+	Grace     time.Duration // How long retired keys keep verifying
+	OnRotate  func(Key)     // Optional: called with each new key
+}
+
+// RotateIfDue rotates the key when the active one is older than Interval, or when
+// there is no active key. It reports whether a rotation happened.
+func (r *Rotator) RotateIfDue(now time.Time) (bool, error) {
+	current, err := r.Store.Active(r.Algorithm)
+	if err != nil && !errors.Is(err, ErrNoActiveKey) {
+		return false, err
+	}
+	if err == nil && now.Sub(current.CreatedAt) < r.Interval {
+		return false, nil
+	}
+	next, err := Rotate(r.Store, r.Algorithm, r.Grace)
+	if err != nil {
+		return false, err
+	}
+	if r.OnRotate != nil {
+		r.OnRotate(next)
+	}
+	return true, nil
+}
+
+// Run checks for due rotations until ctx is cancelled. Checks happen at a tenth of
+// the interval (at least once a minute) so a rotation is never late by much.
+func (r *Rotator) Run(ctx context.Context) error {
+	if r.Interval <= 0 {
+		return errors.New("rotation interval must be positive")
+	}
+	if _, err := r.RotateIfDue(time.Now()); err != nil {
+		return err
+	}
+
+	tick := min(r.Interval/10, time.Minute)
+	if tick <= 0 {
+		tick = r.Interval
+	}
+	ticker := time.NewTicker(tick)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case now := <-ticker.C:
+			if _, err := r.RotateIfDue(now); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,241 @@
+package mcp
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// --- Content Blocks ---
+// https://modelcontextprotocol.io/specification/2025-06-18/server/tools#tool-result
+
+// Content block type discriminators.
+const (
+	ContentTypeText         = "text"
+	ContentTypeImage        = "image"
+	ContentTypeAudio        = "audio"
+	ContentTypeResource     = "resource"
+	ContentTypeResourceLink = "resource_link"
+)
+
+// ContentBlock is implemented by every content type that can appear in
+// tool results, prompt messages and sampling messages.
+type ContentBlock interface {
+	ContentType() string
+}
+
+// Annotations tell clients how a content block is meant to be used.
+type Annotations struct {
+	Audience     []string `json:"audience,omitempty"`     // "user" and/or "assistant"
+	Priority     *float64 `json:"priority,omitempty"`     // 0 (optional) to 1 (required)
+	LastModified string   `json:"lastModified,omitempty"` // ISO 8601 timestamp
+}
+
+// TextContent is a plain text content block.
+type TextContent struct {
+	Type        string       `json:"type"` // Always "text"
+	Text        string       `json:"text"`
+	Annotations *Annotations `json:"annotations,omitempty"`
+}
+
+func NewTextContent(text string) TextContent {
+	return TextContent{
+		Type: ContentTypeText,
+		Text: text,
+	}
+}
+
+// ImageContent carries base64-encoded image data.
+type ImageContent struct {
+	Type        string       `json:"type"` // Always "image"
+	Data        string       `json:"data"` // Base64-encoded image bytes
+	MimeType    string       `json:"mimeType"`
+	Annotations *Annotations `json:"annotations,omitempty"`
+}
+
+func NewImageContent(data []byte, mimeType string) ImageContent {
+	return ImageContent{
+		Type:     ContentTypeImage,
+		Data:     base64.StdEncoding.EncodeToString(data),
+		MimeType: mimeType,
+	}
+}
+
+// Decode returns the raw image bytes.
+func (c ImageContent) Decode() ([]byte, error) { return base64.StdEncoding.DecodeString(c.Data) }
+
+// AudioContent carries base64-encoded audio data.
+type AudioContent struct {
+	Type        string       `json:"type"` // Always "audio"
+	Data        string       `json:"data"` // Base64-encoded audio bytes
+	MimeType    string       `json:"mimeType"`
+	Annotations *Annotations `json:"annotations,omitempty"`
+}
+
+func NewAudioContent(data []byte, mimeType string) AudioContent {
+	return AudioContent{
+		Type:     ContentTypeAudio,
+		Data:     base64.StdEncoding.EncodeToString(data),
+		MimeType: mimeType,
+	}
+}
+
+// Decode returns the raw audio bytes.
+func (c AudioContent) Decode() ([]byte, error) { return base64.StdEncoding.DecodeString(c.Data) }
+
+// ResourceContents holds the contents of a resource. Exactly one of Text or Blob is set.
+type ResourceContents struct {
+	URI      string `json:"uri"`
+	MimeType string `json:"mimeType,omitempty"`
+	Text     string `json:"text,omitempty"`
+	Blob     string `json:"blob,omitempty"` // Base64-encoded binary data
+}
+
+// EmbeddedResource embeds the contents of a resource directly in a message.
+type EmbeddedResource struct {
+	Type        string           `json:"type"` // Always "resource"
+	Resource    ResourceContents `json:"resource"`
+	Annotations *Annotations     `json:"annotations,omitempty"`
+}
+
+func NewEmbeddedResource(resource ResourceContents) EmbeddedResource {
+	return EmbeddedResource{
+		Type:     ContentTypeResource,
+		Resource: resource,
+	}
+}
+
+// ResourceLink points to a resource the client may fetch with resources/read.
+type ResourceLink struct {
+	Type        string       `json:"type"` // Always "resource_link"
+	URI         string       `json:"uri"`
+	Name        string       `json:"name"`
+	Description string       `json:"description,omitempty"`
+	MimeType    string       `json:"mimeType,omitempty"`
+	Size        *int64       `json:"size,omitempty"`
+	Annotations *Annotations `json:"annotations,omitempty"`
+}
+
+func NewResourceLink(uri, name string) ResourceLink {
+	return ResourceLink{
+		Type: ContentTypeResourceLink,
+		URI:  uri,
+		Name: name,
+	}
+}
+
+func (TextContent) ContentType() string      { return ContentTypeText }
+func (ImageContent) ContentType() string     { return ContentTypeImage }
+func (AudioContent) ContentType() string     { return ContentTypeAudio }
+func (EmbeddedResource) ContentType() string { return ContentTypeResource }
+func (ResourceLink) ContentType() string     { return ContentTypeResourceLink }
+
+// The MarshalJSON methods always write the correct type discriminator,
+// even for blocks built as struct literals.
+
+func (c TextContent) MarshalJSON() ([]byte, error) {
+	type alias TextContent
+	c.Type = ContentTypeText
+	return json.Marshal(alias(c))
+}
+
+func (c ImageContent) MarshalJSON() ([]byte, error) {
+	type alias ImageContent
+	c.Type = ContentTypeImage
+	return json.Marshal(alias(c))
+}
+
+func (c AudioContent) MarshalJSON() ([]byte, error) {
+	type alias AudioContent
+	c.Type = ContentTypeAudio
+	return json.Marshal(alias(c))
+}
+
+func (c EmbeddedResource) MarshalJSON() ([]byte, error) {
+	type alias EmbeddedResource
+	c.Type = ContentTypeResource
+	return json.Marshal(alias(c))
+}
+
+func (c ResourceLink) MarshalJSON() ([]byte, error) {
+	type alias ResourceLink
+	c.Type = ContentTypeResourceLink
+	return json.Marshal(alias(c))
+}
+
+// UnmarshalContent decodes a single content block based on its "type" field.
+func UnmarshalContent(raw json.RawMessage) (ContentBlock, error) {
+	var header struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(raw, &header); err != nil {
+		return nil, fmt.Errorf("invalid content block: %w", err)
+	}
+
+	var (
+		block ContentBlock
+		err   error
+	)
+	switch header.Type {
+	case ContentTypeText:
+		var c TextContent
+		err = json.Unmarshal(raw, &c)
+		block = c
+	case ContentTypeImage:
+		var c ImageContent
+		err = json.Unmarshal(raw, &c)
+		block = c
+	case ContentTypeAudio:
+		var c AudioContent
+		err = json.Unmarshal(raw, &c)
+		block = c
+	case ContentTypeResource:
+		var c EmbeddedResource
+		err = json.Unmarshal(raw, &c)
+		block = c
+	case ContentTypeResourceLink:
+		var c ResourceLink
+		err = json.Unmarshal(raw, &c)
+		block = c
+	case "":
+		return nil, fmt.Errorf("content block is missing its type")
+	default:
+		return nil, fmt.Errorf("unsupported content block type '%s'", header.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s content block: %w", header.Type, err)
+	}
+	return block, nil
+}
+
+// Contents is a list of content blocks of mixed types.
+type Contents []ContentBlock
+
+func (c *Contents) UnmarshalJSON(b []byte) error {
+	var raws []json.RawMessage
+	if err := json.Unmarshal(b, &raws); err != nil {
+		return err
+	}
+	blocks := make(Contents, 0, len(raws))
+	for _, raw := range raws {
+		block, err := UnmarshalContent(raw)
+		if err != nil {
+			return err
+		}
+		blocks = append(blocks, block)
+	}
+	*c = blocks
+	return nil
+}
+
+// Text joins the text of all text blocks, separated by newlines.
+func (c Contents) Text() string {
+	var parts []string
+	for _, block := range c {
+		if text, ok := block.(TextContent); ok {
+			parts = append(parts, text.Text)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
@@ -0,0 +1,92 @@
+package mcp
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContentsRoundTrip(t *testing.T) {
+	priority := 0.9
+	size := int64(2048)
+	original := Contents{
+		TextContent{Text: "Here is the chart", Annotations: &Annotations{Audience: []string{"user"}, Priority: &priority}},
+		NewImageContent([]byte{0x89, 'P', 'N', 'G'}, "image/png"),
+		NewAudioContent([]byte("RIFF"), "audio/wav"),
+		NewEmbeddedResource(ResourceContents{URI: "file:///notes.md", MimeType: "text/markdown", Text: "# Notes"}),
+		ResourceLink{URI: "file:///report.pdf", Name: "report.pdf", MimeType: "application/pdf", Size: &size},
+	}
+
+	b, err := json.Marshal(original)
+	require.NoError(t, err)
+	assert.Contains(t, string(b), `"type":"text"`, "type must be written for struct literals")
+	assert.Contains(t, string(b), `"type":"resource_link"`)
+
+	var decoded Contents
+	require.NoError(t, json.Unmarshal(b, &decoded))
+	require.Len(t, decoded, len(original))
+
+	text, ok := decoded[0].(TextContent)
+	require.True(t, ok)
+	assert.Equal(t, "Here is the chart", text.Text)
+	assert.Equal(t, []string{"user"}, text.Annotations.Audience)
+	assert.Equal(t, 0.9, *text.Annotations.Priority)
+
+	image, ok := decoded[1].(ImageContent)
+	require.True(t, ok)
+	data, err := image.Decode()
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0x89, 'P', 'N', 'G'}, data)
+	assert.Equal(t, "image/png", image.MimeType)
+
+	assert.Equal(t, ContentTypeAudio, decoded[2].ContentType())
+
+	resource, ok := decoded[3].(EmbeddedResource)
+	require.True(t, ok)
+	assert.Equal(t, "# Notes", resource.Resource.Text)
+
+	link, ok := decoded[4].(ResourceLink)
+	require.True(t, ok)
+	assert.Equal(t, int64(2048), *link.Size)
+
+	assert.Equal(t, "Here is the chart", decoded.Text())
+}
+
+func TestUnmarshalContent_Errors(t *testing.T) {
+	_, err := UnmarshalContent(json.RawMessage(`{"text": "no type"}`))
+	assert.ErrorContains(t, err, "missing its type")
+
+	_, err = UnmarshalContent(json.RawMessage(`{"type": "video", "data": ""}`))
+	assert.ErrorContains(t, err, "unsupported content block type 'video'")
+
+	var contents Contents
+	assert.Error(t, json.Unmarshal([]byte(`[{"type": "text", "text": "ok"}, {"type": "hologram"}]`), &contents))
+}
+
+func TestPromptMessageContent(t *testing.T) {
+	raw := `{"role": "user", "content": {"type": "resource", "resource": {"uri": "file:///main.go", "text": "package main"}}}`
+
+	var msg PromptMessage
+	require.NoError(t, json.Unmarshal([]byte(raw), &msg))
+	assert.Equal(t, "user", msg.Role)
+	resource, ok := msg.Content.(EmbeddedResource)
+	require.True(t, ok)
+	assert.Equal(t, "file:///main.go", resource.Resource.URI)
+}
+
+func TestSamplingContentRestrictions(t *testing.T) {
+	var msg SamplingMessage
+	require.NoError(t, json.Unmarshal([]byte(`{"role": "user", "content": {"type": "image", "data": "AAAA", "mimeType": "image/jpeg"}}`), &msg))
+	assert.Equal(t, ContentTypeImage, msg.Content.ContentType())
+
+	err := json.Unmarshal([]byte(`{"role": "user", "content": {"type": "resource_link", "uri": "file:///x", "name": "x"}}`), &msg)
+	assert.ErrorContains(t, err, "not allowed in sampling messages")
+
+	var result CreateMessageResult
+	require.NoError(t, json.Unmarshal([]byte(`{"role": "assistant", "content": {"type": "text", "text": "Hi"}, "model": "m-1", "stopReason": "endTurn"}`), &result))
+	assert.Equal(t, "m-1", result.Model)
+	assert.Equal(t, "endTurn", result.StopReason)
+	assert.Equal(t, "Hi", result.Content.(TextContent).Text)
+}
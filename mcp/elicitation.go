@@ -0,0 +1,115 @@
+package mcp
+
+import (
+	"errors"
+	"fmt"
+)
+
+// --- Elicitation ---
+// https://modelcontextprotocol.io/specification/2025-06-18/client/elicitation
+
+// ElicitAction is the user's response to an elicitation request.
+type ElicitAction string
+
+const (
+	ElicitAccept  ElicitAction = "accept"  // User submitted the requested data
+	ElicitDecline ElicitAction = "decline" // User explicitly declined to provide the data
+	ElicitCancel  ElicitAction = "cancel"  // User dismissed the request without choosing
+)
+
+// Primitive types allowed for elicitation schema properties.
+const (
+	SchemaTypeString  = "string"
+	SchemaTypeNumber  = "number"
+	SchemaTypeInteger = "integer"
+	SchemaTypeBoolean = "boolean"
+)
+
+// PrimitiveSchema describes a single flat property of a requested schema.
+// Only string, number, integer and boolean types are allowed; enums are
+// expressed as strings with an Enum list.
+type PrimitiveSchema struct {
+	Type        string   `json:"type"`
+	Title       string   `json:"title,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Enum        []string `json:"enum,omitempty"`      // Only valid for string types
+	EnumNames   []string `json:"enumNames,omitempty"` // Display names for Enum values
+	MinLength   *int     `json:"minLength,omitempty"`
+	MaxLength   *int     `json:"maxLength,omitempty"`
+	Format      string   `json:"format,omitempty"` // email, uri, date, date-time
+	Minimum     *float64 `json:"minimum,omitempty"`
+	Maximum     *float64 `json:"maximum,omitempty"`
+	Default     any      `json:"default,omitempty"`
+}
+
+// ElicitationSchema is the restricted JSON Schema a server may request.
+// It must be an object whose properties are all primitives.
+type ElicitationSchema struct {
+	Type       string                     `json:"type"`
+	Properties map[string]PrimitiveSchema `json:"properties"`
+	Required   []string                   `json:"required,omitempty"`
+}
+
+func NewElicitationSchema() ElicitationSchema {
+	return ElicitationSchema{
+		Type:       "object",
+		Properties: make(map[string]PrimitiveSchema),
+	}
+}
+
+var validStringFormats = map[string]bool{
+	"":          true,
+	"email":     true,
+	"uri":       true,
+	"date":      true,
+	"date-time": true,
+}
+
+// Validate checks that the schema only uses flat primitive properties.
+func (s *ElicitationSchema) Validate() error {
+	if s.Type != "object" {
+		return fmt.Errorf("elicitation schema must be of type 'object', got '%s'", s.Type)
+	}
+	if len(s.Properties) == 0 {
+		return errors.New("elicitation schema must define at least one property")
+	}
+	for name, prop := range s.Properties {
+		switch prop.Type {
+		case SchemaTypeString:
+			if !validStringFormats[prop.Format] {
+				return fmt.Errorf("property '%s': unsupported string format '%s'", name, prop.Format)
+			}
+			if len(prop.EnumNames) > 0 && len(prop.EnumNames) != len(prop.Enum) {
+				return fmt.Errorf("property '%s': enumNames must match enum length", name)
+			}
+		case SchemaTypeNumber, SchemaTypeInteger, SchemaTypeBoolean:
+			if len(prop.Enum) > 0 || prop.Format != "" || prop.MinLength != nil || prop.MaxLength != nil {
+				return fmt.Errorf("property '%s': string constraints are not allowed on type '%s'", name, prop.Type)
+			}
+		default:
+			return fmt.Errorf("property '%s': type '%s' is not a primitive", name, prop.Type)
+		}
+	}
+	for _, req := range s.Required {
+		if _, ok := s.Properties[req]; !ok {
+			return fmt.Errorf("required property '%s' is not defined", req)
+		}
+	}
+	return nil
+}
+
+// ElicitRequestParams are sent by the server with an elicitation/create request.
+type ElicitRequestParams struct {
+	Message         string            `json:"message"`
+	RequestedSchema ElicitationSchema `json:"requestedSchema"`
+}
+
+// ElicitResult is returned by the client in response to elicitation/create.
+// Content is only present when Action is ElicitAccept.
+type ElicitResult struct {
+	Action  ElicitAction   `json:"action"`
+	Content map[string]any `json:"content,omitempty"`
+}
+
+// Accepted reports whether the user submitted data.
+func (r *ElicitResult) Accepted() bool { return r.Action == ElicitAccept }
@@ -36,4 +36,12 @@ const (
 	// Invokes a specific tool with provided parameters.
 	// https://modelcontextprotocol.io/specification/2025-03-26/server/tools
 	MethodToolsCall string = "tools/call"
+
+	// Requests an LLM completion from the client.
+	// https://modelcontextprotocol.io/specification/2025-06-18/client/sampling
+	MethodSamplingCreateMessage string = "sampling/createMessage"
+
+	// Requests additional information from the user through the client.
+	// https://modelcontextprotocol.io/specification/2025-06-18/client/elicitation
+	MethodElicitationCreate string = "elicitation/create"
 )
@@ -9,4 +9,8 @@ const (
 	MemoryReplace MCPNotification = "memory/replace"
 	ToolResponse  MCPNotification = "tool/response"
 	LogEvent      MCPNotification = "log/event"
+
+	// Sent by servers when the list of tools they offer changes.
+	// https://modelcontextprotocol.io/specification/2025-06-18/server/tools#list-changed-notification
+	ToolsListChanged MCPNotification = "notifications/tools/list_changed"
 )
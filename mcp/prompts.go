@@ -1 +1,64 @@
 package mcp
+
+import (
+	"encoding/json"
+)
+
+// --- Prompts ---
+// https://modelcontextprotocol.io/specification/2025-06-18/server/prompts
+
+// Prompt describes a prompt template offered by the server.
+type Prompt struct {
+	Name        string           `json:"name"`
+	Title       string           `json:"title,omitempty"`
+	Description string           `json:"description,omitempty"`
+	Arguments   []PromptArgument `json:"arguments,omitempty"`
+}
+
+// PromptArgument describes an argument a prompt template accepts.
+type PromptArgument struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Required    bool   `json:"required,omitempty"`
+}
+
+type ListPromptsResult struct {
+	Prompts    []Prompt `json:"prompts"`
+	NextCursor string   `json:"nextCursor,omitempty"`
+}
+
+// GetPromptParams are sent by the client with a prompts/get request.
+type GetPromptParams struct {
+	Name      string            `json:"name"`
+	Arguments map[string]string `json:"arguments,omitempty"`
+}
+
+// GetPromptResult is returned by the server for a prompts/get request.
+type GetPromptResult struct {
+	Description string          `json:"description,omitempty"`
+	Messages    []PromptMessage `json:"messages"`
+}
+
+// PromptMessage is a single message of a prompt. Content may be text,
+// an image, audio, an embedded resource or a resource link.
+type PromptMessage struct {
+	Role    string       `json:"role"` // "user" or "assistant"
+	Content ContentBlock `json:"content"`
+}
+
+func (m *PromptMessage) UnmarshalJSON(b []byte) error {
+	var raw struct {
+		Role    string          `json:"role"`
+		Content json.RawMessage `json:"content"`
+	}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	content, err := UnmarshalContent(raw.Content)
+	if err != nil {
+		return err
+	}
+	m.Role = raw.Role
+	m.Content = content
+	return nil
+}
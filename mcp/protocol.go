@@ -6,6 +6,13 @@ import (
 	"sync"
 )
 
+var (
+	// ErrMethodNotFound is returned when no handler is registered for a request method.
+	ErrMethodNotFound = errors.New("method not found")
+	// ErrNotificationNotFound is returned when no handler is registered for a notification method.
+	ErrNotificationNotFound = errors.New("notification method not found")
+)
+
 type RequestHandlerExtra struct {
 	// Add contextual info if needed (e.g., trace IDs, client metadata)
 	Context context.Context
@@ -61,7 +68,7 @@ func (p *Protocol) HandleRequest(method string, request any, extra RequestHandle
 	handlerEntry, ok := p.reqHandlers[method]
 	p.mu.RUnlock()
 	if !ok {
-		return nil, errors.New("method not found")
+		return nil, ErrMethodNotFound
 	}
 	return handlerEntry.handler(request, extra)
 }
@@ -71,7 +78,7 @@ func (p *Protocol) HandleNotification(method string, notification any) error {
 	handlerEntry, ok := p.notificationHandlers[method]
 	p.mu.RUnlock()
 	if !ok {
-		return errors.New("notification method not found")
+		return ErrNotificationNotFound
 	}
 	return handlerEntry.handler(notification)
 }
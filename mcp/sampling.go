@@ -0,0 +1,99 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// --- Sampling ---
+// https://modelcontextprotocol.io/specification/2025-06-18/client/sampling
+
+// SamplingMessage is a message sent to or returned from the client's LLM.
+// Content is limited to text, image and audio blocks.
+type SamplingMessage struct {
+	Role    string       `json:"role"` // "user" or "assistant"
+	Content ContentBlock `json:"content"`
+}
+
+func (m *SamplingMessage) UnmarshalJSON(b []byte) error {
+	var raw struct {
+		Role    string          `json:"role"`
+		Content json.RawMessage `json:"content"`
+	}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	content, err := samplingContent(raw.Content)
+	if err != nil {
+		return err
+	}
+	m.Role = raw.Role
+	m.Content = content
+	return nil
+}
+
+// samplingContent decodes a content block and rejects types sampling does not allow.
+func samplingContent(raw json.RawMessage) (ContentBlock, error) {
+	content, err := UnmarshalContent(raw)
+	if err != nil {
+		return nil, err
+	}
+	switch content.ContentType() {
+	case ContentTypeText, ContentTypeImage, ContentTypeAudio:
+		return content, nil
+	default:
+		return nil, fmt.Errorf("content type '%s' is not allowed in sampling messages", content.ContentType())
+	}
+}
+
+// ModelHint suggests a model by (partial) name.
+type ModelHint struct {
+	Name string `json:"name,omitempty"`
+}
+
+// ModelPreferences express the server's priorities when the client selects a model.
+// Priorities range from 0 to 1.
+type ModelPreferences struct {
+	Hints                []ModelHint `json:"hints,omitempty"`
+	CostPriority         *float64    `json:"costPriority,omitempty"`
+	SpeedPriority        *float64    `json:"speedPriority,omitempty"`
+	IntelligencePriority *float64    `json:"intelligencePriority,omitempty"`
+}
+
+// CreateMessageParams are sent by the server with a sampling/createMessage request.
+type CreateMessageParams struct {
+	Messages         []SamplingMessage `json:"messages"`
+	ModelPreferences *ModelPreferences `json:"modelPreferences,omitempty"`
+	SystemPrompt     string            `json:"systemPrompt,omitempty"`
+	IncludeContext   string            `json:"includeContext,omitempty"` // "none", "thisServer" or "allServers"
+	Temperature      *float64          `json:"temperature,omitempty"`
+	MaxTokens        int               `json:"maxTokens"`
+	StopSequences    []string          `json:"stopSequences,omitempty"`
+	Metadata         map[string]any    `json:"metadata,omitempty"`
+}
+
+// CreateMessageResult is returned by the client for a sampling/createMessage request.
+type CreateMessageResult struct {
+	Role       string       `json:"role"`
+	Content    ContentBlock `json:"content"`
+	Model      string       `json:"model"`
+	StopReason string       `json:"stopReason,omitempty"`
+}
+
+func (r *CreateMessageResult) UnmarshalJSON(b []byte) error {
+	type alias CreateMessageResult
+	var raw struct {
+		alias
+		Content json.RawMessage `json:"content"`
+	}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	content, err := samplingContent(raw.Content)
+	if err != nil {
+		return err
+	}
+	*r = CreateMessageResult(raw.alias)
+	r.Content = content
+	return nil
+}
@@ -0,0 +1,55 @@
+package mcp
+
+import (
+	"encoding/json"
+)
+
+// --- Tool Call Structures ---
+// https://modelcontextprotocol.io/specification/2025-06-18/server/tools
+
+// CallToolParams are sent by the client with a tools/call request.
+type CallToolParams struct {
+	Name      string          `json:"name"`
+	Arguments json.RawMessage `json:"arguments,omitempty"`
+	Meta      *RequestMeta    `json:"_meta,omitempty"`
+}
+
+// RequestMeta is the _meta object of a request.
+//
+// https://modelcontextprotocol.io/specification/2025-06-18/basic#meta
+type RequestMeta struct {
+	// Turn names the assistant turn a tool call belongs to, so that a server can
+	// enforce a limit on the calls made per turn.
+	Turn string `json:"gomcp/turn,omitempty"`
+}
+
+// CallToolResult is returned by the server for a tools/call request.
+//
+// StructuredContent carries a JSON object conforming to the tool's OutputSchema.
+// For backwards compatibility, servers also serialize it into a text block in Content.
+type CallToolResult struct {
+	Content           Contents        `json:"content"`
+	StructuredContent json.RawMessage `json:"structuredContent,omitempty"`
+	IsError           bool            `json:"isError,omitempty"` // Tool ran but reported a failure
+}
+
+// NewToolErrorResult creates a result reporting a tool execution error to the caller.
+func NewToolErrorResult(message string) *CallToolResult {
+	return &CallToolResult{
+		Content: Contents{NewTextContent(message)},
+		IsError: true,
+	}
+}
+
+// NewStructuredToolResult creates a result whose structured content is the JSON encoding
+// of v, mirrored into a text block for clients that do not read structured content.
+func NewStructuredToolResult(v any) (*CallToolResult, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return &CallToolResult{
+		Content:           Contents{NewTextContent(string(b))},
+		StructuredContent: b,
+	}, nil
+}
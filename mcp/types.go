@@ -16,6 +16,10 @@ type SamplingCapabilities struct {
 	// Empty object {} indicates support
 }
 
+type ElicitationCapabilities struct {
+	// Empty object {} indicates support
+}
+
 type LoggingCapabilities struct {
 	// Empty object {} indicates support
 }
@@ -39,6 +43,7 @@ type ExperimentalCapabilities map[string]any
 type ClientCapabilities struct {
 	Roots        *RootCapabilities        `json:"roots,omitempty"`
 	Sampling     *SamplingCapabilities    `json:"sampling,omitempty"`
+	Elicitation  *ElicitationCapabilities `json:"elicitation,omitempty"`
 	Experimental ExperimentalCapabilities `json:"experimental,omitempty"`
 }
 
@@ -0,0 +1,124 @@
+package recall
+
+import (
+	"math"
+)
+
+// BM25Index ranks texts against keyword queries with Okapi BM25.
+type BM25Index struct {
+	K1 float64 // Term frequency saturation; defaults to 1.2
+	B  float64 // Document length normalization; defaults to 0.75
+
+	docs     map[string]bm25Doc
+	postings map[string]map[string]int // Term to the documents containing it and how often
+	totalLen int
+}
+
+type bm25Doc struct {
+	terms  []string // Distinct terms, to update postings on removal
+	length int
+}
+
+func NewBM25Index() *BM25Index {
+	return &BM25Index{
+		docs:     make(map[string]bm25Doc),
+		postings: make(map[string]map[string]int),
+	}
+}
+
+// Add indexes text under id, replacing any text already there.
+func (b *BM25Index) Add(id, text string) {
+	b.Remove(id)
+	words := terms(text)
+	counts := make(map[string]int)
+	for _, term := range words {
+		counts[term]++
+	}
+	doc := bm25Doc{length: len(words)}
+	for term, count := range counts {
+		if b.postings[term] == nil {
+			b.postings[term] = make(map[string]int)
+		}
+		b.postings[term][id] = count
+		doc.terms = append(doc.terms, term)
+	}
+	b.docs[id] = doc
+	b.totalLen += doc.length
+}
+
+// Remove drops the text indexed under id, if any.
+func (b *BM25Index) Remove(id string) {
+	doc, ok := b.docs[id]
+	if !ok {
+		return
+	}
+	for _, term := range doc.terms {
+		delete(b.postings[term], id)
+		if len(b.postings[term]) == 0 {
+			delete(b.postings, term)
+		}
+	}
+	b.totalLen -= doc.length
+	delete(b.docs, id)
+}
+
+// Search returns up to k texts matching any query term, best first.
+func (b *BM25Index) Search(query string, k int) []Hit {
+	scores := make(map[string]float64)
+	for _, term := range distinct(terms(query)) {
+		for id := range b.postings[term] {
+			scores[id] += b.termScore(term, id)
+		}
+	}
+	hits := make([]Hit, 0, len(scores))
+	for id, score := range scores {
+		hits = append(hits, Hit{ID: id, Score: score})
+	}
+	return topHits(hits, k)
+}
+
+// Score returns the BM25 score of the text indexed under id for query, zero if
+// it matches no query term or is not indexed.
+func (b *BM25Index) Score(query, id string) float64 {
+	var score float64
+	for _, term := range distinct(terms(query)) {
+		score += b.termScore(term, id)
+	}
+	return score
+}
+
+func (b *BM25Index) Len() int {
+	return len(b.docs)
+}
+
+func (b *BM25Index) termScore(term, id string) float64 {
+	frequency := float64(b.postings[term][id])
+	if frequency == 0 {
+		return 0
+	}
+	k1, bParam := b.K1, b.B
+	if k1 <= 0 {
+		k1 = 1.2
+	}
+	if bParam <= 0 {
+		bParam = 0.75
+	}
+	n := float64(len(b.docs))
+	df := float64(len(b.postings[term]))
+	idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+	avgLen := float64(b.totalLen) / n
+	length := float64(b.docs[id].length)
+	return idf * frequency * (k1 + 1) / (frequency + k1*(1-bParam+bParam*length/avgLen))
+}
+
+func distinct(words []string) []string {
+	seen := make(map[string]bool, len(words))
+	unique := words[:0:0]
+	for _, word := range words {
+		if !seen[word] {
+			seen[word] = true
+			unique = append(unique, word)
+		}
+	}
+	return unique
+}
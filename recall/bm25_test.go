@@ -0,0 +1,35 @@
+package recall
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBM25Index_Ranking(t *testing.T) {
+	index := NewBM25Index()
+	index.Add("deploy", "Deploy the service to staging, then deploy to production.")
+	index.Add("config", "The service reads its config from config.yaml.")
+	index.Add("lunch", "Lunch is at noon.")
+
+	hits := index.Search("how do we DEPLOY the service?", 10)
+	require.Len(t, hits, 2, "only texts sharing a term match")
+	assert.Equal(t, "deploy", hits[0].ID, "repeated rare terms rank higher")
+	assert.Equal(t, "config", hits[1].ID)
+	assert.InDelta(t, hits[0].Score, index.Score("how do we DEPLOY the service?", "deploy"), 1e-9)
+	assert.Zero(t, index.Score("deploy", "lunch"))
+}
+
+func TestBM25Index_AddReplacesAndRemove(t *testing.T) {
+	index := NewBM25Index()
+	index.Add("a", "alpha beta")
+	index.Add("a", "gamma")
+	assert.Empty(t, index.Search("alpha", 10))
+	assert.Equal(t, 1, index.Len())
+
+	index.Remove("a")
+	assert.Empty(t, index.Search("gamma", 10))
+	assert.Empty(t, index.postings, "postings of removed texts are dropped")
+	assert.Zero(t, index.totalLen)
+}
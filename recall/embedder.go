@@ -0,0 +1,81 @@
+package recall
+
+import (
+	"context"
+	"hash/fnv"
+	"math"
+	"strings"
+	"unicode"
+)
+
+// Embedder turns texts into vectors whose cosine similarity reflects how related
+// the texts are. Implementations typically call an embedding model; every vector
+// an embedder returns must have the same number of dimensions.
+type Embedder interface {
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// HashingEmbedder embeds text by hashing its words into a fixed number of
+// dimensions. It needs no model and is deterministic, which makes it useful for
+// tests and as a lexical fallback, but it only matches shared words.
+type HashingEmbedder struct {
+	Dimensions int // Defaults to 256
+}
+
+func (e HashingEmbedder) Embed(_ context.Context, texts []string) ([][]float32, error) {
+	dimensions := e.Dimensions
+	if dimensions <= 0 {
+		dimensions = 256
+	}
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		vector := make([]float32, dimensions)
+		for _, term := range terms(text) {
+			h := fnv.New64a()
+			h.Write([]byte(term))
+			sum := h.Sum64()
+			// The top bit picks a sign so unrelated words tend to cancel out
+			// rather than pile up in the same dimension.
+			if sum>>63 == 0 {
+				vector[sum%uint64(dimensions)]++
+			} else {
+				vector[sum%uint64(dimensions)]--
+			}
+		}
+		vectors[i] = normalize(vector)
+	}
+	return vectors, nil
+}
+
+// terms splits text into lowercase words for keyword search and hashing.
+func terms(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// normalize returns a copy of v scaled to unit length, so a dot product is the
+// cosine similarity. The zero vector is returned unchanged.
+func normalize(v []float32) []float32 {
+	var norm float64
+	for _, x := range v {
+		norm += float64(x) * float64(x)
+	}
+	normalized := make([]float32, len(v))
+	if norm == 0 {
+		return normalized
+	}
+	norm = math.Sqrt(norm)
+	for i, x := range v {
+		normalized[i] = float32(float64(x) / norm)
+	}
+	return normalized
+}
+
+func dot(a, b []float32) float64 {
+	var sum float64
+	for i := range min(len(a), len(b)) {
+		sum += float64(a[i]) * float64(b[i])
+	}
+	return sum
+}
@@ -0,0 +1,268 @@
+package recall
+
+import (
+	"container/heap"
+	"math"
+	"math/rand"
+	"slices"
+)
+
+// HNSWConfig tunes an HNSWIndex. Zero fields take their defaults.
+type HNSWConfig struct {
+	M              int   // Neighbors kept per node and layer, twice that on the bottom layer; defaults to 16
+	EfConstruction int   // Candidates considered when adding a vector; defaults to 200
+	EfSearch       int   // Candidates considered when searching, at least k; defaults to 64
+	Seed           int64 // Seeds the random layer assignment, so a graph can be rebuilt identically
+}
+
+// HNSWIndex is an approximate nearest neighbour index using a hierarchical
+// navigable small world graph. Searches visit a small part of the graph, so they
+// stay fast for large indexes at the cost of occasionally missing a neighbour.
+//
+// Removed vectors stay in the graph to keep it connected, and are skipped in
+// results; once they outnumber the live vectors the graph is rebuilt.
+type HNSWIndex struct {
+	config   HNSWConfig
+	rng      *rand.Rand
+	nodes    []*hnswNode
+	byID     map[string]int
+	entry    int // Node searches start from, -1 when empty
+	maxLevel int
+	removed  int
+}
+
+type hnswNode struct {
+	id        string
+	vector    []float32
+	neighbors [][]int // Neighbor node indexes per layer, up to the node's level
+	removed   bool
+}
+
+func NewHNSWIndex(config HNSWConfig) *HNSWIndex {
+	if config.M <= 0 {
+		config.M = 16
+	}
+	if config.EfConstruction <= 0 {
+		config.EfConstruction = 200
+	}
+	if config.EfSearch <= 0 {
+		config.EfSearch = 64
+	}
+	h := &HNSWIndex{config: config}
+	h.reset()
+	return h
+}
+
+func (h *HNSWIndex) reset() {
+	h.rng = rand.New(rand.NewSource(h.config.Seed))
+	h.nodes = nil
+	h.byID = make(map[string]int)
+	h.entry = -1
+	h.maxLevel = 0
+	h.removed = 0
+}
+
+func (h *HNSWIndex) Add(id string, vector []float32) {
+	if _, ok := h.byID[id]; ok {
+		h.Remove(id)
+	}
+	h.insert(id, normalize(vector))
+}
+
+func (h *HNSWIndex) insert(id string, vector []float32) {
+	level := h.randomLevel()
+	index := len(h.nodes)
+	node := &hnswNode{id: id, vector: vector, neighbors: make([][]int, level+1)}
+	h.nodes = append(h.nodes, node)
+	h.byID[id] = index
+	if h.entry < 0 {
+		h.entry, h.maxLevel = index, level
+		return
+	}
+
+	// Descend greedily through the layers above the node's own, then link it to
+	// its nearest neighbours on every layer it belongs to.
+	entry := []int{h.entry}
+	for l := h.maxLevel; l > level; l-- {
+		entry = []int{h.searchLayer(vector, entry, 1, l)[0].node}
+	}
+	for l := min(level, h.maxLevel); l >= 0; l-- {
+		found := h.searchLayer(vector, entry, h.config.EfConstruction, l)
+		limit := h.maxNeighbors(l)
+		for _, c := range found[:min(limit, len(found))] {
+			node.neighbors[l] = append(node.neighbors[l], c.node)
+			h.connect(c.node, index, l)
+		}
+		entry = entry[:0]
+		for _, c := range found {
+			entry = append(entry, c.node)
+		}
+	}
+	if level > h.maxLevel {
+		h.entry, h.maxLevel = index, level
+	}
+}
+
+// connect links from to to on a layer, dropping from's farthest neighbour if it
+// then has too many.
+func (h *HNSWIndex) connect(from, to, level int) {
+	node := h.nodes[from]
+	node.neighbors[level] = append(node.neighbors[level], to)
+	limit := h.maxNeighbors(level)
+	if len(node.neighbors[level]) <= limit {
+		return
+	}
+	slices.SortFunc(node.neighbors[level], func(a, b int) int {
+		da, db := distance(node.vector, h.nodes[a].vector), distance(node.vector, h.nodes[b].vector)
+		switch {
+		case da < db:
+			return -1
+		case da > db:
+			return 1
+		}
+		return a - b
+	})
+	node.neighbors[level] = node.neighbors[level][:limit]
+}
+
+func (h *HNSWIndex) Remove(id string) {
+	index, ok := h.byID[id]
+	if !ok {
+		return
+	}
+	delete(h.byID, id)
+	h.nodes[index].removed = true
+	h.removed++
+	if h.removed > len(h.byID) {
+		h.rebuild()
+	}
+}
+
+// rebuild builds a fresh graph from the live vectors, in the order they were added.
+func (h *HNSWIndex) rebuild() {
+	nodes := h.nodes
+	h.reset()
+	for _, node := range nodes {
+		if !node.removed {
+			h.insert(node.id, node.vector)
+		}
+	}
+}
+
+func (h *HNSWIndex) Search(vector []float32, k int) []Hit {
+	if k <= 0 || len(h.byID) == 0 {
+		return nil
+	}
+	query := normalize(vector)
+	entry := []int{h.entry}
+	for l := h.maxLevel; l > 0; l-- {
+		entry = []int{h.searchLayer(query, entry, 1, l)[0].node}
+	}
+	found := h.searchLayer(query, entry, max(h.config.EfSearch, k), 0)
+
+	hits := make([]Hit, 0, len(found))
+	for _, c := range found {
+		if node := h.nodes[c.node]; !node.removed {
+			hits = append(hits, Hit{ID: node.id, Score: 1 - c.distance})
+		}
+	}
+	return topHits(hits, k)
+}
+
+func (h *HNSWIndex) Len() int {
+	return len(h.byID)
+}
+
+func (h *HNSWIndex) maxNeighbors(level int) int {
+	if level == 0 {
+		return 2 * h.config.M
+	}
+	return h.config.M
+}
+
+// randomLevel draws a node's top layer from an exponentially decaying
+// distribution, so each layer holds about 1/M of the nodes of the one below.
+func (h *HNSWIndex) randomLevel() int {
+	return int(-math.Log(1-h.rng.Float64()) / math.Log(float64(h.config.M)))
+}
+
+// searchLayer returns up to ef nodes on a layer nearest to query, nearest first,
+// searching outwards from the entry nodes.
+func (h *HNSWIndex) searchLayer(query []float32, entry []int, ef, level int) []candidate {
+	visited := make(map[int]bool)
+	candidates := &candidateHeap{}
+	results := &candidateHeap{farthestFirst: true}
+	for _, node := range entry {
+		c := candidate{node: node, distance: distance(query, h.nodes[node].vector)}
+		visited[node] = true
+		heap.Push(candidates, c)
+		heap.Push(results, c)
+	}
+	for candidates.Len() > 0 {
+		nearest := heap.Pop(candidates).(candidate)
+		if results.Len() >= ef && nearest.distance > results.items[0].distance {
+			break
+		}
+		for _, neighbor := range h.nodes[nearest.node].neighbors[level] {
+			if visited[neighbor] {
+				continue
+			}
+			visited[neighbor] = true
+			c := candidate{node: neighbor, distance: distance(query, h.nodes[neighbor].vector)}
+			if results.Len() < ef || c.distance < results.items[0].distance {
+				heap.Push(candidates, c)
+				heap.Push(results, c)
+				if results.Len() > ef {
+					heap.Pop(results)
+				}
+			}
+		}
+	}
+
+	found := results.items
+	slices.SortFunc(found, func(a, b candidate) int {
+		switch {
+		case a.distance < b.distance:
+			return -1
+		case a.distance > b.distance:
+			return 1
+		}
+		return a.node - b.node
+	})
+	return found
+}
+
+// distance is the cosine distance between two normalized vectors.
+func distance(a, b []float32) float64 {
+	return 1 - dot(a, b)
+}
+
+type candidate struct {
+	node     int
+	distance float64
+}
+
+// candidateHeap is a heap of candidates, nearest or farthest on top.
+type candidateHeap struct {
+	items         []candidate
+	farthestFirst bool
+}
+
+func (h *candidateHeap) Len() int { return len(h.items) }
+
+func (h *candidateHeap) Less(i, j int) bool {
+	if h.farthestFirst {
+		return h.items[i].distance > h.items[j].distance
+	}
+	return h.items[i].distance < h.items[j].distance
+}
+
+func (h *candidateHeap) Swap(i, j int) { h.items[i], h.items[j] = h.items[j], h.items[i] }
+
+func (h *candidateHeap) Push(x any) { h.items = append(h.items, x.(candidate)) }
+
+func (h *candidateHeap) Pop() any {
+	last := h.items[len(h.items)-1]
+	h.items = h.items[:len(h.items)-1]
+	return last
+}
@@ -0,0 +1,102 @@
+package recall
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func randomVector(r *rand.Rand, dimensions int) []float32 {
+	v := make([]float32, dimensions)
+	for i := range v {
+		v[i] = float32(r.NormFloat64())
+	}
+	return v
+}
+
+func TestFlatIndex(t *testing.T) {
+	index := NewFlatIndex()
+	index.Add("x", []float32{1, 0})
+	index.Add("y", []float32{0, 2})
+	index.Add("xy", []float32{1, 1})
+
+	hits := index.Search([]float32{3, 0}, 2)
+	require.Len(t, hits, 2)
+	assert.Equal(t, "x", hits[0].ID)
+	assert.InDelta(t, 1, hits[0].Score, 1e-6, "vectors are normalized")
+	assert.Equal(t, "xy", hits[1].ID)
+
+	index.Remove("x")
+	index.Add("y", []float32{1, 0})
+	assert.Equal(t, 2, index.Len())
+	assert.Equal(t, "y", index.Search([]float32{1, 0}, 1)[0].ID)
+}
+
+func TestHNSWIndex_MatchesFlatIndex(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	flat := NewFlatIndex()
+	hnsw := NewHNSWIndex(HNSWConfig{M: 8, Seed: 1})
+	for i := 0; i < 2000; i++ {
+		v := randomVector(r, 24)
+		flat.Add(fmt.Sprint(i), v)
+		hnsw.Add(fmt.Sprint(i), v)
+	}
+	assert.Equal(t, 2000, hnsw.Len())
+
+	found, total := 0, 0
+	for q := 0; q < 50; q++ {
+		query := randomVector(r, 24)
+		want := make(map[string]bool)
+		for _, hit := range flat.Search(query, 10) {
+			want[hit.ID] = true
+		}
+		hits := hnsw.Search(query, 10)
+		require.Len(t, hits, 10)
+		for _, hit := range hits {
+			if want[hit.ID] {
+				found++
+			}
+		}
+		total += len(want)
+	}
+	assert.Greater(t, float64(found)/float64(total), 0.9, "recall@10 against exact search")
+}
+
+func TestHNSWIndex_RemoveAndRebuild(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	hnsw := NewHNSWIndex(HNSWConfig{Seed: 2})
+	vectors := make(map[string][]float32)
+	for i := 0; i < 200; i++ {
+		id := fmt.Sprint(i)
+		vectors[id] = randomVector(r, 8)
+		hnsw.Add(id, vectors[id])
+	}
+
+	for i := 0; i < 150; i++ {
+		hnsw.Remove(fmt.Sprint(i))
+	}
+	assert.Equal(t, 50, hnsw.Len())
+	assert.LessOrEqual(t, hnsw.removed, hnsw.Len(), "the graph is rebuilt once removed vectors dominate")
+
+	for i := 0; i < 150; i++ {
+		hits := hnsw.Search(vectors[fmt.Sprint(i)], 1)
+		require.Len(t, hits, 1)
+		assert.NotEqual(t, fmt.Sprint(i), hits[0].ID, "removed vectors are not returned")
+	}
+	assert.Equal(t, "199", hnsw.Search(vectors["199"], 1)[0].ID)
+
+	hnsw.Add("199", vectors["0"])
+	assert.Equal(t, 50, hnsw.Len(), "adding an existing ID replaces it")
+	assert.Equal(t, "199", hnsw.Search(vectors["0"], 1)[0].ID)
+}
+
+func TestHNSWIndex_Empty(t *testing.T) {
+	hnsw := NewHNSWIndex(HNSWConfig{})
+	assert.Empty(t, hnsw.Search([]float32{1}, 3))
+	hnsw.Add("only", []float32{1})
+	hnsw.Remove("only")
+	assert.Empty(t, hnsw.Search([]float32{1}, 3))
+}
@@ -0,0 +1,72 @@
+package recall
+
+import (
+	"cmp"
+	"slices"
+)
+
+// Hit is a search result: the ID of an indexed item and how well it matched,
+// higher is better.
+type Hit struct {
+	ID    string  `json:"id"`
+	Score float64 `json:"score"`
+}
+
+// VectorIndex finds the vectors nearest to a query by cosine similarity. Vectors
+// are normalized when added, so callers need not normalize them. Indexes are not
+// safe for concurrent use.
+type VectorIndex interface {
+	// Add indexes a vector under id, replacing any vector already there.
+	Add(id string, vector []float32)
+	// Remove drops the vector indexed under id, if any.
+	Remove(id string)
+	// Search returns up to k of the most similar vectors, best first.
+	Search(vector []float32, k int) []Hit
+	// Len returns the number of indexed vectors.
+	Len() int
+}
+
+// FlatIndex compares a query with every vector. It is exact and the best choice
+// for up to tens of thousands of vectors.
+type FlatIndex struct {
+	vectors map[string][]float32
+}
+
+func NewFlatIndex() *FlatIndex {
+	return &FlatIndex{vectors: make(map[string][]float32)}
+}
+
+func (f *FlatIndex) Add(id string, vector []float32) {
+	f.vectors[id] = normalize(vector)
+}
+
+func (f *FlatIndex) Remove(id string) {
+	delete(f.vectors, id)
+}
+
+func (f *FlatIndex) Search(vector []float32, k int) []Hit {
+	query := normalize(vector)
+	hits := make([]Hit, 0, len(f.vectors))
+	for id, v := range f.vectors {
+		hits = append(hits, Hit{ID: id, Score: dot(query, v)})
+	}
+	return topHits(hits, k)
+}
+
+func (f *FlatIndex) Len() int {
+	return len(f.vectors)
+}
+
+// topHits sorts hits best first, ties by ID so results are stable, and keeps k.
+func topHits(hits []Hit, k int) []Hit {
+	slices.SortFunc(hits, func(a, b Hit) int {
+		if c := cmp.Compare(b.Score, a.Score); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.ID, b.ID)
+	})
+	if k >= 0 && len(hits) > k {
+		hits = hits[:k]
+	}
+	return hits
+}
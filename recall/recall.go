@@ -0,0 +1,472 @@
+// Package recall finds memory blocks relevant to a query, so an agent can pull
+// older memory back into a pruned context window. Blocks are embedded with a
+// pluggable Embedder, searched with a vector index, and optionally blended with
+// BM25 keyword scores.
+package recall
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/gomcp/backend"
+	mcpctx "github.com/gomcp/context"
+)
+
+var (
+	// ErrDimensionMismatch indicates an embedding with a different number of
+	// dimensions than the vectors already indexed.
+	ErrDimensionMismatch = errors.New("embedding dimensions do not match the index")
+	// ErrWatchEnded indicates that Sync stopped because the store ended its watch.
+	ErrWatchEnded = errors.New("context store watch ended")
+)
+
+// Mode selects how Recall scores blocks.
+type Mode int
+
+const (
+	Hybrid      Mode = iota // Vector similarity blended with keyword scores
+	VectorOnly              // Vector similarity only
+	KeywordOnly             // BM25 keyword scores only; queries are not embedded
+)
+
+// Options configures an Index. Zero fields take their defaults.
+type Options struct {
+	Vectors       VectorIndex // Defaults to a FlatIndex; use an HNSWIndex for large indexes
+	Mode          Mode
+	KeywordWeight float64 // Share of a hybrid score from keywords, from 0 to 1; defaults to 0.3
+	Oversample    int     // Candidates taken from each index per requested result; defaults to 4
+}
+
+// Result is a recalled memory block.
+type Result struct {
+	ContextID    string              `json:"context_id"`
+	Block        *mcpctx.MemoryBlock `json:"block"`
+	Score        float64             `json:"score"`
+	VectorScore  float64             `json:"vector_score"`  // Cosine similarity to the query
+	KeywordScore float64             `json:"keyword_score"` // BM25 score for the query
+}
+
+// Index recalls memory blocks from any number of contexts. It is safe for
+// concurrent use.
+type Index struct {
+	embedder Embedder
+	options  Options
+	path     string
+
+	mu         sync.RWMutex
+	docs       map[string]*document
+	keywords   *BM25Index
+	dimensions int
+}
+
+// document is an indexed block, stored with its normalized embedding so it can be
+// rescored exactly and persisted without embedding it again.
+type document struct {
+	ContextID string              `json:"context_id"`
+	Block     *mcpctx.MemoryBlock `json:"block"`
+	Vector    []float32           `json:"vector"`
+}
+
+// indexFile is the persisted form of an Index.
+type indexFile struct {
+	Dimensions int         `json:"dimensions"`
+	Documents  []*document `json:"documents"`
+}
+
+// New returns an empty in-memory index.
+func New(embedder Embedder, options Options) *Index {
+	if options.Vectors == nil {
+		options.Vectors = NewFlatIndex()
+	}
+	if options.KeywordWeight <= 0 {
+		options.KeywordWeight = 0.3
+	}
+	if options.Oversample <= 0 {
+		options.Oversample = 4
+	}
+	return &Index{
+		embedder: embedder,
+		options:  options,
+		docs:     make(map[string]*document),
+		keywords: NewBM25Index(),
+	}
+}
+
+// Open returns an index persisted at path, loading it if the file exists. Keep
+// the file alongside the context store, such as contexts.recall in a FileStore's
+// directory or next to a SQLite database; a FileStore skips it as long as its
+// name does not end in .json. Call Save to write the index back, since nothing
+// else does. The vector index is rebuilt from the stored embeddings, so blocks
+// are not embedded again.
+func Open(path string, embedder Embedder, options Options) (*Index, error) {
+	index := New(embedder, options)
+	index.path = path
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return index, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read recall index: %w", err)
+	}
+	var file indexFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to decode recall index: %w", err)
+	}
+	index.dimensions = file.Dimensions
+	for _, doc := range file.Documents {
+		index.add(doc)
+	}
+	return index, nil
+}
+
+// Save writes the index to the file it was opened from.
+func (x *Index) Save() error {
+	if x.path == "" {
+		return errors.New("failed to save recall index: not opened from a file")
+	}
+	x.mu.RLock()
+	file := indexFile{Dimensions: x.dimensions}
+	for _, key := range slices.Sorted(maps.Keys(x.docs)) {
+		file.Documents = append(file.Documents, x.docs[key])
+	}
+	data, err := json.Marshal(file)
+	x.mu.RUnlock()
+	if err != nil {
+		return fmt.Errorf("failed to encode recall index: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(x.path), ".recall-*")
+	if err != nil {
+		return fmt.Errorf("failed to write recall index: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write recall index: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write recall index: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write recall index: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), x.path); err != nil {
+		return fmt.Errorf("failed to write recall index: %w", err)
+	}
+	return nil
+}
+
+// Len returns the number of indexed blocks.
+func (x *Index) Len() int {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	return len(x.docs)
+}
+
+// IndexContext makes the index match c's memory: new blocks are added, blocks c
+// no longer holds are dropped, and only blocks whose content changed are embedded
+// again. Blocks without an ID and expired blocks are not indexed.
+func (x *Index) IndexContext(ctx context.Context, c *mcpctx.Context) error {
+	now := time.Now()
+	x.mu.RLock()
+	current := make(map[string]*document)
+	var stale []*document
+	var texts []string
+	for _, block := range c.Memory {
+		if block.ID == "" || block.Expired(now) {
+			continue
+		}
+		key := documentKey(c.ID, block.ID)
+		doc := &document{ContextID: c.ID, Block: cloneBlock(block)}
+		if existing := x.docs[key]; existing != nil && existing.Block.Content == block.Content {
+			doc.Vector = existing.Vector
+		} else {
+			stale = append(stale, doc)
+			texts = append(texts, block.Content)
+		}
+		current[key] = doc
+	}
+	x.mu.RUnlock()
+
+	if len(texts) > 0 {
+		vectors, err := x.embedder.Embed(ctx, texts)
+		if err != nil {
+			return fmt.Errorf("failed to embed memory: %w", err)
+		}
+		if len(vectors) != len(texts) {
+			return fmt.Errorf("failed to embed memory: got %d embeddings for %d blocks", len(vectors), len(texts))
+		}
+		for i, doc := range stale {
+			doc.Vector = normalize(vectors[i])
+		}
+	}
+
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	dimensions := x.dimensions
+	for _, doc := range stale {
+		if dimensions == 0 {
+			dimensions = len(doc.Vector)
+		}
+		if len(doc.Vector) != dimensions {
+			return fmt.Errorf("%w: got %d dimensions, index has %d", ErrDimensionMismatch, len(doc.Vector), dimensions)
+		}
+	}
+	x.removeContext(c.ID, func(key string) bool { return current[key] == nil })
+	for key, doc := range current {
+		if existing := x.docs[key]; existing != nil && existing.Block.Content == doc.Block.Content {
+			// Only the block's other fields can have changed, so the indexes
+			// need not be touched.
+			x.docs[key] = doc
+			continue
+		}
+		x.add(doc)
+	}
+	return nil
+}
+
+// RemoveContext drops every block of a context from the index.
+func (x *Index) RemoveContext(id string) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.removeContext(id, func(string) bool { return true })
+}
+
+// Sync indexes every context in store and then keeps the index up to date with
+// the store's changes until ctx is done, when it returns ctx.Err(). If the store
+// ends the watch first it returns ErrWatchEnded, and Sync can be called again.
+// Sync does not save the index; an index from Open should be saved periodically
+// while Sync runs and once it returns.
+func (x *Index) Sync(ctx context.Context, store backend.ContextStore) error {
+	// Watch before listing so no change falls between the two.
+	events, err := store.Watch(ctx, backend.Filter{})
+	if err != nil {
+		return err
+	}
+	contexts, err := store.List(ctx, backend.Filter{})
+	if err != nil {
+		return err
+	}
+	for _, c := range contexts {
+		if err := x.IndexContext(ctx, c); err != nil {
+			return err
+		}
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case event, ok := <-events:
+			if !ok {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				return ErrWatchEnded
+			}
+			if event.Type == backend.EventDelete {
+				x.RemoveContext(event.ID)
+				continue
+			}
+			if err := x.IndexContext(ctx, event.Context); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// Recall returns up to k blocks most relevant to query across every indexed
+// context, best first.
+func (x *Index) Recall(ctx context.Context, query string, k int) ([]Result, error) {
+	return x.recall(ctx, query, k, "")
+}
+
+// RecallContext is Recall limited to the blocks of one context. It scores every
+// block of the context exactly rather than searching the vector index.
+func (x *Index) RecallContext(ctx context.Context, contextID, query string, k int) ([]Result, error) {
+	return x.recall(ctx, query, k, contextID)
+}
+
+func (x *Index) recall(ctx context.Context, query string, k int, contextID string) ([]Result, error) {
+	if k <= 0 {
+		return nil, nil
+	}
+	var queryVector []float32
+	if x.options.Mode != KeywordOnly {
+		vectors, err := x.embedder.Embed(ctx, []string{query})
+		if err != nil {
+			return nil, fmt.Errorf("failed to embed query: %w", err)
+		}
+		if len(vectors) != 1 {
+			return nil, fmt.Errorf("failed to embed query: got %d embeddings", len(vectors))
+		}
+		queryVector = normalize(vectors[0])
+	}
+
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	if queryVector != nil && len(x.docs) > 0 && len(queryVector) != x.dimensions {
+		return nil, fmt.Errorf("%w: query has %d dimensions, index has %d", ErrDimensionMismatch, len(queryVector), x.dimensions)
+	}
+
+	// Gather candidates from both indexes, then score each of them exactly on
+	// both measures so hybrid scores are comparable.
+	candidates := make(map[string]bool)
+	if contextID != "" {
+		for key, doc := range x.docs {
+			if doc.ContextID == contextID {
+				candidates[key] = true
+			}
+		}
+	} else {
+		limit := k * x.options.Oversample
+		if x.options.Mode != KeywordOnly {
+			for _, hit := range x.options.Vectors.Search(queryVector, limit) {
+				candidates[hit.ID] = true
+			}
+		}
+		if x.options.Mode != VectorOnly {
+			for _, hit := range x.keywords.Search(query, limit) {
+				candidates[hit.ID] = true
+			}
+		}
+	}
+
+	now := time.Now()
+	var results []Result
+	maxKeyword := 0.0
+	for key := range candidates {
+		doc := x.docs[key]
+		if doc.Block.Expired(now) {
+			continue
+		}
+		result := Result{ContextID: doc.ContextID, Block: doc.Block}
+		if queryVector != nil {
+			result.VectorScore = dot(queryVector, doc.Vector)
+		}
+		if x.options.Mode != VectorOnly {
+			result.KeywordScore = x.keywords.Score(query, key)
+			maxKeyword = max(maxKeyword, result.KeywordScore)
+		}
+		results = append(results, result)
+	}
+	for i := range results {
+		result := &results[i]
+		switch x.options.Mode {
+		case VectorOnly:
+			result.Score = result.VectorScore
+		case KeywordOnly:
+			result.Score = result.KeywordScore
+		default:
+			// BM25 scores are unbounded, so scale them to the best candidate.
+			keyword := 0.0
+			if maxKeyword > 0 {
+				keyword = result.KeywordScore / maxKeyword
+			}
+			weight := min(x.options.KeywordWeight, 1)
+			result.Score = (1-weight)*result.VectorScore + weight*keyword
+		}
+	}
+	if x.options.Mode == KeywordOnly {
+		results = slices.DeleteFunc(results, func(result Result) bool { return result.KeywordScore == 0 })
+	}
+
+	slices.SortFunc(results, func(a, b Result) int {
+		if c := cmp.Compare(b.Score, a.Score); c != 0 {
+			return c
+		}
+		if c := cmp.Compare(a.ContextID, b.ContextID); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Block.ID, b.Block.ID)
+	})
+	if len(results) > k {
+		results = results[:k]
+	}
+	for i := range results {
+		results[i].Block = cloneBlock(results[i].Block)
+	}
+	return results, nil
+}
+
+// Restore returns the update that brings recalled blocks back into c. Blocks c
+// already holds are skipped; the others are inserted in chronological order.
+func Restore(c *mcpctx.Context, results []Result) mcpctx.ContextUpdate {
+	update := mcpctx.ContextUpdate{ID: c.ID}
+	held := make(map[string]bool, len(c.Memory))
+	for _, block := range c.Memory {
+		held[block.ID] = true
+	}
+	var recalled []*mcpctx.MemoryBlock
+	for _, result := range results {
+		if !held[result.Block.ID] {
+			held[result.Block.ID] = true
+			recalled = append(recalled, cloneBlock(result.Block))
+		}
+	}
+	slices.SortStableFunc(recalled, func(a, b *mcpctx.MemoryBlock) int {
+		return a.Time.Compare(b.Time)
+	})
+
+	// Each block goes before the first held block that is newer. Inserts apply in
+	// order, so an index counts the blocks inserted before it.
+	i := 0
+	for n, block := range recalled {
+		for i < len(c.Memory) && !c.Memory[i].Time.After(block.Time) {
+			i++
+		}
+		update.Insert = append(update.Insert, mcpctx.MemoryInsert{Index: i + n, Blocks: []*mcpctx.MemoryBlock{block}})
+	}
+	return update
+}
+
+func (x *Index) add(doc *document) {
+	key := documentKey(doc.ContextID, doc.Block.ID)
+	x.docs[key] = doc
+	x.options.Vectors.Add(key, doc.Vector)
+	x.keywords.Add(key, doc.Block.Content)
+	if x.dimensions == 0 {
+		x.dimensions = len(doc.Vector)
+	}
+}
+
+// removeContext drops the blocks of a context whose keys match drop.
+func (x *Index) removeContext(id string, drop func(key string) bool) {
+	for key, doc := range x.docs {
+		if doc.ContextID == id && drop(key) {
+			delete(x.docs, key)
+			x.options.Vectors.Remove(key)
+			x.keywords.Remove(key)
+		}
+	}
+	if len(x.docs) == 0 {
+		x.dimensions = 0
+	}
+}
+
+// documentKey identifies a block within its context; forks share block IDs, so
+// the ID alone is not unique.
+func documentKey(contextID, blockID string) string {
+	return contextID + "\x00" + blockID
+}
+
+func cloneBlock(block *mcpctx.MemoryBlock) *mcpctx.MemoryBlock {
+	clone := *block
+	clone.Tags = slices.Clone(block.Tags)
+	clone.Metadata = maps.Clone(block.Metadata)
+	if block.ExpiresAt != nil {
+		expires := *block.ExpiresAt
+		clone.ExpiresAt = &expires
+	}
+	return &clone
+}
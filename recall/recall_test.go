@@ -0,0 +1,253 @@
+package recall
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gomcp/backend"
+	mcpctx "github.com/gomcp/context"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func recallTestContext() *mcpctx.Context {
+	c := mcpctx.NewContext(nil)
+	start := time.Unix(1700000000, 0).UTC()
+	for i, content := range []string{
+		"The database password rotates every ninety days.",
+		"We deploy the billing service with a blue green rollout.",
+		"Lunch orders go in before eleven.",
+		"Staging uses a smaller database instance than production.",
+	} {
+		c.Memory = append(c.Memory, &mcpctx.MemoryBlock{
+			ID:      []string{"password", "deploy", "lunch", "staging"}[i],
+			Role:    "user",
+			Content: content,
+			Time:    start.Add(time.Duration(i) * time.Minute),
+		})
+	}
+	return c
+}
+
+func resultIDs(results []Result) []string {
+	ids := make([]string, len(results))
+	for i, result := range results {
+		ids[i] = result.Block.ID
+	}
+	return ids
+}
+
+// countingEmbedder records how many texts it embedded.
+type countingEmbedder struct {
+	HashingEmbedder
+	texts int
+}
+
+func (e *countingEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	e.texts += len(texts)
+	return e.HashingEmbedder.Embed(ctx, texts)
+}
+
+func TestHashingEmbedder(t *testing.T) {
+	vectors, err := HashingEmbedder{Dimensions: 64}.Embed(context.Background(), []string{"Blue green deploy", "deploy blue-green", ""})
+	require.NoError(t, err)
+	require.Len(t, vectors, 3)
+	assert.Len(t, vectors[0], 64)
+	assert.InDelta(t, 1, dot(vectors[0], vectors[1]), 1e-6, "same words in any order and case")
+	assert.Zero(t, dot(vectors[2], vectors[2]))
+}
+
+func TestIndex_Recall(t *testing.T) {
+	for name, vectors := range map[string]VectorIndex{
+		"flat": NewFlatIndex(),
+		"hnsw": NewHNSWIndex(HNSWConfig{}),
+	} {
+		t.Run(name, func(t *testing.T) {
+			index := New(HashingEmbedder{}, Options{Vectors: vectors})
+			c := recallTestContext()
+			require.NoError(t, index.IndexContext(context.Background(), c))
+			assert.Equal(t, 4, index.Len())
+
+			results, err := index.Recall(context.Background(), "which database does staging use", 2)
+			require.NoError(t, err)
+			assert.Equal(t, []string{"staging", "password"}, resultIDs(results))
+			assert.Equal(t, c.ID, results[0].ContextID)
+			assert.Greater(t, results[0].KeywordScore, results[1].KeywordScore)
+			assert.Greater(t, results[0].VectorScore, 0.0)
+
+			results[0].Block.Content = "changed"
+			again, err := index.Recall(context.Background(), "which database does staging use", 1)
+			require.NoError(t, err)
+			assert.NotEqual(t, "changed", again[0].Block.Content, "results are copies")
+		})
+	}
+}
+
+func TestIndex_Modes(t *testing.T) {
+	embedder := &countingEmbedder{}
+	index := New(embedder, Options{Mode: KeywordOnly})
+	require.NoError(t, index.IndexContext(context.Background(), recallTestContext()))
+	indexed := embedder.texts
+
+	results, err := index.Recall(context.Background(), "lunch", 5)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"lunch"}, resultIDs(results), "keyword search only returns matches")
+	assert.Equal(t, indexed, embedder.texts, "keyword queries are not embedded")
+
+	index = New(HashingEmbedder{}, Options{Mode: VectorOnly})
+	require.NoError(t, index.IndexContext(context.Background(), recallTestContext()))
+	results, err = index.Recall(context.Background(), "blue green rollout", 1)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"deploy"}, resultIDs(results))
+	assert.Zero(t, results[0].KeywordScore)
+	assert.Equal(t, results[0].VectorScore, results[0].Score)
+}
+
+func TestIndex_IndexContextTracksChanges(t *testing.T) {
+	embedder := &countingEmbedder{}
+	index := New(embedder, Options{})
+	c := recallTestContext()
+	require.NoError(t, index.IndexContext(context.Background(), c))
+	assert.Equal(t, 4, embedder.texts)
+
+	past := time.Now().Add(-time.Second)
+	c.Memory[0].Tags = []string{"secret"}
+	c.Memory[1].UpdateContent("We deploy with canaries now.")
+	c.Memory[2].ExpiresAt = &past
+	c.Memory = c.Memory[:3]
+	require.NoError(t, index.IndexContext(context.Background(), c))
+	assert.Equal(t, 5, embedder.texts, "only changed content is embedded again")
+	assert.Equal(t, 2, index.Len(), "removed and expired blocks are dropped")
+
+	results, err := index.Recall(context.Background(), "canaries", 5)
+	require.NoError(t, err)
+	require.NotEmpty(t, results)
+	assert.Equal(t, "deploy", results[0].Block.ID)
+	results, err = index.Recall(context.Background(), "password", 1)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"secret"}, results[0].Block.Tags)
+
+	other := recallTestContext()
+	require.NoError(t, index.IndexContext(context.Background(), other))
+	results, err = index.RecallContext(context.Background(), other.ID, "password", 10)
+	require.NoError(t, err)
+	assert.Len(t, results, 4)
+	for _, result := range results {
+		assert.Equal(t, other.ID, result.ContextID)
+	}
+
+	index.RemoveContext(other.ID)
+	assert.Equal(t, 2, index.Len())
+}
+
+func TestIndex_DimensionMismatch(t *testing.T) {
+	index := New(HashingEmbedder{Dimensions: 16}, Options{})
+	require.NoError(t, index.IndexContext(context.Background(), recallTestContext()))
+
+	index.embedder = HashingEmbedder{Dimensions: 32}
+	err := index.IndexContext(context.Background(), recallTestContext())
+	assert.True(t, errors.Is(err, ErrDimensionMismatch))
+	_, err = index.Recall(context.Background(), "database", 1)
+	assert.True(t, errors.Is(err, ErrDimensionMismatch))
+}
+
+func TestIndex_SaveAndOpen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "contexts.recall")
+	index, err := Open(path, HashingEmbedder{}, Options{})
+	require.NoError(t, err)
+	require.NoError(t, index.IndexContext(context.Background(), recallTestContext()))
+	want, err := index.Recall(context.Background(), "billing rollout", 3)
+	require.NoError(t, err)
+	require.NoError(t, index.Save())
+
+	embedder := &countingEmbedder{}
+	reopened, err := Open(path, embedder, Options{Vectors: NewHNSWIndex(HNSWConfig{})})
+	require.NoError(t, err)
+	assert.Equal(t, 4, reopened.Len())
+	got, err := reopened.Recall(context.Background(), "billing rollout", 3)
+	require.NoError(t, err)
+	assert.Equal(t, resultIDs(want), resultIDs(got))
+	assert.Equal(t, 1, embedder.texts, "only the query is embedded after reopening")
+
+	require.Error(t, New(HashingEmbedder{}, Options{}).Save())
+}
+
+func TestIndex_Sync(t *testing.T) {
+	store := backend.NewMemoryStore()
+	defer store.Close()
+	c := recallTestContext()
+	require.NoError(t, store.Put(context.Background(), c))
+
+	index := New(HashingEmbedder{}, Options{})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- index.Sync(ctx, store) }()
+
+	require.Eventually(t, func() bool { return index.Len() == 4 }, time.Second, 10*time.Millisecond)
+	_, err := store.Apply(context.Background(), mcpctx.ContextUpdate{
+		ID:     c.ID,
+		Append: []*mcpctx.MemoryBlock{{ID: "oncall", Content: "Dana is on call this week."}},
+	})
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return index.Len() == 5 }, time.Second, 10*time.Millisecond)
+
+	require.NoError(t, store.Delete(context.Background(), c.ID))
+	require.Eventually(t, func() bool { return index.Len() == 0 }, time.Second, 10*time.Millisecond)
+
+	cancel()
+	assert.True(t, errors.Is(<-done, context.Canceled))
+}
+
+func TestIndex_FileStoreLayout(t *testing.T) {
+	dir := t.TempDir()
+	store, err := backend.OpenFileStore(dir)
+	require.NoError(t, err)
+	defer store.Close()
+	c := recallTestContext()
+	require.NoError(t, store.Put(context.Background(), c))
+
+	path := filepath.Join(dir, "contexts.recall")
+	index, err := Open(path, HashingEmbedder{}, Options{})
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- index.Sync(ctx, store) }()
+	require.Eventually(t, func() bool { return index.Len() == 4 }, time.Second, 10*time.Millisecond)
+	cancel()
+	<-done
+	require.NoError(t, index.Save())
+
+	// The index file is not mistaken for a context.
+	contexts, err := store.List(context.Background(), backend.Filter{})
+	require.NoError(t, err)
+	require.Len(t, contexts, 1)
+	assert.Equal(t, c.ID, contexts[0].ID)
+
+	reopened, err := Open(path, HashingEmbedder{}, Options{})
+	require.NoError(t, err)
+	assert.Equal(t, 4, reopened.Len())
+}
+
+func TestRestore(t *testing.T) {
+	full := recallTestContext()
+	pruned := recallTestContext()
+	pruned.ID = full.ID
+	pruned.Memory = []*mcpctx.MemoryBlock{pruned.Memory[0], pruned.Memory[3]}
+
+	index := New(HashingEmbedder{}, Options{})
+	require.NoError(t, index.IndexContext(context.Background(), full))
+	results, err := index.Recall(context.Background(), "deploy lunch database", 4)
+	require.NoError(t, err)
+
+	update := Restore(pruned, results)
+	require.NoError(t, pruned.ApplyUpdate(update))
+	var ids []string
+	for _, block := range pruned.Memory {
+		ids = append(ids, block.ID)
+	}
+	assert.Equal(t, []string{"password", "deploy", "lunch", "staging"}, ids, "recalled blocks return in chronological order")
+}
@@ -0,0 +1,53 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/gomcp/mcp"
+	"github.com/gomcp/validate"
+)
+
+// ErrNoClientConnection is returned when a server-initiated request has no
+// client connection to travel over.
+var ErrNoClientConnection = errors.New("no client connection available for server requests")
+
+// Requester sends requests from the server to a connected client and waits for the response.
+// It is implemented by the transport that owns the client session.
+type Requester interface {
+	Request(ctx context.Context, method string, params any) (json.RawMessage, error)
+}
+
+// Elicit asks the connected client to collect information from the user while a tool
+// call is in progress. The schema must only contain flat primitive properties.
+// Accepted responses are checked against the schema before being returned, so tool
+// handlers can trust the content of an accepted result.
+//
+// https://modelcontextprotocol.io/specification/2025-06-18/client/elicitation
+func Elicit(ctx context.Context, r Requester, message string, schema mcp.ElicitationSchema) (*mcp.ElicitResult, error) {
+	if r == nil {
+		return nil, ErrNoClientConnection
+	}
+	if err := schema.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid elicitation schema: %w", err)
+	}
+
+	raw, err := r.Request(ctx, mcp.MethodElicitationCreate, mcp.ElicitRequestParams{
+		Message:         message,
+		RequestedSchema: schema,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("elicitation request failed: %w", err)
+	}
+
+	var result mcp.ElicitResult
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal elicitation result: %w", err)
+	}
+	if err := validate.ValidateElicitationResult(schema, result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
@@ -0,0 +1,184 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gomcp/auth"
+	"github.com/gomcp/codec"
+	"github.com/gomcp/mcp"
+)
+
+// ProtectedResourcePath is where the protected resource metadata is served.
+//
+// https://datatracker.ietf.org/doc/html/rfc9728#section-3
+const ProtectedResourcePath = "/.well-known/oauth-protected-resource"
+
+// ResourceServerConfig configures the MCP endpoint as an OAuth 2.1 resource server.
+//
+// https://modelcontextprotocol.io/specification/2025-06-18/basic/authorization
+type ResourceServerConfig struct {
+	// Resource is the canonical URI of this MCP server. Access tokens must carry
+	// it as their audience.
+	Resource string
+	// Issuer is the authorization server whose tokens are accepted.
+	Issuer string
+	// JWKSURL is where the issuer publishes its signing keys.
+	JWKSURL string
+	// AuthorizationServers are advertised to clients; defaults to Issuer.
+	AuthorizationServers []string
+	// ScopesSupported are advertised to clients.
+	ScopesSupported []string
+	// MethodScopes are the scopes required for each MCP method.
+	MethodScopes map[string][]string
+	// ToolScopes are the scopes required to call each tool, on top of those for tools/call.
+	ToolScopes map[string][]string
+	// Leeway is the clock skew tolerated when checking token times.
+	Leeway time.Duration
+	// Verifier overrides the JWKS based verifier, e.g. for tests.
+	Verifier *auth.Token
+}
+
+// ProtectedResourceMetadata describes this server to OAuth clients (RFC 9728).
+type ProtectedResourceMetadata struct {
+	Resource               string   `json:"resource"`
+	AuthorizationServers   []string `json:"authorization_servers,omitempty"`
+	ScopesSupported        []string `json:"scopes_supported,omitempty"`
+	BearerMethodsSupported []string `json:"bearer_methods_supported,omitempty"`
+}
+
+type resourceServer struct {
+	cfg         ResourceServerConfig
+	verifier    *auth.Token
+	metadataURL string
+}
+
+// EnableOAuth requires a valid bearer token from the configured issuer on the MCP
+// endpoint and serves the protected resource metadata clients use to find it.
+func (s *Server) EnableOAuth(cfg ResourceServerConfig) error {
+	if cfg.Resource == "" {
+		return errors.New("resource server requires a resource URI")
+	}
+	resource, err := url.Parse(cfg.Resource)
+	if err != nil || resource.Host == "" {
+		return fmt.Errorf("invalid resource URI '%s'", cfg.Resource)
+	}
+	if len(cfg.AuthorizationServers) == 0 && cfg.Issuer != "" {
+		cfg.AuthorizationServers = []string{cfg.Issuer}
+	}
+
+	verifier := cfg.Verifier
+	if verifier == nil {
+		if cfg.Issuer == "" || cfg.JWKSURL == "" {
+			return errors.New("resource server requires an issuer and a JWKS URL")
+		}
+		verifier = &auth.Token{
+			KeyFunc:    auth.NewJWKS(cfg.JWKSURL).KeyFunc,
+			Algorithms: []string{auth.RS256, auth.ES256, auth.EdDSA},
+			Issuer:     cfg.Issuer,
+			Audience:   cfg.Resource,
+			Leeway:     cfg.Leeway,
+		}
+	}
+
+	// Metadata for a resource with a path is served below the well-known prefix.
+	metadataURL := (&url.URL{Scheme: resource.Scheme, Host: resource.Host, Path: ProtectedResourcePath + strings.TrimSuffix(resource.Path, "/")}).String()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.oauth = &resourceServer{cfg: cfg, verifier: verifier, metadataURL: metadataURL}
+	return nil
+}
+
+func (s *Server) resourceServer() *resourceServer {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.oauth
+}
+
+// RequireBearer is middleware that rejects requests without a valid access token
+// once OAuth is enabled, and stores the token's claims in the request context.
+func (s *Server) RequireBearer(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rs := s.resourceServer()
+		if rs == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		claims, err := rs.verifier.Validate(r)
+		if err != nil {
+			if errors.Is(err, auth.ErrNoToken) {
+				rs.challenge(w, http.StatusUnauthorized, "", "", "")
+			} else {
+				rs.challenge(w, http.StatusUnauthorized, "invalid_token", err.Error(), "")
+			}
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(auth.NewContext(r.Context(), claims)))
+	})
+}
+
+// HandleProtectedResourceMetadata serves the protected resource metadata.
+func (s *Server) HandleProtectedResourceMetadata(w http.ResponseWriter, r *http.Request) {
+	rs := s.resourceServer()
+	if rs == nil {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ProtectedResourceMetadata{
+		Resource:               rs.cfg.Resource,
+		AuthorizationServers:   rs.cfg.AuthorizationServers,
+		ScopesSupported:        rs.cfg.ScopesSupported,
+		BearerMethodsSupported: []string{"header"},
+	})
+}
+
+// authorizeRPC checks that the request's token holds the scopes required for an
+// MCP method and, for tools/call, the tool. It writes a 403 and returns false otherwise.
+func (s *Server) authorizeRPC(w http.ResponseWriter, r *http.Request, req *codec.JSONRPCRequest) bool {
+	rs := s.resourceServer()
+	if rs == nil {
+		return true
+	}
+	required := slices.Clone(rs.cfg.MethodScopes[req.Method])
+	if req.Method == string(mcp.MethodToolsCall) && len(rs.cfg.ToolScopes) > 0 {
+		var params mcp.CallToolParams
+		if err := json.Unmarshal(req.Params, &params); err == nil {
+			required = append(required, rs.cfg.ToolScopes[params.Name]...)
+		}
+	}
+	if len(required) == 0 {
+		return true
+	}
+
+	claims, _ := auth.FromContext(r.Context())
+	if claims != nil && claims.HasScopes(required...) {
+		return true
+	}
+	rs.challenge(w, http.StatusForbidden, "insufficient_scope", fmt.Sprintf("%s requires scope '%s'", req.Method, strings.Join(required, " ")), strings.Join(required, " "))
+	return false
+}
+
+// challenge writes an error response with a Bearer WWW-Authenticate header
+// pointing clients to the protected resource metadata (RFC 6750, RFC 9728).
+func (rs *resourceServer) challenge(w http.ResponseWriter, status int, code, description, scope string) {
+	params := []string{fmt.Sprintf("resource_metadata=%q", rs.metadataURL)}
+	if code != "" {
+		params = append(params, fmt.Sprintf("error=%q", code))
+	}
+	if description != "" {
+		params = append(params, fmt.Sprintf("error_description=%q", description))
+	}
+	if scope != "" {
+		params = append(params, fmt.Sprintf("scope=%q", scope))
+	}
+	w.Header().Set("WWW-Authenticate", "Bearer "+strings.Join(params, ", "))
+	http.Error(w, http.StatusText(status), status)
+}
@@ -0,0 +1,146 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gomcp/auth"
+	"github.com/gomcp/mcp"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testIssuer   = "https://auth.example"
+	testResource = "https://mcp.example/api/mcp"
+)
+
+// newOAuthServer starts a JWKS endpoint for a fresh Ed25519 key and returns a server
+// accepting tokens signed with it, plus an issuer for such tokens.
+func newOAuthServer(t *testing.T) (*Server, http.Handler, *auth.Token) {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	jwk, err := auth.NewJWK("as-key-1", pub)
+	require.NoError(t, err)
+	jwks := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(auth.JWKSet{Keys: []auth.JWK{jwk}})
+	}))
+	t.Cleanup(jwks.Close)
+
+	s := newTestServer()
+	s.AddTool(weatherTool, func(ctx context.Context, req *ToolRequest) (*mcp.CallToolResult, error) {
+		claims, _ := auth.FromContext(ctx)
+		return mcp.NewStructuredToolResult(weatherReport{Temperature: 20, Conditions: claims.Subject})
+	})
+	require.NoError(t, s.EnableOAuth(ResourceServerConfig{
+		Resource:        testResource,
+		Issuer:          testIssuer,
+		JWKSURL:         jwks.URL,
+		ScopesSupported: []string{"mcp:read", "mcp:tools", "weather"},
+		MethodScopes: map[string][]string{
+			string(mcp.MethodToolsList): {"mcp:read"},
+			string(mcp.MethodToolsCall): {"mcp:tools"},
+		},
+		ToolScopes: map[string][]string{"get_weather": {"weather"}},
+	}))
+
+	issuer := &auth.Token{PrivateKey: priv, KeyID: "as-key-1", Issuer: testIssuer, Audience: testResource}
+	return s, SetupRoutes(s), issuer
+}
+
+func postRPC(handler http.Handler, token, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/api/mcp", bytes.NewBufferString(body))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestOAuth_Unauthorized(t *testing.T) {
+	_, handler, issuer := newOAuthServer(t)
+	body := `{"jsonrpc": "2.0", "id": 1, "method": "tools/list"}`
+	challenge := `Bearer resource_metadata="https://mcp.example/.well-known/oauth-protected-resource/api/mcp"`
+
+	rr := postRPC(handler, "", body)
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	assert.Equal(t, challenge, rr.Header().Get("WWW-Authenticate"))
+
+	// Tokens for another resource are rejected.
+	other := *issuer
+	other.Audience = "https://other.example"
+	token, err := other.Create("alice", "mcp:read")
+	require.NoError(t, err)
+	rr = postRPC(handler, token, body)
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	assert.Contains(t, rr.Header().Get("WWW-Authenticate"), `error="invalid_token"`)
+
+	// As are tokens signed with a shared secret, even with the right claims.
+	hmac := &auth.Token{Secret: []byte("secret"), Issuer: testIssuer, Audience: testResource}
+	token, err = hmac.Create("alice", "mcp:read")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, postRPC(handler, token, body).Code)
+}
+
+func TestOAuth_Scopes(t *testing.T) {
+	_, handler, issuer := newOAuthServer(t)
+	list := `{"jsonrpc": "2.0", "id": 1, "method": "tools/list"}`
+	call := `{"jsonrpc": "2.0", "id": 2, "method": "tools/call", "params": {"name": "get_weather", "arguments": {"location": "Lisbon"}}}`
+
+	readOnly, err := issuer.Create("alice", "mcp:read")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, postRPC(handler, readOnly, list).Code)
+
+	rr := postRPC(handler, readOnly, call)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	assert.Contains(t, rr.Header().Get("WWW-Authenticate"), `error="insufficient_scope"`)
+	assert.Contains(t, rr.Header().Get("WWW-Authenticate"), `scope="mcp:tools weather"`)
+
+	toolsOnly, err := issuer.Create("alice", "mcp:tools")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, postRPC(handler, toolsOnly, call).Code)
+
+	full, err := issuer.Create("alice", "mcp:tools", "weather")
+	require.NoError(t, err)
+	rr = postRPC(handler, full, call)
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var resp struct {
+		Result mcp.CallToolResult `json:"result"`
+	}
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+	assert.JSONEq(t, `{"temperature": 20, "conditions": "alice"}`, string(resp.Result.StructuredContent))
+}
+
+func TestOAuth_ProtectedResourceMetadata(t *testing.T) {
+	_, handler, _ := newOAuthServer(t)
+
+	for _, path := range []string{ProtectedResourcePath, ProtectedResourcePath + "/api/mcp"} {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var meta ProtectedResourceMetadata
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&meta))
+		assert.Equal(t, testResource, meta.Resource)
+		assert.Equal(t, []string{testIssuer}, meta.AuthorizationServers)
+		assert.Equal(t, []string{"header"}, meta.BearerMethodsSupported)
+	}
+
+	// Without OAuth the endpoint does not exist and requests need no token.
+	s := newTestServer()
+	plain := SetupRoutes(s)
+	rr := httptest.NewRecorder()
+	plain.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, ProtectedResourcePath, nil))
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.Equal(t, http.StatusOK, postRPC(plain, "", `{"jsonrpc": "2.0", "id": 1, "method": "ping"}`).Code)
+}
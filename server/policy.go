@@ -0,0 +1,77 @@
+package server
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gomcp/mcp"
+	"github.com/gomcp/types"
+	"github.com/gomcp/validate"
+)
+
+// SetSecurityPolicy enables policy enforcement for tools/call requests.
+// metadata maps tool names to the SecurityMetadata describing their origin.
+func (s *Server) SetSecurityPolicy(policy types.SecurityPolicy, metadata map[string]types.SecurityMetadata) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.policy = &policy
+	s.toolMetadata = metadata
+}
+
+// SetToolKeys sets the keys used to verify signed tool descriptions. Signed tools
+// that fail verification are denied; with RequireSignedTools in the security policy,
+// unsigned tools are denied as well.
+func (s *Server) SetToolKeys(keys validate.KeyResolver) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.toolKeys = keys
+}
+
+// enforcePolicy evaluates a tool call against the configured policy. It returns
+// a result to send instead of running the tool, or nil if the call may proceed.
+// Calls that require confirmation are confirmed with the user via elicitation.
+//
+// MaxToolCallsPerTurn counts the calls a client session makes for one assistant
+// turn, as named by the turn in the call's _meta (see mcp.RequestMeta); a call for
+// another turn starts a new count. Calls that name no turn, or arrive outside a
+// session, cannot be counted and are denied while the limit is set. Only calls
+// that are allowed, after any confirmation, count towards the limit.
+func (s *Server) enforcePolicy(ctx context.Context, call types.ToolCall, turnID string, requester Requester) *mcp.CallToolResult {
+	s.mu.RLock()
+	policy, metadata, keys := s.policy, s.toolMetadata, s.toolKeys
+	s.mu.RUnlock()
+	if policy == nil {
+		return nil
+	}
+
+	engine := validate.NewPolicyEngine(*policy, s.ListTools(), metadata).WithKeys(keys)
+	turn := engine.NewTurn()
+	if policy.MaxToolCallsPerTurn > 0 {
+		sess, ok := requester.(*session)
+		if !ok || turnID == "" {
+			return mcp.NewToolErrorResult(fmt.Sprintf("tool call denied by policy: the limit of %d tool calls per turn needs calls to name their turn over a client session", policy.MaxToolCallsPerTurn))
+		}
+		turn = sess.turn(turnID, policy, engine)
+	}
+	decision := turn.Limit(engine.EvaluateCall(call))
+	switch {
+	case decision.Allowed():
+	case decision.NeedsConfirmation():
+		req := validate.ConfirmationRequest(decision)
+		result, err := Elicit(ctx, requester, req.Message, req.RequestedSchema)
+		if err != nil {
+			return mcp.NewToolErrorResult(fmt.Sprintf("tool call requires confirmation which could not be obtained: %v", err))
+		}
+		if !validate.Confirmed(result) {
+			return mcp.NewToolErrorResult("tool call was not confirmed by the user")
+		}
+	default:
+		return mcp.NewToolErrorResult(fmt.Sprintf("tool call denied by policy: %s", decision.Reason))
+	}
+
+	if !turn.Use() {
+		// Concurrent calls used up the turn while this one was being confirmed.
+		return mcp.NewToolErrorResult(fmt.Sprintf("tool call denied by policy: exceeds the limit of %d tool calls per turn", policy.MaxToolCallsPerTurn))
+	}
+	return nil
+}
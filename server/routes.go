@@ -7,7 +7,7 @@ import (
 	"github.com/go-chi/chi/middleware"
 )
 
-func SetupRoutes() *chi.Mux {
+func SetupRoutes(s *Server) *chi.Mux {
 	r := chi.NewRouter()
 
 	// standard middleware
@@ -20,8 +20,12 @@ func SetupRoutes() *chi.Mux {
 		w.Write([]byte("hi"))
 	})
 
-	r.Route("/api", func(r chi.Router) {
+	r.Get(ProtectedResourcePath, s.HandleProtectedResourceMetadata)
+	r.Get(ProtectedResourcePath+"/*", s.HandleProtectedResourceMetadata)
 
+	r.Route("/api", func(r chi.Router) {
+		r.With(s.RequireBearer).Get("/mcp", s.HandleStream)
+		r.With(s.RequireBearer).Post("/mcp", s.HandleRPC)
 	})
 
 	return r
@@ -0,0 +1,79 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gomcp/codec"
+	"github.com/gomcp/mcp"
+)
+
+// registerHandlers wires the MCP methods served by this server into its protocol.
+func (s *Server) registerHandlers() {
+	s.protocol.SetRequestHandler(mcp.MethodPing, nil, func(request any, extra mcp.RequestHandlerExtra) (any, error) {
+		return struct{}{}, nil
+	})
+	s.protocol.SetRequestHandler(mcp.MethodToolsList, nil, s.handleToolsList)
+	s.protocol.SetRequestHandler(mcp.MethodToolsCall, nil, s.handleToolsCall)
+}
+
+// HandleRPC serves JSON-RPC requests posted to the MCP endpoint. Posts naming a
+// session opened with HandleStream may also carry the client's responses to
+// requests the server sent on that stream.
+//
+// https://modelcontextprotocol.io/specification/2025-03-26/basic/transports#sending-messages-to-the-server
+func (s *Server) HandleRPC(w http.ResponseWriter, r *http.Request) {
+	sess, err := s.lookupSession(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if sess != nil {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			codec.WriteJSONRPCError(w, codec.ParseError, err.Error(), nil)
+			return
+		}
+		var msg struct {
+			Method string `json:"method"`
+			sessionResponse
+		}
+		if json.Unmarshal(body, &msg) == nil && msg.Method == "" && msg.ID != nil {
+			if err := sess.deliver(msg.sessionResponse); err != nil {
+				codec.WriteJSONRPCError(w, codec.InvalidRequest, err.Error(), nil)
+				return
+			}
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		r = r.WithContext(withRequester(r.Context(), sess))
+	}
+
+	req, err := codec.ParseJSONRPCRequest(r)
+	if err != nil {
+		codec.WriteJSONRPCError(w, codec.ParseError, err.Error(), nil)
+		return
+	}
+	if !s.authorizeRPC(w, r, req) {
+		return
+	}
+
+	result, err := s.protocol.HandleRequest(req.Method, req.Params, mcp.RequestHandlerExtra{Context: r.Context()})
+	if err != nil {
+		var rpcErr *codec.RPCError
+		switch {
+		case errors.As(err, &rpcErr):
+			codec.WriteJSONRPCError(w, rpcErr.Code, rpcErr.Message, req.ID)
+		case errors.Is(err, mcp.ErrMethodNotFound):
+			codec.WriteJSONRPCError(w, codec.MethodNotFound, "", req.ID)
+		default:
+			codec.WriteJSONRPCError(w, codec.InternalError, err.Error(), req.ID)
+		}
+		return
+	}
+	codec.WriteJSONRPCResponse(w, result, req.ID)
+}
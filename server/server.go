@@ -7,33 +7,53 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 
 	"github.com/gomcp/logger"
+	"github.com/gomcp/mcp"
+	"github.com/gomcp/types"
+	"github.com/gomcp/validate"
 
 	"github.com/google/uuid"
 )
 
 type Server struct {
+	mu        sync.RWMutex
 	StartTime time.Time
 	Svr       *http.Server
 	log       *logger.Logger
+	protocol  *mcp.Protocol
+	tools     map[string]registeredTool
+
+	policy       *types.SecurityPolicy
+	toolMetadata map[string]types.SecurityMetadata
+	toolKeys     validate.KeyResolver
+
+	oauth *resourceServer
+
+	sessionsMu sync.Mutex
+	sessions   map[string]*session
 }
 
 func NewServer() *Server {
 	svrCfgs := ServerConfigs()
-	return &Server{
+	s := &Server{
 		StartTime: time.Now().UTC(),
 		log:       logger.NewLogger("Server", uuid.NewString()),
-		Svr: &http.Server{
-			Handler:      SetupRoutes(),
-			Addr:         "localhost:9090",
-			ReadTimeout:  svrCfgs.TimeoutRead,
-			WriteTimeout: svrCfgs.TimeoutWrite,
-			IdleTimeout:  svrCfgs.TimeoutIdle,
-		},
+		protocol:  mcp.NewProtocol(),
+		tools:     make(map[string]registeredTool),
+	}
+	s.registerHandlers()
+	s.Svr = &http.Server{
+		Handler:      SetupRoutes(s),
+		Addr:         "localhost:9090",
+		ReadTimeout:  svrCfgs.TimeoutRead,
+		WriteTimeout: svrCfgs.TimeoutWrite,
+		IdleTimeout:  svrCfgs.TimeoutIdle,
 	}
+	return s
 }
 
 func secondsToTimeStr(seconds float64) string {
@@ -67,7 +87,8 @@ func (s *Server) Run() {
 		<-sig
 
 		// shutdown signal with grace period of 10 seconds
-		shutdownCtx, _ := context.WithTimeout(serverCtx, 10*time.Second)
+		shutdownCtx, cancel := context.WithTimeout(serverCtx, 10*time.Second)
+		defer cancel()
 
 		go func() {
 			<-shutdownCtx.Done()
@@ -106,7 +127,8 @@ func (s *Server) Start(shutDown chan bool) {
 		<-shutDown
 
 		// shutdown signal with grace period of 10 seconds
-		shutdownCtx, _ := context.WithTimeout(serverCtx, 10*time.Second)
+		shutdownCtx, cancel := context.WithTimeout(serverCtx, 10*time.Second)
+		defer cancel()
 
 		go func() {
 			<-shutdownCtx.Done()
@@ -0,0 +1,214 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gomcp/auth"
+	"github.com/gomcp/codec"
+	"github.com/gomcp/types"
+	"github.com/gomcp/validate"
+
+	"github.com/google/uuid"
+)
+
+// SessionParam is the query parameter naming the session a client posts to. It is
+// part of the endpoint announced when the client opens its event stream.
+const SessionParam = "sessionId"
+
+// session is a client's open event stream. It implements Requester by sending
+// requests as message events and matching the responses the client posts back.
+type session struct {
+	id      string
+	subject string // Token subject that opened the session, if authenticated
+	events  chan []byte
+	done    chan struct{}
+	nextID  atomic.Int64
+
+	mu      sync.Mutex
+	pending map[int64]chan sessionResponse
+	turnID  string                // Assistant turn the client is making tool calls for
+	calls   *validate.TurnBudget  // Tool calls allowed in that turn, see enforcePolicy
+	policy  *types.SecurityPolicy // Policy the budget was created from
+}
+
+// sessionResponse is a client's response to a server-initiated request.
+type sessionResponse struct {
+	ID     *int64          `json:"id"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  *codec.RPCError `json:"error,omitempty"`
+}
+
+type requesterKey struct{}
+
+// withRequester attaches the session a request arrived on to its context, so
+// method handlers can make requests back to the client.
+func withRequester(ctx context.Context, r Requester) context.Context {
+	return context.WithValue(ctx, requesterKey{}, r)
+}
+
+// requesterFrom returns the Requester attached by withRequester, or nil.
+func requesterFrom(ctx context.Context) Requester {
+	r, _ := ctx.Value(requesterKey{}).(Requester)
+	return r
+}
+
+func (s *session) Request(ctx context.Context, method string, params any) (json.RawMessage, error) {
+	raw, err := json.Marshal(params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request params: %w", err)
+	}
+	id := s.nextID.Add(1)
+	msg, err := json.Marshal(codec.JSONRPCRequest{JSONRPC: codec.JsonRPCVersion, ID: id, Method: method, Params: raw})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	ch := make(chan sessionResponse, 1)
+	s.mu.Lock()
+	s.pending[id] = ch
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.pending, id)
+		s.mu.Unlock()
+	}()
+
+	select {
+	case s.events <- msg:
+	case <-s.done:
+		return nil, ErrNoClientConnection
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	select {
+	case resp := <-ch:
+		if resp.Error != nil {
+			return nil, resp.Error
+		}
+		return resp.Result, nil
+	case <-s.done:
+		return nil, ErrNoClientConnection
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// turn returns the budget counting the tool calls of the named assistant turn.
+// A new turn, or a change of policy, starts a new budget from engine.
+func (s *session) turn(id string, policy *types.SecurityPolicy, engine *validate.PolicyEngine) *validate.TurnBudget {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.calls == nil || s.turnID != id || s.policy != policy {
+		s.turnID, s.calls, s.policy = id, engine.NewTurn(), policy
+	}
+	return s.calls
+}
+
+// deliver hands a response posted by the client to the request waiting on it.
+func (s *session) deliver(resp sessionResponse) error {
+	if resp.ID == nil {
+		return fmt.Errorf("response has no id")
+	}
+	s.mu.Lock()
+	ch, ok := s.pending[*resp.ID]
+	delete(s.pending, *resp.ID)
+	s.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("no pending request with id %d", *resp.ID)
+	}
+	ch <- resp
+	return nil
+}
+
+func (s *Server) openSession(r *http.Request) *session {
+	sess := &session{
+		id:      uuid.NewString(),
+		events:  make(chan []byte),
+		done:    make(chan struct{}),
+		pending: make(map[int64]chan sessionResponse),
+	}
+	if claims, ok := auth.FromContext(r.Context()); ok {
+		sess.subject = claims.Subject
+	}
+	s.sessionsMu.Lock()
+	defer s.sessionsMu.Unlock()
+	if s.sessions == nil {
+		s.sessions = make(map[string]*session)
+	}
+	s.sessions[sess.id] = sess
+	return sess
+}
+
+func (s *Server) closeSession(sess *session) {
+	s.sessionsMu.Lock()
+	delete(s.sessions, sess.id)
+	s.sessionsMu.Unlock()
+	close(sess.done)
+}
+
+// lookupSession returns the session named by the request, or nil if it names
+// none. Sessions opened with a token only accept posts made with the same subject.
+func (s *Server) lookupSession(r *http.Request) (*session, error) {
+	id := r.URL.Query().Get(SessionParam)
+	if id == "" {
+		return nil, nil
+	}
+	s.sessionsMu.Lock()
+	sess := s.sessions[id]
+	s.sessionsMu.Unlock()
+	if sess == nil {
+		return nil, fmt.Errorf("unknown session: %s", id)
+	}
+	subject := ""
+	if claims, ok := auth.FromContext(r.Context()); ok {
+		subject = claims.Subject
+	}
+	if subject != sess.subject {
+		return nil, fmt.Errorf("unknown session: %s", id)
+	}
+	return sess, nil
+}
+
+// HandleStream opens a server-sent event stream for a client session. The first
+// event names the endpoint the client posts its messages to; requests from the
+// server, such as elicitation, follow as message events for as long as the
+// stream stays open.
+//
+// https://modelcontextprotocol.io/specification/2024-11-05/basic/transports#http-with-sse
+func (s *Server) HandleStream(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	// The stream outlives the server's write timeout.
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Time{})
+
+	sess := s.openSession(r)
+	defer s.closeSession(sess)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "event: endpoint\ndata: %s?%s=%s\n\n", r.URL.Path, SessionParam, sess.id)
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case msg := <-sess.events:
+			if _, err := fmt.Fprintf(w, "event: message\ndata: %s\n\n", msg); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
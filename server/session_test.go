@@ -0,0 +1,172 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gomcp/codec"
+	"github.com/gomcp/mcp"
+	"github.com/gomcp/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// readEvent reads the next server-sent event from a stream.
+func readEvent(t *testing.T, r *bufio.Reader) (event, data string) {
+	t.Helper()
+	for {
+		line, err := r.ReadString('\n')
+		require.NoError(t, err)
+		line = strings.TrimRight(line, "\r\n")
+		switch {
+		case line == "" && event != "":
+			return event, data
+		case strings.HasPrefix(line, "event: "):
+			event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+}
+
+func TestHandleStream_ElicitationRoundTrip(t *testing.T) {
+	s := newTestServer()
+	s.AddTool(types.ToolDescription{Name: "greet", InputSchema: json.RawMessage(`{"type": "object"}`)},
+		func(ctx context.Context, req *ToolRequest) (*mcp.CallToolResult, error) {
+			schema := mcp.NewElicitationSchema()
+			schema.Properties["name"] = mcp.PrimitiveSchema{Type: "string"}
+			schema.Required = []string{"name"}
+			result, err := req.Elicit(ctx, "What is your name?", schema)
+			if err != nil {
+				return nil, err
+			}
+			return &mcp.CallToolResult{Content: mcp.Contents{mcp.NewTextContent("hello " + result.Content["name"].(string))}}, nil
+		})
+	ts := httptest.NewServer(SetupRoutes(s))
+	defer ts.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL+"/api/mcp", nil)
+	require.NoError(t, err)
+	stream, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer stream.Body.Close()
+	assert.Equal(t, "text/event-stream", stream.Header.Get("Content-Type"))
+	events := bufio.NewReader(stream.Body)
+
+	event, endpoint := readEvent(t, events)
+	require.Equal(t, "endpoint", event)
+	require.True(t, strings.HasPrefix(endpoint, "/api/mcp?"+SessionParam+"="))
+
+	type callResponse struct {
+		Result mcp.CallToolResult `json:"result"`
+		Error  *codec.RPCError    `json:"error"`
+	}
+	called := make(chan callResponse, 1)
+	go func() {
+		var resp callResponse
+		body := `{"jsonrpc": "2.0", "id": 1, "method": "tools/call", "params": {"name": "greet"}}`
+		if r, err := http.Post(ts.URL+endpoint, "application/json", bytes.NewBufferString(body)); err == nil {
+			json.NewDecoder(r.Body).Decode(&resp)
+			r.Body.Close()
+		}
+		called <- resp
+	}()
+
+	event, data := readEvent(t, events)
+	require.Equal(t, "message", event)
+	var elicit codec.JSONRPCRequest
+	require.NoError(t, json.Unmarshal([]byte(data), &elicit))
+	assert.Equal(t, string(mcp.MethodElicitationCreate), elicit.Method)
+	var params mcp.ElicitRequestParams
+	require.NoError(t, json.Unmarshal(elicit.Params, &params))
+	assert.Equal(t, "What is your name?", params.Message)
+
+	reply, err := json.Marshal(codec.JSONRPCResponse{
+		JSONRPC: codec.JsonRPCVersion,
+		ID:      elicit.ID,
+		Result:  mcp.ElicitResult{Action: mcp.ElicitAccept, Content: map[string]any{"name": "Ada"}},
+	})
+	require.NoError(t, err)
+	posted, err := http.Post(ts.URL+endpoint, "application/json", bytes.NewReader(reply))
+	require.NoError(t, err)
+	io.Copy(io.Discard, posted.Body)
+	posted.Body.Close()
+	assert.Equal(t, http.StatusAccepted, posted.StatusCode)
+
+	resp := <-called
+	require.Nil(t, resp.Error)
+	assert.False(t, resp.Result.IsError)
+	assert.Equal(t, "hello Ada", resp.Result.Content.Text())
+}
+
+func TestHandleRPC_UnknownSession(t *testing.T) {
+	s := newTestServer()
+	body := `{"jsonrpc": "2.0", "id": 1, "method": "ping"}`
+	rr := httptest.NewRecorder()
+	s.HandleRPC(rr, httptest.NewRequest(http.MethodPost, "/api/mcp?"+SessionParam+"=missing", bytes.NewBufferString(body)))
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestCallTool_SessionCallLimit(t *testing.T) {
+	s := newTestServer()
+	s.AddTool(types.ToolDescription{
+		Name:        "delete_forecast",
+		InputSchema: json.RawMessage(`{"type": "object"}`),
+		Annotations: &types.ToolAnnotations{DestructiveHint: types.Hint(true)},
+	}, func(ctx context.Context, req *ToolRequest) (*mcp.CallToolResult, error) {
+		return &mcp.CallToolResult{Content: mcp.Contents{mcp.NewTextContent("deleted")}}, nil
+	})
+	s.SetSecurityPolicy(types.SecurityPolicy{DestructiveTools: types.PolicyConfirm, MaxToolCallsPerTurn: 1}, nil)
+
+	sess := &session{events: make(chan []byte), done: make(chan struct{}), pending: make(map[int64]chan sessionResponse)}
+	defer close(sess.done)
+	// The user declines the first confirmation and accepts every later one.
+	go func() {
+		for action := `{"action": "decline"}`; ; action = `{"action": "accept", "content": {"confirm": true}}` {
+			select {
+			case msg := <-sess.events:
+				var req codec.JSONRPCRequest
+				json.Unmarshal(msg, &req)
+				id := int64(req.ID.(float64))
+				sess.deliver(sessionResponse{ID: &id, Result: json.RawMessage(action)})
+			case <-sess.done:
+				return
+			}
+		}
+	}()
+
+	call := func(turn string, requester Requester) *mcp.CallToolResult {
+		params := mcp.CallToolParams{Name: "delete_forecast"}
+		if turn != "" {
+			params.Meta = &mcp.RequestMeta{Turn: turn}
+		}
+		result, err := s.CallTool(context.Background(), params, requester)
+		require.NoError(t, err)
+		return result
+	}
+	assert.Contains(t, call("t1", sess).Content.Text(), "not confirmed")
+	assert.False(t, call("t1", sess).IsError, "declined calls do not count towards the limit")
+	assert.Contains(t, call("t1", sess).Content.Text(), "limit of 1 tool calls")
+	assert.False(t, call("t2", sess).IsError, "a new turn starts a new count")
+
+	// Calls that cannot be counted are denied.
+	assert.Contains(t, call("", sess).Content.Text(), "needs calls to name their turn")
+	assert.Contains(t, call("t3", nil).Content.Text(), "needs calls to name their turn")
+
+	// A new policy starts a new count as well.
+	assert.Contains(t, call("t2", sess).Content.Text(), "limit of 1 tool calls")
+	s.SetSecurityPolicy(types.SecurityPolicy{DestructiveTools: types.PolicyConfirm, MaxToolCallsPerTurn: 2}, nil)
+	assert.False(t, call("t2", sess).IsError)
+	assert.False(t, call("t2", sess).IsError)
+	assert.Contains(t, call("t2", sess).Content.Text(), "limit of 2 tool calls")
+}
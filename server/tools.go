@@ -0,0 +1,129 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/gomcp/codec"
+	"github.com/gomcp/mcp"
+	"github.com/gomcp/types"
+	"github.com/gomcp/validate"
+)
+
+// ToolHandler executes a single tools/call request. Handlers that set
+// StructuredContent on their result have it validated against the tool's
+// OutputSchema before it is sent to the client.
+type ToolHandler func(ctx context.Context, req *ToolRequest) (*mcp.CallToolResult, error)
+
+// ToolRequest is passed to a ToolHandler for a single tool invocation.
+type ToolRequest struct {
+	Params    mcp.CallToolParams
+	Tool      types.ToolDescription
+	requester Requester
+}
+
+// Elicit asks the user for more information through the client that made the call.
+func (r *ToolRequest) Elicit(ctx context.Context, message string, schema mcp.ElicitationSchema) (*mcp.ElicitResult, error) {
+	return Elicit(ctx, r.requester, message, schema)
+}
+
+type registeredTool struct {
+	desc    types.ToolDescription
+	handler ToolHandler
+}
+
+// AddTool registers a tool and the handler that executes it.
+// Registering a tool with an existing name replaces it.
+func (s *Server) AddTool(desc types.ToolDescription, handler ToolHandler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tools[desc.Name] = registeredTool{desc: desc, handler: handler}
+}
+
+// ListTools returns the descriptions of all registered tools, sorted by name.
+func (s *Server) ListTools() []types.ToolDescription {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	tools := make([]types.ToolDescription, 0, len(s.tools))
+	for _, tool := range s.tools {
+		tools = append(tools, tool.desc)
+	}
+	slices.SortFunc(tools, func(a, b types.ToolDescription) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return tools
+}
+
+// CallTool checks a tools/call request against the security policy (if one is set),
+// validates its arguments, runs the tool's handler and validates any structured
+// content it returns against the tool's OutputSchema.
+//
+// Protocol errors (unknown tool, invalid arguments) are returned as *codec.RPCError.
+// Failures inside the tool are reported in the result with IsError set.
+func (s *Server) CallTool(ctx context.Context, params mcp.CallToolParams, requester Requester) (*mcp.CallToolResult, error) {
+	s.mu.RLock()
+	tool, ok := s.tools[params.Name]
+	s.mu.RUnlock()
+	if !ok {
+		return nil, &codec.RPCError{Code: codec.InvalidParams, Message: fmt.Sprintf("unknown tool: %s", params.Name)}
+	}
+
+	args := params.Arguments
+	if len(args) == 0 {
+		args = json.RawMessage(`{}`)
+	}
+	call := types.ToolCall{FunctionName: params.Name, Arguments: args}
+	turnID := ""
+	if params.Meta != nil {
+		turnID = params.Meta.Turn
+	}
+	if denied := s.enforcePolicy(ctx, call, turnID, requester); denied != nil {
+		return denied, nil
+	}
+	if _, err := validate.ValidateToolSchema(ctx, call, []types.ToolDescription{tool.desc}); err != nil {
+		return nil, &codec.RPCError{Code: codec.InvalidParams, Message: err.Error()}
+	}
+
+	result, err := tool.handler(ctx, &ToolRequest{
+		Params:    params,
+		Tool:      tool.desc,
+		requester: requester,
+	})
+	if err != nil {
+		return mcp.NewToolErrorResult(err.Error()), nil
+	}
+	if result == nil {
+		result = &mcp.CallToolResult{}
+	}
+	if result.IsError {
+		return result, nil
+	}
+
+	if _, err := validate.ValidateStructuredContent(result.StructuredContent, &tool.desc); err != nil {
+		// Never forward output that does not match the advertised schema.
+		return mcp.NewToolErrorResult(fmt.Sprintf("tool '%s' produced output that does not match its output schema", tool.desc.Name)), nil
+	}
+	if len(result.StructuredContent) > 0 && len(result.Content) == 0 {
+		result.Content = mcp.Contents{mcp.NewTextContent(string(result.StructuredContent))}
+	}
+	if result.Content == nil {
+		result.Content = mcp.Contents{}
+	}
+	return result, nil
+}
+
+func (s *Server) handleToolsList(request any, extra mcp.RequestHandlerExtra) (any, error) {
+	return types.ListToolsResult{Tools: s.ListTools()}, nil
+}
+
+func (s *Server) handleToolsCall(request any, extra mcp.RequestHandlerExtra) (any, error) {
+	raw, _ := request.(json.RawMessage)
+	var params mcp.CallToolParams
+	if err := json.Unmarshal(raw, &params); err != nil {
+		return nil, &codec.RPCError{Code: codec.InvalidParams, Message: fmt.Sprintf("invalid tools/call params: %v", err)}
+	}
+	return s.CallTool(extra.Context, params, requesterFrom(extra.Context))
+}
@@ -0,0 +1,195 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gomcp/codec"
+	"github.com/gomcp/mcp"
+	"github.com/gomcp/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestServer creates a server without a logger or listener.
+func newTestServer() *Server {
+	s := &Server{
+		protocol: mcp.NewProtocol(),
+		tools:    make(map[string]registeredTool),
+	}
+	s.registerHandlers()
+	return s
+}
+
+var weatherTool = types.ToolDescription{
+	Name:        "get_weather",
+	Description: "Fetches weather",
+	InputSchema: json.RawMessage(`{"type": "object", "properties": {"location": {"type": "string"}}, "required": ["location"]}`),
+	OutputSchema: json.RawMessage(`{
+		"type": "object",
+		"properties": {"temperature": {"type": "number"}, "conditions": {"type": "string"}},
+		"required": ["temperature", "conditions"]
+	}`),
+}
+
+type weatherReport struct {
+	Temperature float64 `json:"temperature"`
+	Conditions  string  `json:"conditions"`
+}
+
+func TestCallTool_StructuredContent(t *testing.T) {
+	s := newTestServer()
+	s.AddTool(weatherTool, func(ctx context.Context, req *ToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewStructuredToolResult(weatherReport{Temperature: 22.5, Conditions: "Sunny"})
+	})
+
+	result, err := s.CallTool(context.Background(), mcp.CallToolParams{
+		Name:      "get_weather",
+		Arguments: json.RawMessage(`{"location": "Lisbon"}`),
+	}, nil)
+	require.NoError(t, err)
+
+	assert.False(t, result.IsError)
+	assert.JSONEq(t, `{"temperature": 22.5, "conditions": "Sunny"}`, string(result.StructuredContent))
+	require.Len(t, result.Content, 1)
+	assert.JSONEq(t, string(result.StructuredContent), result.Content.Text())
+}
+
+func TestCallTool_InvalidStructuredContent(t *testing.T) {
+	s := newTestServer()
+	s.AddTool(weatherTool, func(ctx context.Context, req *ToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewStructuredToolResult(map[string]any{"temperature": "hot"})
+	})
+
+	result, err := s.CallTool(context.Background(), mcp.CallToolParams{
+		Name:      "get_weather",
+		Arguments: json.RawMessage(`{"location": "Lisbon"}`),
+	}, nil)
+	require.NoError(t, err)
+
+	assert.True(t, result.IsError)
+	assert.Empty(t, result.StructuredContent, "invalid output must not be forwarded")
+	assert.NotContains(t, result.Content.Text(), "hot")
+}
+
+func TestCallTool_ProtocolErrors(t *testing.T) {
+	s := newTestServer()
+	s.AddTool(weatherTool, func(ctx context.Context, req *ToolRequest) (*mcp.CallToolResult, error) {
+		return nil, errors.New("weather service unavailable")
+	})
+
+	_, err := s.CallTool(context.Background(), mcp.CallToolParams{Name: "unknown"}, nil)
+	var rpcErr *codec.RPCError
+	require.ErrorAs(t, err, &rpcErr)
+	assert.Equal(t, codec.InvalidParams, rpcErr.Code)
+
+	_, err = s.CallTool(context.Background(), mcp.CallToolParams{Name: "get_weather", Arguments: json.RawMessage(`{}`)}, nil)
+	require.ErrorAs(t, err, &rpcErr)
+	assert.Contains(t, rpcErr.Message, "location is required")
+
+	result, err := s.CallTool(context.Background(), mcp.CallToolParams{
+		Name:      "get_weather",
+		Arguments: json.RawMessage(`{"location": "Lisbon"}`),
+	}, nil)
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+	assert.Equal(t, "weather service unavailable", result.Content.Text())
+}
+
+func TestHandleRPC_ToolsList(t *testing.T) {
+	s := newTestServer()
+	s.AddTool(weatherTool, nil)
+
+	body := `{"jsonrpc": "2.0", "id": 1, "method": "tools/list"}`
+	rr := httptest.NewRecorder()
+	s.HandleRPC(rr, httptest.NewRequest(http.MethodPost, "/api/mcp", bytes.NewBufferString(body)))
+
+	var resp struct {
+		Result types.ListToolsResult `json:"result"`
+		Error  *codec.RPCError       `json:"error"`
+	}
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+	require.Nil(t, resp.Error)
+	require.Len(t, resp.Result.Tools, 1)
+	assert.Equal(t, "get_weather", resp.Result.Tools[0].Name)
+}
+
+func TestHandleRPC_MethodNotFound(t *testing.T) {
+	s := newTestServer()
+
+	body := `{"jsonrpc": "2.0", "id": 1, "method": "does/not/exist"}`
+	rr := httptest.NewRecorder()
+	s.HandleRPC(rr, httptest.NewRequest(http.MethodPost, "/api/mcp", bytes.NewBufferString(body)))
+
+	var resp codec.JSONRPCResponse
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+	require.NotNil(t, resp.Error)
+	assert.Equal(t, codec.MethodNotFound, resp.Error.Code)
+}
+
+// fakeRequester answers every server-to-client request with a fixed result.
+type fakeRequester struct {
+	method string
+	result json.RawMessage
+}
+
+func (f *fakeRequester) Request(ctx context.Context, method string, params any) (json.RawMessage, error) {
+	f.method = method
+	return f.result, nil
+}
+
+func TestCallTool_SecurityPolicy(t *testing.T) {
+	s := newTestServer()
+	ran := 0
+	handler := func(ctx context.Context, req *ToolRequest) (*mcp.CallToolResult, error) {
+		ran++
+		return mcp.NewStructuredToolResult(weatherReport{Temperature: 22.5, Conditions: "Sunny"})
+	}
+	s.AddTool(weatherTool, handler)
+	deleteTool := types.ToolDescription{
+		Name:        "delete_forecast",
+		InputSchema: json.RawMessage(`{"type": "object"}`),
+		Annotations: &types.ToolAnnotations{DestructiveHint: types.Hint(true)},
+	}
+	s.AddTool(deleteTool, func(ctx context.Context, req *ToolRequest) (*mcp.CallToolResult, error) {
+		ran++
+		return &mcp.CallToolResult{Content: mcp.Contents{mcp.NewTextContent("deleted")}}, nil
+	})
+	s.SetSecurityPolicy(types.SecurityPolicy{
+		DisallowedTools:  []string{"get_weather"},
+		DestructiveTools: types.PolicyConfirm,
+	}, nil)
+
+	result, err := s.CallTool(context.Background(), mcp.CallToolParams{
+		Name:      "get_weather",
+		Arguments: json.RawMessage(`{"location": "Lisbon"}`),
+	}, nil)
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+	assert.Contains(t, result.Content.Text(), "denied by policy")
+
+	// Confirmation cannot be obtained without a client connection.
+	result, err = s.CallTool(context.Background(), mcp.CallToolParams{Name: "delete_forecast"}, nil)
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+
+	declined := &fakeRequester{result: json.RawMessage(`{"action": "decline"}`)}
+	result, err = s.CallTool(context.Background(), mcp.CallToolParams{Name: "delete_forecast"}, declined)
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+	assert.Equal(t, mcp.MethodElicitationCreate, declined.method)
+	assert.Zero(t, ran, "no handler may run before the policy allows it")
+
+	confirmed := &fakeRequester{result: json.RawMessage(`{"action": "accept", "content": {"confirm": true}}`)}
+	result, err = s.CallTool(context.Background(), mcp.CallToolParams{Name: "delete_forecast"}, confirmed)
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+	assert.Equal(t, "deleted", result.Content.Text())
+	assert.Equal(t, 1, ran)
+}
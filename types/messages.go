@@ -3,6 +3,8 @@ package types
 import (
 	"encoding/json"
 	"time"
+
+	"github.com/gomcp/mcp"
 )
 
 // General event/data handler
@@ -58,11 +60,68 @@ type ToolCall struct {
 }
 
 type ToolDescription struct {
-	Name         string          `json:"name"`
-	Description  string          `json:"description"`
-	InputSchema  json.RawMessage `json:"input_schema"`            // Expects JSON Schema definition here
-	OutputSchema json.RawMessage `json:"output_schema,omitempty"` // Optional: Schema for the tool's result
-	// Potentially SecurityMetadata for verifying the description itself
+	Name         string            `json:"name"`
+	Description  string            `json:"description"`
+	InputSchema  json.RawMessage   `json:"input_schema"`            // Expects JSON Schema definition here
+	OutputSchema json.RawMessage   `json:"output_schema,omitempty"` // Optional: Schema for the tool's result
+	Annotations  *ToolAnnotations  `json:"annotations,omitempty"`   // Optional: Behavioural hints for clients and policies
+	Security     *SecurityMetadata `json:"security,omitempty"`      // Optional: Provenance and signature for verifying the description itself
+}
+
+// ToolAnnotations describe how a tool behaves. They are hints only: clients should
+// not rely on them for tools from untrusted servers.
+//
+// https://modelcontextprotocol.io/specification/2025-06-18/server/tools#tool-annotations
+type ToolAnnotations struct {
+	Title           string `json:"title,omitempty"`           // Human-readable title for the tool
+	ReadOnlyHint    *bool  `json:"readOnlyHint,omitempty"`    // Tool does not modify its environment (default: false)
+	DestructiveHint *bool  `json:"destructiveHint,omitempty"` // Tool may perform destructive updates (default: true)
+	IdempotentHint  *bool  `json:"idempotentHint,omitempty"`  // Repeated calls with the same arguments have no additional effect (default: false)
+	OpenWorldHint   *bool  `json:"openWorldHint,omitempty"`   // Tool interacts with external entities (default: true)
+}
+
+// Hint returns a pointer to b for use in ToolAnnotations.
+func Hint(b bool) *bool { return &b }
+
+func hintOr(hint *bool, fallback bool) bool {
+	if hint == nil {
+		return fallback
+	}
+	return *hint
+}
+
+// IsReadOnly reports whether the tool claims not to modify its environment.
+func (t *ToolDescription) IsReadOnly() bool {
+	return t.Annotations != nil && hintOr(t.Annotations.ReadOnlyHint, false)
+}
+
+// IsDestructive reports whether the tool may perform destructive updates.
+// Read-only tools are never destructive; otherwise the spec default is true.
+func (t *ToolDescription) IsDestructive() bool {
+	if t.IsReadOnly() {
+		return false
+	}
+	return t.Annotations == nil || hintOr(t.Annotations.DestructiveHint, true)
+}
+
+// IsIdempotent reports whether repeated calls with the same arguments are safe.
+// Read-only tools are always idempotent.
+func (t *ToolDescription) IsIdempotent() bool {
+	if t.IsReadOnly() {
+		return true
+	}
+	return t.Annotations != nil && hintOr(t.Annotations.IdempotentHint, false)
+}
+
+// IsOpenWorld reports whether the tool may interact with external entities.
+func (t *ToolDescription) IsOpenWorld() bool {
+	return t.Annotations == nil || hintOr(t.Annotations.OpenWorldHint, true)
+}
+
+// ListToolsResult is returned by the server for a tools/list request.
+type ListToolsResult struct {
+	Tools      []ToolDescription `json:"tools"`
+	NextCursor string            `json:"nextCursor,omitempty"`
 }
 
 // ToolResultMetadata provides details about the execution of a tool.
@@ -92,6 +151,7 @@ type Message struct {
 	ID         string              `json:"id"`                     // Unique identifier for this message
 	Role       Role                `json:"role"`                   // Who sent this message?
 	Content    string              `json:"content"`                // Text content of the message (or tool result data)
+	Blocks     mcp.Contents        `json:"blocks,omitempty"`       // Rich content (images, audio, resources) accompanying Content
 	Timestamp  time.Time           `json:"timestamp"`              // Time the message was generated
 	ToolCalls  []ToolCall          `json:"tool_calls,omitempty"`   // Assistant requests to call tools (only if Role == RoleAssistant)
 	ToolCallID string              `json:"tool_call_id,omitempty"` // Links a Tool Result message back to its request (only if Role == RoleTool)
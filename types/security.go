@@ -37,11 +37,66 @@ type UserIdentity struct {
 	Claims map[string]any `json:"claims,omitempty"` // e.g., JWT claims
 }
 
+// PolicyAction defines how a policy treats a class of tool calls.
+type PolicyAction string
+
+const (
+	PolicyAllow   PolicyAction = "allow"   // Call may proceed (default when unset)
+	PolicyConfirm PolicyAction = "confirm" // Call may only proceed after the user confirms it
+	PolicyDeny    PolicyAction = "deny"    // Call must not proceed
+)
+
+// effective returns the action a policy enforces for a. Unset actions allow,
+// and unrecognized ones (e.g. a typo in a policy file) deny rather than
+// silently allowing.
+func (a PolicyAction) effective() PolicyAction {
+	switch a {
+	case "", PolicyAllow:
+		return PolicyAllow
+	case PolicyConfirm:
+		return PolicyConfirm
+	default:
+		return PolicyDeny
+	}
+}
+
+// severity orders actions from least to most restrictive.
+func (a PolicyAction) severity() int {
+	switch a.effective() {
+	case PolicyDeny:
+		return 2
+	case PolicyConfirm:
+		return 1
+	default:
+		return 0
+	}
+}
+
 // SecurityPolicy defines rules and constraints for the interaction.
 type SecurityPolicy struct {
-	AllowedTools        []string `json:"allowed_tools,omitempty"`           // Explicit list of tools allowed (whitelist)
-	DisallowedTools     []string `json:"disallowed_tools,omitempty"`        // Explicit list of tools disallowed (blacklist)
-	RequiredToolSource  string   `json:"required_tool_source,omitempty"`    // Tools must originate from this source (e.g., "trusted-registry")
-	MaxToolCallsPerTurn int      `json:"max_tool_calls_per_turn,omitempty"` // Limit on tool calls per assistant turn
-	DataHandlingRules   string   `json:"data_handling_rules,omitempty"`     // Instructions or policy references for data privacy/handling
+	AllowedTools        []string     `json:"allowed_tools,omitempty"`           // Explicit list of tools allowed (whitelist)
+	DisallowedTools     []string     `json:"disallowed_tools,omitempty"`        // Explicit list of tools disallowed (blacklist)
+	RequiredToolSource  string       `json:"required_tool_source,omitempty"`    // Tools must originate from this source (e.g., "trusted-registry")
+	MaxToolCallsPerTurn int          `json:"max_tool_calls_per_turn,omitempty"` // Limit on tool calls per assistant turn
+	DataHandlingRules   string       `json:"data_handling_rules,omitempty"`     // Instructions or policy references for data privacy/handling
+	DestructiveTools    PolicyAction `json:"destructive_tools,omitempty"`       // How to treat tools that may perform destructive updates
+	NonIdempotentTools  PolicyAction `json:"non_idempotent_tools,omitempty"`    // How to treat tools that are not safe to repeat
+	OpenWorldTools      PolicyAction `json:"open_world_tools,omitempty"`        // How to treat tools that interact with external entities
+	RequireSignedTools  bool         `json:"require_signed_tools,omitempty"`    // Tool descriptions must carry a valid signature in their SecurityMetadata
+}
+
+// AnnotationAction returns the most restrictive action the policy assigns to the
+// tool based on its annotations, along with the reason for it. Tools without
+// annotations fall back to the spec defaults (destructive, open world).
+func (p *SecurityPolicy) AnnotationAction(tool ToolDescription) (PolicyAction, string) {
+	action, reason := PolicyAllow, ""
+	apply := func(applies bool, a PolicyAction, why string) {
+		if applies && a.severity() > action.severity() {
+			action, reason = a.effective(), why
+		}
+	}
+	apply(tool.IsDestructive(), p.DestructiveTools, "tool may perform destructive updates")
+	apply(!tool.IsIdempotent(), p.NonIdempotentTools, "tool is not idempotent")
+	apply(tool.IsOpenWorld(), p.OpenWorldTools, "tool interacts with external entities")
+	return action, reason
 }
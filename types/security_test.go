@@ -0,0 +1,82 @@
+package types
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToolAnnotationDefaults(t *testing.T) {
+	unannotated := ToolDescription{Name: "run_shell"}
+	assert.False(t, unannotated.IsReadOnly())
+	assert.True(t, unannotated.IsDestructive())
+	assert.False(t, unannotated.IsIdempotent())
+	assert.True(t, unannotated.IsOpenWorld())
+
+	readOnly := ToolDescription{
+		Name:        "read_file",
+		Annotations: &ToolAnnotations{ReadOnlyHint: Hint(true), DestructiveHint: Hint(true), OpenWorldHint: Hint(false)},
+	}
+	assert.True(t, readOnly.IsReadOnly())
+	assert.False(t, readOnly.IsDestructive(), "read-only tools are never destructive")
+	assert.True(t, readOnly.IsIdempotent())
+	assert.False(t, readOnly.IsOpenWorld())
+}
+
+func TestToolAnnotationsJSON(t *testing.T) {
+	tool := ToolDescription{
+		Name:        "delete_file",
+		Annotations: &ToolAnnotations{Title: "Delete File", DestructiveHint: Hint(true), IdempotentHint: Hint(true)},
+	}
+	b, err := json.Marshal(tool)
+	require.NoError(t, err)
+	assert.Contains(t, string(b), `"annotations":{"title":"Delete File","destructiveHint":true,"idempotentHint":true}`)
+}
+
+func TestSecurityPolicyAnnotationAction(t *testing.T) {
+	policy := SecurityPolicy{
+		DestructiveTools: PolicyConfirm,
+		OpenWorldTools:   PolicyDeny,
+	}
+
+	tests := []struct {
+		name   string
+		tool   ToolDescription
+		action PolicyAction
+	}{
+		{
+			name:   "Read-only closed world tool is allowed",
+			tool:   ToolDescription{Annotations: &ToolAnnotations{ReadOnlyHint: Hint(true), OpenWorldHint: Hint(false)}},
+			action: PolicyAllow,
+		},
+		{
+			name:   "Destructive closed world tool needs confirmation",
+			tool:   ToolDescription{Annotations: &ToolAnnotations{OpenWorldHint: Hint(false)}},
+			action: PolicyConfirm,
+		},
+		{
+			name:   "Unannotated tool gets the strictest matching action",
+			tool:   ToolDescription{},
+			action: PolicyDeny,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			action, reason := policy.AnnotationAction(tc.tool)
+			assert.Equal(t, tc.action, action)
+			if action != PolicyAllow {
+				assert.NotEmpty(t, reason)
+			}
+		})
+	}
+
+	t.Run("Unknown action denies", func(t *testing.T) {
+		policy := SecurityPolicy{DestructiveTools: "block"}
+		action, reason := policy.AnnotationAction(ToolDescription{})
+		assert.Equal(t, PolicyDeny, action)
+		assert.NotEmpty(t, reason)
+	})
+}
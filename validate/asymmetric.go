@@ -0,0 +1,231 @@
+package validate
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+// ErrNotARecipient indicates a payload holds no content key for the given recipient.
+var ErrNotARecipient = errors.New("payload is not addressed to this recipient")
+
+// keyWrapInfo binds derived key-encryption keys to this payload format.
+const keyWrapInfo = "gomcp secured payload v1 key wrap"
+
+// Recipient holds the content key of an asymmetric payload, wrapped for one recipient.
+type Recipient struct {
+	KeyID        string `json:"kid"` // Identifies the recipient's X25519 key
+	EphemeralKey []byte `json:"epk"` // Sender's ephemeral X25519 public key
+	WrappedKey   []byte `json:"wk"`  // Content key encrypted with the key derived via ECDH
+}
+
+// RecipientKey is the X25519 public key of a party allowed to open a payload.
+type RecipientKey struct {
+	KeyID     string
+	PublicKey *ecdh.PublicKey
+}
+
+// SecureAsymmetric marshals the input data, encrypts it with a random content key and
+// signs the result with the sender's Ed25519 key. The content key is wrapped for each
+// recipient so that only they can decrypt, while anyone holding the sender's public key
+// can verify. Unlike Secure, verifiers cannot forge payloads.
+func SecureAsymmetric(data any, signingKey ed25519.PrivateKey, recipients []RecipientKey, opts ...SecureOption) ([]byte, error) {
+	return secureAsymmetric(data, signingKey, "", recipients, opts)
+}
+
+// secureAsymmetric implements SecureAsymmetric, recording keyID in the payload if it is set.
+func secureAsymmetric(data any, signingKey ed25519.PrivateKey, keyID string, recipients []RecipientKey, opts []SecureOption) ([]byte, error) {
+	if len(signingKey) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("%w: expected %d bytes for Ed25519 private key", ErrInvalidKey, ed25519.PrivateKeySize)
+	}
+	if len(recipients) == 0 {
+		return nil, errors.New("at least one recipient is required")
+	}
+
+	plaintext, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal input data: %w", err)
+	}
+
+	contentKey := make([]byte, AesKeySize)
+	if _, err := io.ReadFull(rand.Reader, contentKey); err != nil {
+		return nil, fmt.Errorf("failed to generate content key: %w", err)
+	}
+	nonce, ciphertext, err := encrypt(plaintext, contentKey, nil)
+	if err != nil {
+		return nil, fmt.Errorf("encryption failed: %w", err)
+	}
+
+	payload := newPayload(AlgEd25519X25519, keyID, opts)
+	payload.Nonce = nonce
+	payload.Ciphertext = ciphertext
+	for _, rk := range recipients {
+		recipient, err := wrapContentKey(contentKey, rk)
+		if err != nil {
+			return nil, fmt.Errorf("failed to wrap content key for '%s': %w", rk.KeyID, err)
+		}
+		payload.Recipients = append(payload.Recipients, recipient)
+	}
+
+	dataToSign, err := payload.signingInput()
+	if err != nil {
+		return nil, fmt.Errorf("signing failed: %w", err)
+	}
+	payload.Signature = ed25519.Sign(signingKey, dataToSign)
+
+	securedBytes, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal secured payload: %w", err)
+	}
+	return securedBytes, nil
+}
+
+// OpenAsymmetric verifies the sender's Ed25519 signature on a payload created by
+// SecureAsymmetric, unwraps the content key addressed to keyID and unmarshals the
+// decrypted data into 'target', which must be a pointer.
+func OpenAsymmetric(securedData []byte, verifyKey ed25519.PublicKey, keyID string, decryptionKey *ecdh.PrivateKey, target any, opts ...OpenOption) error {
+	if target == nil {
+		return errors.New("target interface cannot be nil")
+	}
+	if len(verifyKey) != ed25519.PublicKeySize {
+		return fmt.Errorf("%w: expected %d bytes for Ed25519 public key", ErrInvalidKey, ed25519.PublicKeySize)
+	}
+	if decryptionKey == nil || decryptionKey.Curve() != ecdh.X25519() {
+		return fmt.Errorf("%w: expected an X25519 private key", ErrInvalidKey)
+	}
+
+	payload, err := parsePayload(securedData)
+	if err != nil {
+		return err
+	}
+	return openAsymmetric(payload, verifyKey, keyID, decryptionKey, target, opts)
+}
+
+// openAsymmetric implements OpenAsymmetric for a parsed payload.
+func openAsymmetric(payload SecuredPayload, verifyKey ed25519.PublicKey, keyID string, decryptionKey *ecdh.PrivateKey, target any, opts []OpenOption) error {
+	if payload.Version != PayloadVersion1 || payload.Algorithm != AlgEd25519X25519 {
+		return fmt.Errorf("%w: expected '%s', got '%s'", ErrUnsupportedAlgorithm, AlgEd25519X25519, payload.algorithm())
+	}
+
+	dataToCheck, err := payload.signingInput()
+	if err != nil {
+		return err
+	}
+	if !ed25519.Verify(verifyKey, dataToCheck, payload.Signature) {
+		return fmt.Errorf("signature verification failed: %w", ErrAuthenticationFailed)
+	}
+	if guard := newOpenOptions(opts).guard; guard != nil {
+		if err := guard.Check(payload); err != nil {
+			return err
+		}
+	}
+
+	var contentKey []byte
+	for _, recipient := range payload.Recipients {
+		if recipient.KeyID == keyID {
+			contentKey, err = unwrapContentKey(recipient, decryptionKey)
+			if err != nil {
+				return err
+			}
+			break
+		}
+	}
+	if contentKey == nil {
+		return fmt.Errorf("%w: '%s'", ErrNotARecipient, keyID)
+	}
+
+	plaintext, err := decrypt(payload.Nonce, payload.Ciphertext, contentKey, nil)
+	if err != nil {
+		return fmt.Errorf("decryption failed: %w", err)
+	}
+	if err := json.Unmarshal(plaintext, target); err != nil {
+		return fmt.Errorf("failed to unmarshal decrypted data into target: %w", err)
+	}
+	return nil
+}
+
+// wrapContentKey encrypts the content key for a recipient with a key-encryption key
+// derived from an ephemeral X25519 key agreement.
+func wrapContentKey(contentKey []byte, rk RecipientKey) (Recipient, error) {
+	if rk.PublicKey == nil || rk.PublicKey.Curve() != ecdh.X25519() {
+		return Recipient{}, fmt.Errorf("%w: expected an X25519 public key", ErrInvalidKey)
+	}
+	ephemeral, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return Recipient{}, fmt.Errorf("failed to generate ephemeral key: %w", err)
+	}
+	shared, err := ephemeral.ECDH(rk.PublicKey)
+	if err != nil {
+		return Recipient{}, fmt.Errorf("key agreement failed: %w", err)
+	}
+
+	epk := ephemeral.PublicKey().Bytes()
+	kek, err := deriveKeyEncryptionKey(shared, epk, rk.PublicKey.Bytes())
+	if err != nil {
+		return Recipient{}, err
+	}
+	gcm, err := newGCM(kek)
+	if err != nil {
+		return Recipient{}, err
+	}
+	// Each key-encryption key is derived from a fresh ephemeral key and used exactly
+	// once, so a fixed nonce is safe here.
+	wrapped := gcm.Seal(nil, make([]byte, NonceSize), contentKey, []byte(rk.KeyID))
+	return Recipient{KeyID: rk.KeyID, EphemeralKey: epk, WrappedKey: wrapped}, nil
+}
+
+func unwrapContentKey(recipient Recipient, decryptionKey *ecdh.PrivateKey) ([]byte, error) {
+	epk, err := ecdh.X25519().NewPublicKey(recipient.EphemeralKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid ephemeral key: %w", ErrInvalidInput, err)
+	}
+	shared, err := decryptionKey.ECDH(epk)
+	if err != nil {
+		return nil, fmt.Errorf("key agreement failed: %w", err)
+	}
+	kek, err := deriveKeyEncryptionKey(shared, recipient.EphemeralKey, decryptionKey.PublicKey().Bytes())
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := newGCM(kek)
+	if err != nil {
+		return nil, err
+	}
+	contentKey, err := gcm.Open(nil, make([]byte, NonceSize), recipient.WrappedKey, []byte(recipient.KeyID))
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to unwrap content key: %w", ErrDecryptionFailed, err)
+	}
+	return contentKey, nil
+}
+
+// deriveKeyEncryptionKey runs HKDF-SHA256 over the shared secret, salted with both
+// public keys so the derived key is bound to this exchange.
+func deriveKeyEncryptionKey(shared, ephemeralPub, recipientPub []byte) ([]byte, error) {
+	salt := append(append([]byte{}, ephemeralPub...), recipientPub...)
+	kek := make([]byte, AesKeySize)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, shared, salt, []byte(keyWrapInfo)), kek); err != nil {
+		return nil, fmt.Errorf("key derivation failed: %w", err)
+	}
+	return kek, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create AES cipher: %w", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GCM: %w", err)
+	}
+	return gcm, nil
+}
@@ -0,0 +1,141 @@
+package validate
+
+import (
+	"crypto/ecdh"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type asymmetricParties struct {
+	senderPub  ed25519.PublicKey
+	senderPriv ed25519.PrivateKey
+	alice      *ecdh.PrivateKey
+	bob        *ecdh.PrivateKey
+}
+
+func newAsymmetricParties(t *testing.T) asymmetricParties {
+	t.Helper()
+	var p asymmetricParties
+	var err error
+	p.senderPub, p.senderPriv, err = ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	p.alice, err = ecdh.X25519().GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	p.bob, err = ecdh.X25519().GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	return p
+}
+
+func (p asymmetricParties) secure(t *testing.T, data any) []byte {
+	t.Helper()
+	securedBytes, err := SecureAsymmetric(data, p.senderPriv, []RecipientKey{
+		{KeyID: "alice", PublicKey: p.alice.PublicKey()},
+		{KeyID: "bob", PublicKey: p.bob.PublicKey()},
+	})
+	require.NoError(t, err)
+	return securedBytes
+}
+
+func TestSecureAsymmetric(t *testing.T) {
+	p := newAsymmetricParties(t)
+	originalData := testPayload{Name: "Alice", Age: 30}
+
+	t.Run("Success Round Trip For Each Recipient", func(t *testing.T) {
+		securedBytes := p.secure(t, &originalData)
+
+		var payload SecuredPayload
+		require.NoError(t, json.Unmarshal(securedBytes, &payload))
+		assert.Equal(t, PayloadVersion1, payload.Version)
+		assert.Equal(t, AlgEd25519X25519, payload.Algorithm)
+		assert.Len(t, payload.Recipients, 2)
+
+		for kid, key := range map[string]*ecdh.PrivateKey{"alice": p.alice, "bob": p.bob} {
+			var recovered testPayload
+			require.NoError(t, OpenAsymmetric(securedBytes, p.senderPub, kid, key, &recovered))
+			assert.Equal(t, originalData, recovered)
+		}
+	})
+
+	t.Run("Fail Not A Recipient", func(t *testing.T) {
+		securedBytes := p.secure(t, &originalData)
+		eve, err := ecdh.X25519().GenerateKey(rand.Reader)
+		require.NoError(t, err)
+
+		var recovered testPayload
+		assert.ErrorIs(t, OpenAsymmetric(securedBytes, p.senderPub, "eve", eve, &recovered), ErrNotARecipient)
+		assert.ErrorIs(t, OpenAsymmetric(securedBytes, p.senderPub, "alice", eve, &recovered), ErrDecryptionFailed)
+	})
+
+	t.Run("Fail Recipient Cannot Forge", func(t *testing.T) {
+		// A recipient knows the content key but not the sender's signing key.
+		_, forgerPriv, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+		forged, err := SecureAsymmetric(&testPayload{Name: "Mallory"}, forgerPriv, []RecipientKey{{KeyID: "bob", PublicKey: p.bob.PublicKey()}})
+		require.NoError(t, err)
+
+		var recovered testPayload
+		assert.ErrorIs(t, OpenAsymmetric(forged, p.senderPub, "bob", p.bob, &recovered), ErrAuthenticationFailed)
+	})
+
+	t.Run("Fail Tampered Header", func(t *testing.T) {
+		securedBytes := p.secure(t, &originalData)
+		var payload SecuredPayload
+		require.NoError(t, json.Unmarshal(securedBytes, &payload))
+
+		// Dropping a recipient is covered by the signature.
+		payload.Recipients = payload.Recipients[:1]
+		tampered, err := json.Marshal(payload)
+		require.NoError(t, err)
+
+		var recovered testPayload
+		assert.ErrorIs(t, OpenAsymmetric(tampered, p.senderPub, "alice", p.alice, &recovered), ErrAuthenticationFailed)
+	})
+
+	t.Run("Fail Mixed Modes", func(t *testing.T) {
+		securedBytes := p.secure(t, &originalData)
+		var recovered testPayload
+		err := ValidateAndOpen(securedBytes, mustGenerateKey(t, AesKeySize), mustGenerateKey(t, HmacKeySize), &recovered)
+		assert.ErrorIs(t, err, ErrUnsupportedAlgorithm)
+
+		hmacBytes, err := Secure(&originalData, mustGenerateKey(t, AesKeySize), mustGenerateKey(t, HmacKeySize))
+		require.NoError(t, err)
+		assert.ErrorIs(t, OpenAsymmetric(hmacBytes, p.senderPub, "alice", p.alice, &recovered), ErrUnsupportedAlgorithm)
+	})
+
+	t.Run("Fail No Recipients", func(t *testing.T) {
+		_, err := SecureAsymmetric(&originalData, p.senderPriv, nil)
+		assert.Error(t, err)
+	})
+}
+
+func TestValidateAndOpen_LegacyPayload(t *testing.T) {
+	encKey := mustGenerateKey(t, AesKeySize)
+	signKey := mustGenerateKey(t, HmacKeySize)
+	plaintext, err := json.Marshal(testPayload{Name: "Legacy", Age: 1})
+	require.NoError(t, err)
+
+	// Payloads written before versioning carry no header and sign Nonce + Ciphertext.
+	nonce, ciphertext, err := encrypt(plaintext, encKey, nil)
+	require.NoError(t, err)
+	signature, err := signHMAC(append(append([]byte{}, nonce...), ciphertext...), signKey)
+	require.NoError(t, err)
+	legacy, err := json.Marshal(map[string][]byte{"n": nonce, "c": ciphertext, "s": signature})
+	require.NoError(t, err)
+
+	var recovered testPayload
+	require.NoError(t, ValidateAndOpen(legacy, encKey, signKey, &recovered))
+	assert.Equal(t, "Legacy", recovered.Name)
+
+	// Relabelling a legacy payload as version 1 invalidates its signature.
+	var payload SecuredPayload
+	require.NoError(t, json.Unmarshal(legacy, &payload))
+	payload.Version = PayloadVersion1
+	relabelled, err := json.Marshal(payload)
+	require.NoError(t, err)
+	assert.ErrorIs(t, ValidateAndOpen(relabelled, encKey, signKey, &recovered), ErrAuthenticationFailed)
+}
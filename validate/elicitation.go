@@ -0,0 +1,70 @@
+package validate
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/gomcp/mcp"
+
+	"github.com/xeipuuv/gojsonschema"
+)
+
+// ErrInvalidElicitation indicates an elicitation response that does not satisfy the requested schema.
+var ErrInvalidElicitation = errors.New("invalid elicitation response")
+
+// ValidateElicitationResult checks a user's response against the schema
+// that was requested. Declined or cancelled responses must not carry content.
+func ValidateElicitationResult(schema mcp.ElicitationSchema, result mcp.ElicitResult) error {
+	switch result.Action {
+	case mcp.ElicitAccept:
+	case mcp.ElicitDecline, mcp.ElicitCancel:
+		if len(result.Content) > 0 {
+			return fmt.Errorf("%w: content is only allowed when action is '%s'", ErrInvalidElicitation, mcp.ElicitAccept)
+		}
+		return nil
+	default:
+		return fmt.Errorf("%w: unknown action '%s'", ErrInvalidElicitation, result.Action)
+	}
+
+	if err := schema.Validate(); err != nil {
+		return fmt.Errorf("invalid requested schema: %w", err)
+	}
+	schemaJSON, err := json.Marshal(schema)
+	if err != nil {
+		return fmt.Errorf("failed to marshal requested schema: %w", err)
+	}
+	content := result.Content
+	if content == nil {
+		content = map[string]any{}
+	}
+	contentJSON, err := json.Marshal(content)
+	if err != nil {
+		return fmt.Errorf("failed to marshal elicitation content: %w", err)
+	}
+
+	s, err := gojsonschema.NewSchema(gojsonschema.NewBytesLoader(schemaJSON))
+	if err != nil {
+		return fmt.Errorf("internal schema error for elicitation: %w", err)
+	}
+	res, err := s.Validate(gojsonschema.NewBytesLoader(contentJSON))
+	if err != nil {
+		return fmt.Errorf("internal validation error for elicitation: %w", err)
+	}
+	if !res.Valid() {
+		var validationErrors []string
+		for _, desc := range res.Errors() {
+			validationErrors = append(validationErrors, fmt.Sprintf("- %s", desc))
+		}
+		return fmt.Errorf("%w:\n%s", ErrInvalidElicitation, strings.Join(validationErrors, "\n"))
+	}
+
+	// Reject fields the server never asked for.
+	for name := range result.Content {
+		if _, ok := schema.Properties[name]; !ok {
+			return fmt.Errorf("%w: unexpected property '%s'", ErrInvalidElicitation, name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package validate
+
+import (
+	"testing"
+
+	"github.com/gomcp/mcp"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testElicitationSchema() mcp.ElicitationSchema {
+	schema := mcp.NewElicitationSchema()
+	schema.Properties["account"] = mcp.PrimitiveSchema{
+		Type:  mcp.SchemaTypeString,
+		Title: "Account",
+		Enum:  []string{"personal", "work"},
+	}
+	schema.Properties["confirm"] = mcp.PrimitiveSchema{
+		Type:  mcp.SchemaTypeBoolean,
+		Title: "Delete all files?",
+	}
+	schema.Required = []string{"confirm"}
+	return schema
+}
+
+func TestValidateElicitationResult(t *testing.T) {
+	schema := testElicitationSchema()
+
+	tests := []struct {
+		name        string
+		result      mcp.ElicitResult
+		expectError bool
+	}{
+		{
+			name:   "Accept Valid Content",
+			result: mcp.ElicitResult{Action: mcp.ElicitAccept, Content: map[string]any{"account": "work", "confirm": true}},
+		},
+		{
+			name:        "Accept Missing Required",
+			result:      mcp.ElicitResult{Action: mcp.ElicitAccept, Content: map[string]any{"account": "work"}},
+			expectError: true,
+		},
+		{
+			name:        "Accept Wrong Type",
+			result:      mcp.ElicitResult{Action: mcp.ElicitAccept, Content: map[string]any{"confirm": "yes"}},
+			expectError: true,
+		},
+		{
+			name:        "Accept Value Outside Enum",
+			result:      mcp.ElicitResult{Action: mcp.ElicitAccept, Content: map[string]any{"account": "other", "confirm": true}},
+			expectError: true,
+		},
+		{
+			name:        "Accept Unexpected Property",
+			result:      mcp.ElicitResult{Action: mcp.ElicitAccept, Content: map[string]any{"confirm": true, "password": "hunter2"}},
+			expectError: true,
+		},
+		{
+			name:   "Decline Without Content",
+			result: mcp.ElicitResult{Action: mcp.ElicitDecline},
+		},
+		{
+			name:        "Cancel With Content",
+			result:      mcp.ElicitResult{Action: mcp.ElicitCancel, Content: map[string]any{"confirm": true}},
+			expectError: true,
+		},
+		{
+			name:        "Unknown Action",
+			result:      mcp.ElicitResult{Action: "maybe"},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateElicitationResult(schema, tt.result)
+			if tt.expectError {
+				require.Error(t, err)
+				assert.ErrorIs(t, err, ErrInvalidElicitation)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestElicitationSchemaRejectsNestedTypes(t *testing.T) {
+	schema := mcp.NewElicitationSchema()
+	schema.Properties["address"] = mcp.PrimitiveSchema{Type: "object"}
+	assert.Error(t, schema.Validate())
+
+	schema = mcp.NewElicitationSchema()
+	schema.Properties["count"] = mcp.PrimitiveSchema{Type: mcp.SchemaTypeInteger, Enum: []string{"1"}}
+	assert.Error(t, schema.Validate())
+
+	schema = testElicitationSchema()
+	schema.Required = append(schema.Required, "missing")
+	assert.Error(t, schema.Validate())
+
+	err := ValidateElicitationResult(schema, mcp.ElicitResult{Action: mcp.ElicitAccept, Content: map[string]any{"confirm": true}})
+	assert.Error(t, err)
+}
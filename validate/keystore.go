@@ -0,0 +1,140 @@
+package validate
+
+import (
+	"crypto"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/gomcp/keys"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+const (
+	encryptionKeyInfo = "gomcp secured payload v1 encryption"
+	signingKeyInfo    = "gomcp secured payload v1 signing"
+)
+
+// SecureWithKeys works like Secure, using the active symmetric key of the store.
+// Separate encryption and signing keys are derived from it, and its ID is recorded
+// in the payload so receivers can find it after the key has been rotated.
+func SecureWithKeys(data any, store keys.KeyStore, opts ...SecureOption) ([]byte, error) {
+	key, err := store.Active(keys.AlgSymmetric)
+	if err != nil {
+		return nil, err
+	}
+	encryptionKey, signingKey, err := deriveSymmetricKeys(key)
+	if err != nil {
+		return nil, err
+	}
+	return secureHMAC(data, encryptionKey, signingKey, key.ID, nil, opts)
+}
+
+// ValidateAndOpenWithKeys works like ValidateAndOpen, looking up the key named by
+// the payload's key ID. Retired keys are accepted until their grace window ends.
+func ValidateAndOpenWithKeys(securedData []byte, store keys.KeyStore, target any, opts ...OpenOption) error {
+	payload, err := parsePayload(securedData)
+	if err != nil {
+		return err
+	}
+	if alg := payload.algorithm(); alg != AlgHMACSHA256 {
+		return fmt.Errorf("%w: '%s' requires OpenAsymmetricWithKeys", ErrUnsupportedAlgorithm, alg)
+	}
+	if payload.KeyID == "" {
+		return fmt.Errorf("%w: payload has no key ID", ErrInvalidInput)
+	}
+	key, err := keys.Verifiable(store, payload.KeyID)
+	if err != nil {
+		return err
+	}
+	encryptionKey, signingKey, err := deriveSymmetricKeys(key)
+	if err != nil {
+		return err
+	}
+	return openHMAC(payload, encryptionKey, signingKey, nil, target, opts)
+}
+
+// SecureAsymmetricWithKeys works like SecureAsymmetric, signing with the active
+// Ed25519 key of the store and recording its ID in the payload.
+func SecureAsymmetricWithKeys(data any, store keys.KeyStore, recipients []RecipientKey, opts ...SecureOption) ([]byte, error) {
+	key, err := store.Active(keys.AlgEd25519)
+	if err != nil {
+		return nil, err
+	}
+	signingKey, err := key.Ed25519PrivateKey()
+	if err != nil {
+		return nil, err
+	}
+	return secureAsymmetric(data, signingKey, key.ID, recipients, opts)
+}
+
+// OpenAsymmetricWithKeys works like OpenAsymmetric. The sender's verification key is
+// looked up by the payload's key ID and the X25519 key of recipientKeyID decrypts it;
+// both must be in the store. The sender's key is usually public-only (see
+// keys.NewPublicKey), as receivers never hold the sender's private key.
+func OpenAsymmetricWithKeys(securedData []byte, store keys.KeyStore, recipientKeyID string, target any, opts ...OpenOption) error {
+	if target == nil {
+		return errors.New("target interface cannot be nil")
+	}
+	payload, err := parsePayload(securedData)
+	if err != nil {
+		return err
+	}
+	if payload.KeyID == "" {
+		return fmt.Errorf("%w: payload has no key ID", ErrInvalidInput)
+	}
+	senderKey, err := keys.Verifiable(store, payload.KeyID)
+	if err != nil {
+		return err
+	}
+	verifyKey, err := senderKey.Ed25519PublicKey()
+	if err != nil {
+		return err
+	}
+	recipientKey, err := keys.Verifiable(store, recipientKeyID)
+	if err != nil {
+		return err
+	}
+	decryptionKey, err := recipientKey.X25519PrivateKey()
+	if err != nil {
+		return err
+	}
+	return openAsymmetric(payload, verifyKey, recipientKeyID, decryptionKey, target, opts)
+}
+
+// KeyStoreResolver returns a KeyResolver that verifies tool signatures with the
+// Ed25519 keys of a store, honouring their grace windows. Public-only keys are
+// resolved from their public material.
+func KeyStoreResolver(store keys.KeyStore) KeyResolver {
+	return keyStoreResolver{store: store}
+}
+
+type keyStoreResolver struct {
+	store keys.KeyStore
+}
+
+func (r keyStoreResolver) PublicKey(keyID string) (crypto.PublicKey, error) {
+	key, err := keys.Verifiable(r.store, keyID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrUnknownSigningKey, err)
+	}
+	return key.Ed25519PublicKey()
+}
+
+// deriveSymmetricKeys derives independent AES and HMAC keys from a symmetric key.
+func deriveSymmetricKeys(key keys.Key) (encryptionKey, signingKey []byte, err error) {
+	if key.Algorithm != keys.AlgSymmetric {
+		return nil, nil, fmt.Errorf("%w: key '%s' is %s", ErrInvalidKey, key.ID, key.Algorithm)
+	}
+	encryptionKey = make([]byte, AesKeySize)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, key.Material, nil, []byte(encryptionKeyInfo)), encryptionKey); err != nil {
+		return nil, nil, fmt.Errorf("key derivation failed: %w", err)
+	}
+	signingKey = make([]byte, HmacKeySize)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, key.Material, nil, []byte(signingKeyInfo)), signingKey); err != nil {
+		return nil, nil, fmt.Errorf("key derivation failed: %w", err)
+	}
+	return encryptionKey, signingKey, nil
+}
@@ -0,0 +1,99 @@
+package validate
+
+import (
+	"crypto/ed25519"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/gomcp/keys"
+	msg "github.com/gomcp/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecureWithKeys_Rotation(t *testing.T) {
+	store := keys.NewMemoryStore()
+	first, err := keys.Rotate(store, keys.AlgSymmetric, time.Hour)
+	require.NoError(t, err)
+
+	originalData := testPayload{Name: "Alice", Age: 30}
+	securedBytes, err := SecureWithKeys(&originalData, store)
+	require.NoError(t, err)
+
+	var payload SecuredPayload
+	require.NoError(t, json.Unmarshal(securedBytes, &payload))
+	assert.Equal(t, first.ID, payload.KeyID)
+
+	// Payloads signed before a rotation still open during the grace window.
+	_, err = keys.Rotate(store, keys.AlgSymmetric, time.Hour)
+	require.NoError(t, err)
+	var recovered testPayload
+	require.NoError(t, ValidateAndOpenWithKeys(securedBytes, store, &recovered))
+	assert.Equal(t, originalData, recovered)
+
+	// ... but not once it has passed.
+	_, err = keys.Rotate(store, keys.AlgSymmetric, 0)
+	require.NoError(t, err)
+	expired := first
+	past := time.Now().Add(-time.Minute)
+	expired.RetiredAt, expired.ExpiresAt = &past, &past
+	require.NoError(t, store.Put(expired))
+	assert.ErrorIs(t, ValidateAndOpenWithKeys(securedBytes, store, &recovered), keys.ErrKeyExpired)
+
+	// Changing the key ID invalidates the signature.
+	active, err := store.Active(keys.AlgSymmetric)
+	require.NoError(t, err)
+	payload.KeyID = active.ID
+	relabelled, err := json.Marshal(payload)
+	require.NoError(t, err)
+	assert.ErrorIs(t, ValidateAndOpenWithKeys(relabelled, store, &recovered), ErrAuthenticationFailed)
+}
+
+func TestSecureAsymmetricWithKeys(t *testing.T) {
+	sender := keys.NewMemoryStore()
+	_, err := keys.Rotate(sender, keys.AlgEd25519, time.Hour)
+	require.NoError(t, err)
+	signingKey, err := sender.Active(keys.AlgEd25519)
+	require.NoError(t, err)
+
+	recipient, err := keys.Generate(keys.AlgX25519)
+	require.NoError(t, err)
+	recipientPriv, err := recipient.X25519PrivateKey()
+	require.NoError(t, err)
+
+	securedBytes, err := SecureAsymmetricWithKeys(&testPayload{Name: "Bob"}, sender,
+		[]RecipientKey{{KeyID: recipient.ID, PublicKey: recipientPriv.PublicKey()}})
+	require.NoError(t, err)
+
+	senderPublic, err := signingKey.PublicOnly()
+	require.NoError(t, err)
+	receiver := keys.NewMemoryStore(senderPublic, recipient)
+
+	var recovered testPayload
+	require.NoError(t, OpenAsymmetricWithKeys(securedBytes, receiver, recipient.ID, &recovered))
+	assert.Equal(t, "Bob", recovered.Name)
+
+	_, err = SecureAsymmetricWithKeys(&testPayload{Name: "Mallory"}, receiver, nil)
+	assert.ErrorIs(t, err, keys.ErrNoActiveKey, "public-only keys never sign")
+}
+
+func TestKeyStoreResolver(t *testing.T) {
+	store := keys.NewMemoryStore()
+	key, err := keys.Rotate(store, keys.AlgEd25519, time.Hour)
+	require.NoError(t, err)
+	priv, err := key.Ed25519PrivateKey()
+	require.NoError(t, err)
+
+	tool := msg.ToolDescription{Name: "echo"}
+	require.NoError(t, SignTool(&tool, key.ID, ed25519.PrivateKey(priv)))
+	assert.NoError(t, VerifyTool(tool, KeyStoreResolver(store)))
+
+	public, err := key.PublicOnly()
+	require.NoError(t, err)
+	assert.NoError(t, VerifyTool(tool, KeyStoreResolver(keys.NewMemoryStore(public))), "public-only keys verify")
+
+	require.NoError(t, SignTool(&tool, "unknown", ed25519.PrivateKey(priv)))
+	assert.ErrorIs(t, VerifyTool(tool, KeyStoreResolver(store)), ErrUnknownSigningKey)
+}
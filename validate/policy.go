@@ -0,0 +1,206 @@
+package validate
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/gomcp/mcp"
+	msg "github.com/gomcp/types"
+)
+
+// ErrNotAssistantMessage indicates tool calls were found on a message not produced by the LLM.
+var ErrNotAssistantMessage = errors.New("tool calls are only valid on assistant messages")
+
+// PolicyDecision is the outcome of evaluating one tool call against a SecurityPolicy.
+type PolicyDecision struct {
+	ToolCallID string           `json:"tool_call_id"`
+	ToolName   string           `json:"tool_name"`
+	Action     msg.PolicyAction `json:"action"`           // allow, confirm or deny
+	Reason     string           `json:"reason,omitempty"` // Why the call was not simply allowed
+}
+
+// Allowed reports whether the call may proceed without further checks.
+func (d PolicyDecision) Allowed() bool { return d.Action == msg.PolicyAllow }
+
+// NeedsConfirmation reports whether the call may only proceed after the user confirms it.
+func (d PolicyDecision) NeedsConfirmation() bool { return d.Action == msg.PolicyConfirm }
+
+// PolicyEngine evaluates tool calls against a SecurityPolicy, the tools that
+// are available and the SecurityMetadata recorded for each tool.
+type PolicyEngine struct {
+	policy   msg.SecurityPolicy
+	tools    map[string]msg.ToolDescription
+	metadata map[string]msg.SecurityMetadata // keyed by tool name
+	keys     KeyResolver                     // verifies signed tool descriptions
+}
+
+// NewPolicyEngine creates an engine for the given policy. metadata maps tool
+// names to the SecurityMetadata describing where each tool came from; tools
+// missing from it fall back to the SecurityMetadata in their description, but
+// only when its signature verifies with the engine's keys (see WithKeys).
+func NewPolicyEngine(policy msg.SecurityPolicy, tools []msg.ToolDescription, metadata map[string]msg.SecurityMetadata) *PolicyEngine {
+	e := &PolicyEngine{
+		policy:   policy,
+		tools:    make(map[string]msg.ToolDescription, len(tools)),
+		metadata: metadata,
+	}
+	for _, tool := range tools {
+		e.tools[tool.Name] = tool
+	}
+	if e.metadata == nil {
+		e.metadata = make(map[string]msg.SecurityMetadata)
+	}
+	return e
+}
+
+// WithKeys sets the keys used to verify signed tool descriptions and returns the engine.
+func (e *PolicyEngine) WithKeys(keys KeyResolver) *PolicyEngine {
+	e.keys = keys
+	return e
+}
+
+// Evaluate returns a decision for every tool call in an assistant message,
+// in the order the calls appear. Calls beyond MaxToolCallsPerTurn are denied;
+// denied calls do not count towards the limit, while calls that need
+// confirmation count as if confirmed. Callers that confirm calls should use
+// EvaluateCall with a TurnBudget instead, so declined calls are not counted.
+func (e *PolicyEngine) Evaluate(message msg.Message) ([]PolicyDecision, error) {
+	if len(message.ToolCalls) > 0 && message.Role != msg.RoleAssistant {
+		return nil, fmt.Errorf("%w: got role '%s'", ErrNotAssistantMessage, message.Role)
+	}
+
+	turn := e.NewTurn()
+	decisions := make([]PolicyDecision, 0, len(message.ToolCalls))
+	for _, call := range message.ToolCalls {
+		decision := turn.Limit(e.EvaluateCall(call))
+		if decision.Action != msg.PolicyDeny {
+			turn.Use()
+		}
+		decisions = append(decisions, decision)
+	}
+	return decisions, nil
+}
+
+// TurnBudget counts the tool calls of one assistant turn against the policy's
+// MaxToolCallsPerTurn. A call is only counted once Use is called for it, which
+// callers do after the call has been allowed (and confirmed, if needed).
+// It is safe for concurrent use.
+type TurnBudget struct {
+	mu    sync.Mutex
+	limit int
+	used  int
+}
+
+// NewTurn returns an empty budget for a new assistant turn.
+func (e *PolicyEngine) NewTurn() *TurnBudget {
+	return &TurnBudget{limit: e.policy.MaxToolCallsPerTurn}
+}
+
+// Limit returns decision unchanged while the turn has calls left, and a denial otherwise.
+func (b *TurnBudget) Limit(decision PolicyDecision) PolicyDecision {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if decision.Action != msg.PolicyDeny && b.limit > 0 && b.used >= b.limit {
+		decision.Action = msg.PolicyDeny
+		decision.Reason = fmt.Sprintf("exceeds the limit of %d tool calls per turn", b.limit)
+	}
+	return decision
+}
+
+// Use counts an allowed call against the turn. It reports false, counting
+// nothing, if the turn has no calls left.
+func (b *TurnBudget) Use() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.limit > 0 && b.used >= b.limit {
+		return false
+	}
+	b.used++
+	return true
+}
+
+// EvaluateCall returns the decision for a single tool call.
+// Per-turn limits are not applied; use Evaluate for whole messages, or a
+// TurnBudget when calls are evaluated one at a time.
+func (e *PolicyEngine) EvaluateCall(call msg.ToolCall) PolicyDecision {
+	decision := PolicyDecision{
+		ToolCallID: call.ID,
+		ToolName:   call.FunctionName,
+		Action:     msg.PolicyAllow,
+	}
+	deny := func(format string, args ...any) PolicyDecision {
+		decision.Action = msg.PolicyDeny
+		decision.Reason = fmt.Sprintf(format, args...)
+		return decision
+	}
+
+	tool, ok := e.tools[call.FunctionName]
+	if !ok {
+		return deny("tool '%s' is not available", call.FunctionName)
+	}
+	if err := NewToolVerifier(e.policy, e.keys).Verify(tool); err != nil {
+		return deny("%v", err)
+	}
+	if slices.Contains(e.policy.DisallowedTools, call.FunctionName) {
+		return deny("tool '%s' is disallowed by policy", call.FunctionName)
+	}
+	if len(e.policy.AllowedTools) > 0 && !slices.Contains(e.policy.AllowedTools, call.FunctionName) {
+		return deny("tool '%s' is not in the list of allowed tools", call.FunctionName)
+	}
+	if e.policy.RequiredToolSource != "" {
+		source, ok := e.toolSource(tool)
+		if !ok {
+			return deny("tool '%s' has no verified source, policy requires '%s'", call.FunctionName, e.policy.RequiredToolSource)
+		}
+		if source != e.policy.RequiredToolSource {
+			return deny("tool '%s' has source '%s', policy requires '%s'", call.FunctionName, source, e.policy.RequiredToolSource)
+		}
+	}
+
+	if action, reason := e.policy.AnnotationAction(tool); action != msg.PolicyAllow {
+		decision.Action = action
+		decision.Reason = reason
+	}
+	return decision
+}
+
+// toolSource returns the source of a tool from the metadata the engine was
+// created with. Failing that, the Source a tool claims for itself is only
+// trusted once its signature verifies, since the server could claim anything.
+func (e *PolicyEngine) toolSource(tool msg.ToolDescription) (string, bool) {
+	if metadata, ok := e.metadata[tool.Name]; ok {
+		return metadata.Source, true
+	}
+	if tool.Security == nil || VerifyTool(tool, e.keys) != nil {
+		return "", false
+	}
+	return tool.Security.Source, true
+}
+
+// ConfirmationRequest builds the elicitation used to ask the user whether a
+// tool call that requires confirmation may proceed.
+func ConfirmationRequest(decision PolicyDecision) mcp.ElicitRequestParams {
+	schema := mcp.NewElicitationSchema()
+	schema.Properties["confirm"] = mcp.PrimitiveSchema{
+		Type:        mcp.SchemaTypeBoolean,
+		Title:       "Allow this tool call?",
+		Description: decision.Reason,
+	}
+	schema.Required = []string{"confirm"}
+	return mcp.ElicitRequestParams{
+		Message:         fmt.Sprintf("The tool '%s' requires confirmation: %s.", decision.ToolName, decision.Reason),
+		RequestedSchema: schema,
+	}
+}
+
+// Confirmed reports whether an elicitation result built from ConfirmationRequest
+// approves the tool call.
+func Confirmed(result *mcp.ElicitResult) bool {
+	if result == nil || !result.Accepted() {
+		return false
+	}
+	confirm, ok := result.Content["confirm"].(bool)
+	return ok && confirm
+}
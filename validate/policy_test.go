@@ -0,0 +1,170 @@
+package validate
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"testing"
+
+	"github.com/gomcp/mcp"
+	msg "github.com/gomcp/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var policyToolsFixture = []msg.ToolDescription{
+	{Name: "read_file", Annotations: &msg.ToolAnnotations{ReadOnlyHint: msg.Hint(true), OpenWorldHint: msg.Hint(false)}},
+	{Name: "delete_file", Annotations: &msg.ToolAnnotations{DestructiveHint: msg.Hint(true), OpenWorldHint: msg.Hint(false)}},
+	{Name: "send_email", Annotations: &msg.ToolAnnotations{DestructiveHint: msg.Hint(false)}},
+	{Name: "run_shell"},
+}
+
+var policyMetadataFixture = map[string]msg.SecurityMetadata{
+	"read_file":   {Source: "trusted-registry"},
+	"delete_file": {Source: "trusted-registry"},
+	"send_email":  {Source: "user-provided"},
+	"run_shell":   {Source: "trusted-registry"},
+}
+
+func assistantMessage(names ...string) msg.Message {
+	m := msg.Message{Role: msg.RoleAssistant}
+	for i, name := range names {
+		m.ToolCalls = append(m.ToolCalls, msg.ToolCall{
+			ID:           string(rune('a' + i)),
+			FunctionName: name,
+			Arguments:    json.RawMessage(`{}`),
+		})
+	}
+	return m
+}
+
+func TestPolicyEngineEvaluate(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   msg.SecurityPolicy
+		message  msg.Message
+		expected []msg.PolicyAction
+		reasons  []string
+	}{
+		{
+			name:     "Empty Policy Allows Known Tools",
+			message:  assistantMessage("read_file", "run_shell"),
+			expected: []msg.PolicyAction{msg.PolicyAllow, msg.PolicyAllow},
+		},
+		{
+			name:     "Unknown Tool Denied",
+			message:  assistantMessage("format_disk"),
+			expected: []msg.PolicyAction{msg.PolicyDeny},
+			reasons:  []string{"not available"},
+		},
+		{
+			name:     "Disallowed Tool Denied",
+			policy:   msg.SecurityPolicy{DisallowedTools: []string{"run_shell"}},
+			message:  assistantMessage("read_file", "run_shell"),
+			expected: []msg.PolicyAction{msg.PolicyAllow, msg.PolicyDeny},
+			reasons:  []string{"", "disallowed"},
+		},
+		{
+			name:     "Allow List Enforced",
+			policy:   msg.SecurityPolicy{AllowedTools: []string{"read_file"}},
+			message:  assistantMessage("read_file", "delete_file"),
+			expected: []msg.PolicyAction{msg.PolicyAllow, msg.PolicyDeny},
+			reasons:  []string{"", "not in the list of allowed tools"},
+		},
+		{
+			name:     "Required Source Enforced",
+			policy:   msg.SecurityPolicy{RequiredToolSource: "trusted-registry"},
+			message:  assistantMessage("read_file", "send_email"),
+			expected: []msg.PolicyAction{msg.PolicyAllow, msg.PolicyDeny},
+			reasons:  []string{"", "has source 'user-provided'"},
+		},
+		{
+			name:     "Max Calls Per Turn",
+			policy:   msg.SecurityPolicy{MaxToolCallsPerTurn: 2},
+			message:  assistantMessage("read_file", "read_file", "read_file"),
+			expected: []msg.PolicyAction{msg.PolicyAllow, msg.PolicyAllow, msg.PolicyDeny},
+			reasons:  []string{"", "", "limit of 2 tool calls"},
+		},
+		{
+			name:     "Denied Calls Do Not Count Towards Limit",
+			policy:   msg.SecurityPolicy{MaxToolCallsPerTurn: 1, DisallowedTools: []string{"delete_file"}},
+			message:  assistantMessage("delete_file", "read_file", "read_file"),
+			expected: []msg.PolicyAction{msg.PolicyDeny, msg.PolicyAllow, msg.PolicyDeny},
+			reasons:  []string{"disallowed", "", "limit of 1 tool calls"},
+		},
+		{
+			name:     "Destructive Tools Need Confirmation",
+			policy:   msg.SecurityPolicy{DestructiveTools: msg.PolicyConfirm},
+			message:  assistantMessage("read_file", "delete_file"),
+			expected: []msg.PolicyAction{msg.PolicyAllow, msg.PolicyConfirm},
+			reasons:  []string{"", "destructive"},
+		},
+		{
+			name:     "Open World Tools Denied",
+			policy:   msg.SecurityPolicy{OpenWorldTools: msg.PolicyDeny},
+			message:  assistantMessage("send_email", "delete_file"),
+			expected: []msg.PolicyAction{msg.PolicyDeny, msg.PolicyAllow},
+			reasons:  []string{"external entities", ""},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			engine := NewPolicyEngine(tc.policy, policyToolsFixture, policyMetadataFixture)
+			decisions, err := engine.Evaluate(tc.message)
+			require.NoError(t, err)
+			require.Len(t, decisions, len(tc.expected))
+
+			for i, decision := range decisions {
+				assert.Equal(t, tc.message.ToolCalls[i].ID, decision.ToolCallID)
+				assert.Equal(t, tc.expected[i], decision.Action, "decision %d", i)
+				if tc.reasons != nil && tc.reasons[i] != "" {
+					assert.Contains(t, decision.Reason, tc.reasons[i])
+				}
+			}
+		})
+	}
+}
+
+func TestPolicyEngineSelfDeclaredSource(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	signed := msg.ToolDescription{Name: "signed_tool", Security: &msg.SecurityMetadata{Source: "trusted-registry"}}
+	require.NoError(t, SignTool(&signed, "registry-key", priv))
+	claimed := msg.ToolDescription{Name: "claimed_tool", Security: &msg.SecurityMetadata{Source: "trusted-registry"}}
+
+	policy := msg.SecurityPolicy{RequiredToolSource: "trusted-registry"}
+	tools := []msg.ToolDescription{signed, claimed}
+	message := assistantMessage("signed_tool", "claimed_tool")
+
+	decisions, err := NewPolicyEngine(policy, tools, nil).WithKeys(StaticKeys{"registry-key": pub}).Evaluate(message)
+	require.NoError(t, err)
+	assert.Equal(t, msg.PolicyAllow, decisions[0].Action, "verified sources are trusted")
+	assert.Equal(t, msg.PolicyDeny, decisions[1].Action, "unsigned sources are unknown")
+	assert.Contains(t, decisions[1].Reason, "no verified source")
+
+	decisions, err = NewPolicyEngine(policy, tools, nil).Evaluate(message)
+	require.NoError(t, err)
+	assert.Equal(t, msg.PolicyDeny, decisions[0].Action, "signatures cannot be verified without keys")
+}
+
+func TestPolicyEngineRejectsNonAssistantToolCalls(t *testing.T) {
+	message := assistantMessage("read_file")
+	message.Role = msg.RoleUser
+
+	_, err := NewPolicyEngine(msg.SecurityPolicy{}, policyToolsFixture, nil).Evaluate(message)
+	assert.ErrorIs(t, err, ErrNotAssistantMessage)
+}
+
+func TestConfirmationRequest(t *testing.T) {
+	decision := PolicyDecision{ToolName: "delete_file", Action: msg.PolicyConfirm, Reason: "tool may perform destructive updates"}
+	req := ConfirmationRequest(decision)
+	require.NoError(t, req.RequestedSchema.Validate())
+	assert.Contains(t, req.Message, "delete_file")
+
+	assert.True(t, Confirmed(&mcp.ElicitResult{Action: mcp.ElicitAccept, Content: map[string]any{"confirm": true}}))
+	assert.False(t, Confirmed(&mcp.ElicitResult{Action: mcp.ElicitAccept, Content: map[string]any{"confirm": false}}))
+	assert.False(t, Confirmed(&mcp.ElicitResult{Action: mcp.ElicitDecline}))
+	assert.False(t, Confirmed(nil))
+}
@@ -0,0 +1,189 @@
+package validate
+
+import (
+	"container/list"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrReplayDetected indicates a payload whose message ID has already been accepted.
+	ErrReplayDetected = errors.New("secured payload has already been received")
+	// ErrPayloadExpired indicates a payload issued outside the accepted clock skew window.
+	ErrPayloadExpired = errors.New("secured payload issued outside the accepted time window")
+	// ErrAudienceMismatch indicates a payload addressed to a different audience.
+	ErrAudienceMismatch = errors.New("secured payload is addressed to a different audience")
+)
+
+// DefaultReplayWindow is the clock skew tolerated by a ReplayGuard without an explicit window.
+const DefaultReplayWindow = 5 * time.Minute
+
+// SecureOption configures how a payload is secured.
+type SecureOption func(*secureOptions)
+
+type secureOptions struct {
+	audience string
+}
+
+// WithAudience addresses the payload to an audience, e.g. the receiving server's URL.
+func WithAudience(audience string) SecureOption {
+	return func(o *secureOptions) { o.audience = audience }
+}
+
+func newSecureOptions(opts []SecureOption) secureOptions {
+	var o secureOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// OpenOption configures how a payload is validated and opened.
+type OpenOption func(*openOptions)
+
+type openOptions struct {
+	guard *ReplayGuard
+}
+
+// WithReplayGuard rejects payloads that are replayed, stale or addressed to someone else.
+func WithReplayGuard(guard *ReplayGuard) OpenOption {
+	return func(o *openOptions) { o.guard = guard }
+}
+
+func newOpenOptions(opts []OpenOption) openOptions {
+	var o openOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// NonceStore records the message IDs of accepted payloads.
+type NonceStore interface {
+	// Add records id until expiresAt. It reports false if id is already recorded.
+	Add(id string, expiresAt time.Time) (bool, error)
+}
+
+// ReplayGuard checks the issued-at time, message ID and audience that version 1
+// payloads carry. Payloads must be issued within Window of the current time, and
+// each message ID is only accepted once while it could still pass the time check.
+type ReplayGuard struct {
+	Audience string           // Expected audience; empty accepts any
+	Window   time.Duration    // Tolerated clock skew; DefaultReplayWindow if zero
+	Store    NonceStore       // Seen message IDs
+	Now      func() time.Time // Optional: clock used for checks, time.Now if nil
+}
+
+// NewReplayGuard creates a guard backed by a MemoryNonceStore holding up to capacity message IDs.
+func NewReplayGuard(audience string, window time.Duration, capacity int) *ReplayGuard {
+	return &ReplayGuard{
+		Audience: audience,
+		Window:   window,
+		Store:    NewMemoryNonceStore(capacity),
+	}
+}
+
+// Check validates the replay protection fields of a payload whose signature has
+// already been verified, and records its message ID.
+func (g *ReplayGuard) Check(payload SecuredPayload) error {
+	if payload.Version < PayloadVersion1 || payload.IssuedAt == 0 || payload.MessageID == "" {
+		return fmt.Errorf("%w: payload carries no replay protection fields", ErrInvalidInput)
+	}
+	if g.Audience != "" && payload.Audience != g.Audience {
+		return fmt.Errorf("%w: expected '%s', got '%s'", ErrAudienceMismatch, g.Audience, payload.Audience)
+	}
+
+	window := g.Window
+	if window <= 0 {
+		window = DefaultReplayWindow
+	}
+	now := time.Now()
+	if g.Now != nil {
+		now = g.Now()
+	}
+	issuedAt := time.Unix(payload.IssuedAt, 0)
+	if issuedAt.Before(now.Add(-window)) || issuedAt.After(now.Add(window)) {
+		return fmt.Errorf("%w: issued at %s", ErrPayloadExpired, issuedAt.UTC().Format(time.RFC3339))
+	}
+
+	// Once the window has passed the payload is rejected as stale, so the
+	// message ID only needs to be remembered until then.
+	fresh, err := g.Store.Add(payload.MessageID, issuedAt.Add(window))
+	if err != nil {
+		return fmt.Errorf("failed to record message id: %w", err)
+	}
+	if !fresh {
+		return fmt.Errorf("%w: message id '%s'", ErrReplayDetected, payload.MessageID)
+	}
+	return nil
+}
+
+// MemoryNonceStore keeps message IDs in memory. It holds at most capacity entries;
+// when full, expired entries are dropped first and then the oldest ones, so the
+// capacity should exceed the number of payloads expected within a replay window.
+type MemoryNonceStore struct {
+	mu       sync.Mutex
+	capacity int
+	entries  map[string]*list.Element
+	order    *list.List // of nonceEntry, oldest first
+}
+
+type nonceEntry struct {
+	id        string
+	expiresAt time.Time
+}
+
+// NewMemoryNonceStore creates a store holding up to capacity message IDs, 10000 if capacity is not positive.
+func NewMemoryNonceStore(capacity int) *MemoryNonceStore {
+	if capacity <= 0 {
+		capacity = 10_000
+	}
+	return &MemoryNonceStore{
+		capacity: capacity,
+		entries:  make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// Add records id until expiresAt, evicting entries if the store is full.
+func (s *MemoryNonceStore) Add(id string, expiresAt time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if el, ok := s.entries[id]; ok {
+		if el.Value.(nonceEntry).expiresAt.After(now) {
+			return false, nil
+		}
+		s.remove(el)
+	}
+
+	s.evictExpired(now)
+	for s.order.Len() >= s.capacity {
+		s.remove(s.order.Front())
+	}
+	s.entries[id] = s.order.PushBack(nonceEntry{id: id, expiresAt: expiresAt})
+	return true, nil
+}
+
+// Len returns the number of recorded message IDs.
+func (s *MemoryNonceStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.order.Len()
+}
+
+// evictExpired drops expired entries from the front of the list. Entries arrive
+// roughly in expiry order, so stopping at the first live one is good enough.
+func (s *MemoryNonceStore) evictExpired(now time.Time) {
+	for el := s.order.Front(); el != nil && !el.Value.(nonceEntry).expiresAt.After(now); el = s.order.Front() {
+		s.remove(el)
+	}
+}
+
+func (s *MemoryNonceStore) remove(el *list.Element) {
+	delete(s.entries, el.Value.(nonceEntry).id)
+	s.order.Remove(el)
+}
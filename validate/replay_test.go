@@ -0,0 +1,151 @@
+package validate
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecure_ReplayFields(t *testing.T) {
+	encKey := mustGenerateKey(t, AesKeySize)
+	signKey := mustGenerateKey(t, HmacKeySize)
+
+	first, err := Secure(&testPayload{Name: "Alice"}, encKey, signKey, WithAudience("https://server.example"))
+	require.NoError(t, err)
+	second, err := Secure(&testPayload{Name: "Alice"}, encKey, signKey)
+	require.NoError(t, err)
+
+	var p1, p2 SecuredPayload
+	require.NoError(t, json.Unmarshal(first, &p1))
+	require.NoError(t, json.Unmarshal(second, &p2))
+	assert.Equal(t, "https://server.example", p1.Audience)
+	assert.Empty(t, p2.Audience)
+	assert.NotEmpty(t, p1.MessageID)
+	assert.NotEqual(t, p1.MessageID, p2.MessageID)
+	assert.WithinDuration(t, time.Now(), time.Unix(p1.IssuedAt, 0), time.Minute)
+
+	// The replay fields are covered by the signature.
+	p1.Audience = "https://other.example"
+	tampered, err := json.Marshal(p1)
+	require.NoError(t, err)
+	var recovered testPayload
+	assert.ErrorIs(t, ValidateAndOpen(tampered, encKey, signKey, &recovered), ErrAuthenticationFailed)
+}
+
+func TestValidateAndOpen_ReplayGuard(t *testing.T) {
+	encKey := mustGenerateKey(t, AesKeySize)
+	signKey := mustGenerateKey(t, HmacKeySize)
+	const audience = "https://server.example"
+
+	t.Run("Rejects Duplicate", func(t *testing.T) {
+		guard := NewReplayGuard(audience, time.Minute, 100)
+		securedBytes, err := Secure(&testPayload{Name: "Alice"}, encKey, signKey, WithAudience(audience))
+		require.NoError(t, err)
+
+		var recovered testPayload
+		require.NoError(t, ValidateAndOpen(securedBytes, encKey, signKey, &recovered, WithReplayGuard(guard)))
+		assert.Equal(t, "Alice", recovered.Name)
+		assert.ErrorIs(t, ValidateAndOpen(securedBytes, encKey, signKey, &recovered, WithReplayGuard(guard)), ErrReplayDetected)
+
+		// Without a guard the payload still opens.
+		assert.NoError(t, ValidateAndOpen(securedBytes, encKey, signKey, &recovered))
+	})
+
+	t.Run("Rejects Wrong Audience", func(t *testing.T) {
+		guard := NewReplayGuard(audience, time.Minute, 100)
+		securedBytes, err := Secure(&testPayload{Name: "Alice"}, encKey, signKey, WithAudience("https://other.example"))
+		require.NoError(t, err)
+
+		var recovered testPayload
+		assert.ErrorIs(t, ValidateAndOpen(securedBytes, encKey, signKey, &recovered, WithReplayGuard(guard)), ErrAudienceMismatch)
+	})
+
+	t.Run("Rejects Outside Window", func(t *testing.T) {
+		securedBytes, err := Secure(&testPayload{Name: "Alice"}, encKey, signKey, WithAudience(audience))
+		require.NoError(t, err)
+
+		var recovered testPayload
+		late := NewReplayGuard(audience, time.Minute, 100)
+		late.Now = func() time.Time { return time.Now().Add(2 * time.Minute) }
+		assert.ErrorIs(t, ValidateAndOpen(securedBytes, encKey, signKey, &recovered, WithReplayGuard(late)), ErrPayloadExpired)
+
+		early := NewReplayGuard(audience, time.Minute, 100)
+		early.Now = func() time.Time { return time.Now().Add(-2 * time.Minute) }
+		assert.ErrorIs(t, ValidateAndOpen(securedBytes, encKey, signKey, &recovered, WithReplayGuard(early)), ErrPayloadExpired)
+	})
+
+	t.Run("Rejects Forged Before Recording", func(t *testing.T) {
+		guard := NewReplayGuard(audience, time.Minute, 100)
+		securedBytes, err := Secure(&testPayload{Name: "Alice"}, encKey, mustGenerateKey(t, HmacKeySize), WithAudience(audience))
+		require.NoError(t, err)
+
+		var recovered testPayload
+		assert.ErrorIs(t, ValidateAndOpen(securedBytes, encKey, signKey, &recovered, WithReplayGuard(guard)), ErrAuthenticationFailed)
+		assert.Equal(t, 0, guard.Store.(*MemoryNonceStore).Len())
+	})
+
+	t.Run("Rejects Legacy Payload", func(t *testing.T) {
+		plaintext, err := json.Marshal(testPayload{Name: "Legacy"})
+		require.NoError(t, err)
+		nonce, ciphertext, err := encrypt(plaintext, encKey, nil)
+		require.NoError(t, err)
+		signature, err := signHMAC(append(append([]byte{}, nonce...), ciphertext...), signKey)
+		require.NoError(t, err)
+		legacy, err := json.Marshal(map[string][]byte{"n": nonce, "c": ciphertext, "s": signature})
+		require.NoError(t, err)
+
+		var recovered testPayload
+		guard := NewReplayGuard("", time.Minute, 100)
+		assert.ErrorIs(t, ValidateAndOpen(legacy, encKey, signKey, &recovered, WithReplayGuard(guard)), ErrInvalidInput)
+	})
+}
+
+func TestOpenAsymmetric_ReplayGuard(t *testing.T) {
+	p := newAsymmetricParties(t)
+	securedBytes, err := SecureAsymmetric(&testPayload{Name: "Alice"}, p.senderPriv,
+		[]RecipientKey{{KeyID: "alice", PublicKey: p.alice.PublicKey()}}, WithAudience("alice"))
+	require.NoError(t, err)
+
+	guard := NewReplayGuard("alice", 0, 0)
+	var recovered testPayload
+	require.NoError(t, OpenAsymmetric(securedBytes, p.senderPub, "alice", p.alice, &recovered, WithReplayGuard(guard)))
+	assert.ErrorIs(t, OpenAsymmetric(securedBytes, p.senderPub, "alice", p.alice, &recovered, WithReplayGuard(guard)), ErrReplayDetected)
+}
+
+func TestMemoryNonceStore(t *testing.T) {
+	t.Run("Bounded", func(t *testing.T) {
+		store := NewMemoryNonceStore(3)
+		expiresAt := time.Now().Add(time.Hour)
+		for i := range 5 {
+			fresh, err := store.Add(fmt.Sprintf("id-%d", i), expiresAt)
+			require.NoError(t, err)
+			assert.True(t, fresh)
+		}
+		assert.Equal(t, 3, store.Len())
+
+		// The oldest IDs were evicted, the newest are still known.
+		fresh, err := store.Add("id-4", expiresAt)
+		require.NoError(t, err)
+		assert.False(t, fresh)
+		fresh, err = store.Add("id-0", expiresAt)
+		require.NoError(t, err)
+		assert.True(t, fresh)
+	})
+
+	t.Run("Expired Entries", func(t *testing.T) {
+		store := NewMemoryNonceStore(10)
+		fresh, err := store.Add("old", time.Now().Add(-time.Second))
+		require.NoError(t, err)
+		assert.True(t, fresh)
+
+		// An expired ID may be recorded again and is dropped before live ones.
+		fresh, err = store.Add("old", time.Now().Add(time.Hour))
+		require.NoError(t, err)
+		assert.True(t, fresh)
+		assert.Equal(t, 1, store.Len())
+	})
+}
@@ -10,6 +10,9 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"time"
+
+	"github.com/google/uuid"
 )
 
 var (
@@ -21,6 +24,11 @@ var (
 	ErrInvalidInput = errors.New("invalid input data for validation")
 	// ErrInvalidKey indicates an incorrect key size.
 	ErrInvalidKey = errors.New("invalid key size")
+	// ErrUnsupportedAlgorithm indicates a payload version or algorithm the caller cannot open.
+	ErrUnsupportedAlgorithm = errors.New("unsupported secured payload algorithm")
+	// ErrAADMismatch indicates a payload bound to different associated data than expected.
+	// It is always wrapped together with ErrAuthenticationFailed.
+	ErrAADMismatch = errors.New("associated data mismatch")
 )
 
 const (
@@ -32,16 +40,89 @@ const (
 	HmacKeySize = 32
 )
 
+const (
+	// PayloadVersionLegacy marks payloads without version or algorithm, signed over Nonce + Ciphertext.
+	PayloadVersionLegacy = 0
+	// PayloadVersion1 payloads are signed over every field except the signature itself.
+	PayloadVersion1 = 1
+
+	// AlgHMACSHA256 encrypts with a shared AES-256-GCM key and signs with a shared HMAC-SHA256 key.
+	AlgHMACSHA256 = "A256GCM+HS256"
+	// AlgEd25519X25519 encrypts with a random content key wrapped for each recipient
+	// through X25519 ECDH, and signs with the sender's Ed25519 key.
+	AlgEd25519X25519 = "A256GCM+X25519+Ed25519"
+)
+
 // SecuredPayload defines the structure for the data during transport.
 type SecuredPayload struct {
-	Nonce      []byte `json:"n"` // Nonce for AES-GCM (12 bytes)
-	Ciphertext []byte `json:"c"` // Encrypted original data (JSON of Context/ContextUpdate)
-	Signature  []byte `json:"s"` // HMAC-SHA256 signature of Nonce + Ciphertext
+	Version    int         `json:"v,omitempty"`   // Payload format version; 0 for legacy payloads
+	Algorithm  string      `json:"alg,omitempty"` // Encryption and signing algorithm; empty means AlgHMACSHA256
+	KeyID      string      `json:"kid,omitempty"` // Identifies the signing key in the sender's keyring
+	IssuedAt   int64       `json:"iat,omitempty"` // Unix time the payload was secured
+	MessageID  string      `json:"jti,omitempty"` // Unique ID used to detect replays
+	Audience   string      `json:"aud,omitempty"` // Intended receiver, see WithAudience
+	AAD        []byte      `json:"aad,omitempty"` // Associated data bound to the ciphertext, sent in the clear
+	Recipients []Recipient `json:"r,omitempty"`   // Wrapped content keys (AlgEd25519X25519 only)
+	Nonce      []byte      `json:"n"`             // Nonce for AES-GCM (12 bytes)
+	Ciphertext []byte      `json:"c"`             // Encrypted original data (JSON of Context/ContextUpdate)
+	Signature  []byte      `json:"s"`             // Signature over the payload, see signingInput
+}
+
+// algorithm returns the payload's algorithm, treating an empty one as the legacy HMAC mode.
+func (p SecuredPayload) algorithm() string {
+	if p.Algorithm == "" {
+		return AlgHMACSHA256
+	}
+	return p.Algorithm
+}
+
+// signingInput returns the bytes covered by the payload's signature. Legacy payloads
+// sign Nonce + Ciphertext; later versions sign the whole payload (with an empty
+// signature) so the version, algorithm and recipients cannot be altered either.
+func (p SecuredPayload) signingInput() ([]byte, error) {
+	switch p.Version {
+	case PayloadVersionLegacy:
+		dataToSign := append([]byte{}, p.Nonce...)
+		return append(dataToSign, p.Ciphertext...), nil
+	case PayloadVersion1:
+		p.Signature = nil
+		return json.Marshal(p)
+	default:
+		return nil, fmt.Errorf("%w: version %d", ErrUnsupportedAlgorithm, p.Version)
+	}
+}
+
+// newPayload creates a version 1 payload header with a fresh issued-at time and message ID.
+func newPayload(alg, keyID string, opts []SecureOption) SecuredPayload {
+	o := newSecureOptions(opts)
+	return SecuredPayload{
+		Version:   PayloadVersion1,
+		Algorithm: alg,
+		KeyID:     keyID,
+		IssuedAt:  time.Now().Unix(),
+		MessageID: uuid.NewString(),
+		Audience:  o.audience,
+	}
+}
+
+// parsePayload unmarshals a secured payload and checks that it is complete.
+func parsePayload(securedData []byte) (SecuredPayload, error) {
+	var payload SecuredPayload
+	if len(securedData) == 0 {
+		return payload, fmt.Errorf("%w: input securedData cannot be empty", ErrInvalidInput)
+	}
+	if err := json.Unmarshal(securedData, &payload); err != nil {
+		return payload, fmt.Errorf("%w: failed to unmarshal secured payload: %w", ErrInvalidInput, err)
+	}
+	if payload.Nonce == nil || len(payload.Nonce) != NonceSize || payload.Ciphertext == nil || payload.Signature == nil {
+		return payload, fmt.Errorf("%w: incomplete secured payload structure", ErrInvalidInput)
+	}
+	return payload, nil
 }
 
-// encrypt encrypts plaintext using AES-GCM with the given key.
-// It generates a random nonce suitable for GCM.
-func encrypt(plaintext []byte, key []byte) (nonce, ciphertext []byte, err error) {
+// encrypt encrypts plaintext using AES-GCM with the given key, authenticating
+// additionalData alongside it. It generates a random nonce suitable for GCM.
+func encrypt(plaintext []byte, key []byte, additionalData []byte) (nonce, ciphertext []byte, err error) {
 	if len(key) != AesKeySize {
 		return nil, nil, fmt.Errorf("%w: expected %d bytes for AES key", ErrInvalidKey, AesKeySize)
 	}
@@ -62,17 +143,16 @@ func encrypt(plaintext []byte, key []byte) (nonce, ciphertext []byte, err error)
 		return nil, nil, fmt.Errorf("failed to generate nonce: %w", err)
 	}
 
-	// Seal encrypts and authenticates plaintext. Nonce is unique for key & plaintext.
-	// We pass nil for additionalData as GCM's tag already covers the ciphertext.
+	// Seal encrypts and authenticates plaintext and additionalData. Nonce is unique for key & plaintext.
 	// The nonce is returned separately to be stored alongside the ciphertext.
-	ciphertext = gcm.Seal(nil, nonce, plaintext, nil)
+	ciphertext = gcm.Seal(nil, nonce, plaintext, additionalData)
 
 	return nonce, ciphertext, nil
 }
 
 // decrypt decrypts ciphertext using AES-GCM with the given key and nonce.
-// It also verifies the GCM authenticity tag.
-func decrypt(nonce, ciphertext []byte, key []byte) (plaintext []byte, err error) {
+// It also verifies the GCM authenticity tag over the ciphertext and additionalData.
+func decrypt(nonce, ciphertext []byte, key []byte, additionalData []byte) (plaintext []byte, err error) {
 	if len(key) != AesKeySize {
 		return nil, fmt.Errorf("%w: expected %d bytes for AES key", ErrInvalidKey, AesKeySize)
 	}
@@ -91,7 +171,7 @@ func decrypt(nonce, ciphertext []byte, key []byte) (plaintext []byte, err error)
 	}
 
 	// Open decrypts and authenticates ciphertext. If the nonce or tag is invalid, it returns an error.
-	plaintext, err = gcm.Open(nil, nonce, ciphertext, nil)
+	plaintext, err = gcm.Open(nil, nonce, ciphertext, additionalData)
 	if err != nil {
 		// This error often means the data was tampered with or the wrong key/nonce was used.
 		return nil, fmt.Errorf("%w: %w", ErrDecryptionFailed, err)
@@ -134,7 +214,20 @@ func verifyHMAC(data, receivedSignature []byte, key []byte) error {
 // Secure marshals the input data, encrypts it, signs the result,
 // and packages it into a SecuredPayload, returning the marshalled payload bytes.
 // Input 'data' should be a pointer to your Context or ContextUpdate struct.
-func Secure(data any, encryptionKey, signingKey []byte) ([]byte, error) {
+func Secure(data any, encryptionKey, signingKey []byte, opts ...SecureOption) ([]byte, error) {
+	return secureHMAC(data, encryptionKey, signingKey, "", nil, opts)
+}
+
+// SecureWithAAD works like Secure and binds the associated data, e.g. a context ID,
+// update sequence and recipient, to the payload. The associated data is sent in the
+// clear and covered by both the GCM tag and the HMAC, so the payload only opens with
+// ValidateAndOpenWithAAD given the same associated data.
+func SecureWithAAD(data any, encryptionKey, signingKey, aad []byte, opts ...SecureOption) ([]byte, error) {
+	return secureHMAC(data, encryptionKey, signingKey, "", aad, opts)
+}
+
+// secureHMAC implements Secure, recording keyID and aad in the payload if they are set.
+func secureHMAC(data any, encryptionKey, signingKey []byte, keyID string, aad []byte, opts []SecureOption) ([]byte, error) {
 	// 1. Marshal the original data structure to JSON
 	plaintext, err := json.Marshal(data)
 	if err != nil {
@@ -142,25 +235,26 @@ func Secure(data any, encryptionKey, signingKey []byte) ([]byte, error) {
 	}
 
 	// 2. Encrypt the JSON data
-	nonce, ciphertext, err := encrypt(plaintext, encryptionKey)
+	nonce, ciphertext, err := encrypt(plaintext, encryptionKey, aad)
 	if err != nil {
 		return nil, fmt.Errorf("encryption failed: %w", err)
 	}
 
-	// 3. Sign the Nonce + Ciphertext combination
-	// Signing both ensures that neither can be replaced independently.
-	dataToSign := append([]byte{}, nonce...)
-	dataToSign = append(dataToSign, ciphertext...)
-	signature, err := signHMAC(dataToSign, signingKey)
+	// 3. Create the secured payload structure
+	payload := newPayload(AlgHMACSHA256, keyID, opts)
+	payload.AAD = aad
+	payload.Nonce = nonce
+	payload.Ciphertext = ciphertext
+
+	// 4. Sign the payload, covering the Nonce + Ciphertext combination and its header.
+	// Signing everything ensures that no part can be replaced independently.
+	dataToSign, err := payload.signingInput()
 	if err != nil {
 		return nil, fmt.Errorf("signing failed: %w", err)
 	}
-
-	// 4. Create the secured payload structure
-	payload := SecuredPayload{
-		Nonce:      nonce,
-		Ciphertext: ciphertext,
-		Signature:  signature,
+	payload.Signature, err = signHMAC(dataToSign, signingKey)
+	if err != nil {
+		return nil, fmt.Errorf("signing failed: %w", err)
 	}
 
 	// 5. Marshal the secured payload for transport
@@ -176,7 +270,17 @@ func Secure(data any, encryptionKey, signingKey []byte) ([]byte, error) {
 // and unmarshals the original data structure into the 'target' pointer.
 // 'securedData' is the raw bytes received from transport (marshalled SecuredPayload).
 // 'target' must be a pointer to the expected struct type (e.g., *mcp.Context).
-func ValidateAndOpen(securedData []byte, encryptionKey, signingKey []byte, target any) error {
+//
+// Both legacy (unversioned) payloads and version 1 payloads using AlgHMACSHA256 are
+// accepted. Payloads sealed with public keys must be opened with OpenAsymmetric.
+func ValidateAndOpen(securedData []byte, encryptionKey, signingKey []byte, target any, opts ...OpenOption) error {
+	return ValidateAndOpenWithAAD(securedData, encryptionKey, signingKey, nil, target, opts...)
+}
+
+// ValidateAndOpenWithAAD works like ValidateAndOpen for payloads created by
+// SecureWithAAD. The payload must carry exactly the expected associated data;
+// payloads bound to other data fail with ErrAADMismatch.
+func ValidateAndOpenWithAAD(securedData []byte, encryptionKey, signingKey, aad []byte, target any, opts ...OpenOption) error {
 	if len(securedData) == 0 {
 		return fmt.Errorf("%w: input securedData cannot be empty", ErrInvalidInput)
 	}
@@ -184,20 +288,24 @@ func ValidateAndOpen(securedData []byte, encryptionKey, signingKey []byte, targe
 		return errors.New("target interface cannot be nil")
 	}
 
-	// 1. Unmarshal the secured payload structure
-	var payload SecuredPayload
-	if err := json.Unmarshal(securedData, &payload); err != nil {
-		return fmt.Errorf("%w: failed to unmarshal secured payload: %w", ErrInvalidInput, err)
+	// 1. Unmarshal the secured payload structure and check its content
+	payload, err := parsePayload(securedData)
+	if err != nil {
+		return err
 	}
-
-	// Basic checks on payload content
-	if payload.Nonce == nil || len(payload.Nonce) != NonceSize || payload.Ciphertext == nil || payload.Signature == nil {
-		return fmt.Errorf("%w: incomplete secured payload structure", ErrInvalidInput)
+	if alg := payload.algorithm(); alg != AlgHMACSHA256 {
+		return fmt.Errorf("%w: '%s' requires OpenAsymmetric", ErrUnsupportedAlgorithm, alg)
 	}
+	return openHMAC(payload, encryptionKey, signingKey, aad, target, opts)
+}
 
-	// 2. Verify the HMAC signature (Nonce + Ciphertext)
-	dataToCheck := append([]byte{}, payload.Nonce...)
-	dataToCheck = append(dataToCheck, payload.Ciphertext...)
+// openHMAC implements ValidateAndOpenWithAAD for a parsed payload.
+func openHMAC(payload SecuredPayload, encryptionKey, signingKey, aad []byte, target any, opts []OpenOption) error {
+	// 2. Verify the HMAC signature
+	dataToCheck, err := payload.signingInput()
+	if err != nil {
+		return err
+	}
 	if err := verifyHMAC(dataToCheck, payload.Signature, signingKey); err != nil {
 		// Authentication failed! Do not proceed.
 		return fmt.Errorf("signature verification failed: %w", err) // err is ErrAuthenticationFailed
@@ -205,8 +313,21 @@ func ValidateAndOpen(securedData []byte, encryptionKey, signingKey []byte, targe
 
 	// --- Signature Verified ---
 
+	// The signature covers the transported associated data; it must also be the data
+	// the caller expects, otherwise a payload could be moved to another slot.
+	if !hmac.Equal(payload.AAD, aad) {
+		return fmt.Errorf("%w: %w", ErrAuthenticationFailed, ErrAADMismatch)
+	}
+
+	// Replay checks run after verification so forged payloads cannot fill the nonce store.
+	if guard := newOpenOptions(opts).guard; guard != nil {
+		if err := guard.Check(payload); err != nil {
+			return err
+		}
+	}
+
 	// 3. Decrypt the ciphertext
-	plaintext, err := decrypt(payload.Nonce, payload.Ciphertext, encryptionKey)
+	plaintext, err := decrypt(payload.Nonce, payload.Ciphertext, encryptionKey, payload.AAD)
 	if err != nil {
 		// Decryption or GCM auth tag check failed!
 		return fmt.Errorf("decryption failed: %w", err) // err includes ErrDecryptionFailed
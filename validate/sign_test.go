@@ -33,57 +33,57 @@ func TestEncryptDecrypt(t *testing.T) {
 	plaintext := []byte("this is a secret message")
 
 	t.Run("Success Round Trip", func(t *testing.T) {
-		nonce, ciphertext, err := encrypt(plaintext, key)
+		nonce, ciphertext, err := encrypt(plaintext, key, nil)
 		require.NoError(t, err)
 		require.NotNil(t, nonce)
 		require.NotNil(t, ciphertext)
 		assert.Len(t, nonce, NonceSize)
 		assert.NotEqual(t, plaintext, ciphertext) // Ciphertext shouldn't be plaintext
 
-		decrypted, err := decrypt(nonce, ciphertext, key)
+		decrypted, err := decrypt(nonce, ciphertext, key, nil)
 		require.NoError(t, err)
 		assert.Equal(t, plaintext, decrypted, "Decrypted text should match original")
 	})
 
 	t.Run("Fail Incorrect Key Size Encrypt", func(t *testing.T) {
 		badKey := []byte{1, 2, 3}
-		_, _, err := encrypt(plaintext, badKey)
+		_, _, err := encrypt(plaintext, badKey, nil)
 		require.Error(t, err)
 		assert.ErrorIs(t, err, ErrInvalidKey)
 	})
 
 	t.Run("Fail Incorrect Key Size Decrypt", func(t *testing.T) {
-		nonce, ciphertext, err := encrypt(plaintext, key) // Encrypt with good key
+		nonce, ciphertext, err := encrypt(plaintext, key, nil) // Encrypt with good key
 		require.NoError(t, err)
 
 		badKey := []byte{1, 2, 3}
-		_, err = decrypt(nonce, ciphertext, badKey)
+		_, err = decrypt(nonce, ciphertext, badKey, nil)
 		require.Error(t, err)
 		assert.ErrorIs(t, err, ErrInvalidKey)
 	})
 
 	t.Run("Fail Incorrect Nonce Size Decrypt", func(t *testing.T) {
-		_, ciphertext, err := encrypt(plaintext, key)
+		_, ciphertext, err := encrypt(plaintext, key, nil)
 		require.NoError(t, err)
 
 		badNonce := []byte{1, 2, 3} // Too short
-		_, err = decrypt(badNonce, ciphertext, key)
+		_, err = decrypt(badNonce, ciphertext, key, nil)
 		require.Error(t, err)
 		assert.ErrorIs(t, err, ErrInvalidInput) // Error indicates invalid input due to nonce size
 	})
 
 	t.Run("Fail Incorrect Key Decrypt", func(t *testing.T) {
-		nonce, ciphertext, err := encrypt(plaintext, key)
+		nonce, ciphertext, err := encrypt(plaintext, key, nil)
 		require.NoError(t, err)
 
 		wrongKey := mustGenerateKey(t, AesKeySize)
-		_, err = decrypt(nonce, ciphertext, wrongKey)
+		_, err = decrypt(nonce, ciphertext, wrongKey, nil)
 		require.Error(t, err)
 		assert.ErrorIs(t, err, ErrDecryptionFailed, "Expected decryption failure with wrong key")
 	})
 
 	t.Run("Fail Tampered Ciphertext Decrypt", func(t *testing.T) {
-		nonce, ciphertext, err := encrypt(plaintext, key)
+		nonce, ciphertext, err := encrypt(plaintext, key, nil)
 		require.NoError(t, err)
 
 		// Tamper with ciphertext (GCM includes auth tag at the end)
@@ -93,7 +93,7 @@ func TestEncryptDecrypt(t *testing.T) {
 			t.Skip("Ciphertext too short to tamper")
 		}
 
-		_, err = decrypt(nonce, ciphertext, key)
+		_, err = decrypt(nonce, ciphertext, key, nil)
 		require.Error(t, err)
 		assert.ErrorIs(t, err, ErrDecryptionFailed, "Expected decryption failure with tampered ciphertext")
 	})
@@ -373,3 +373,57 @@ func TestSecureAndValidateOpen(t *testing.T) {
 		assert.Contains(t, err.Error(), "failed to marshal input data")
 	})
 }
+
+func TestSecureWithAAD(t *testing.T) {
+	encKey := mustGenerateKey(t, AesKeySize)
+	signKey := mustGenerateKey(t, HmacKeySize)
+	originalData := testPayload{Name: "Alice", Age: 30}
+	aad := []byte("context=ctx-1;seq=7;recipient=server-a")
+
+	securedBytes, err := SecureWithAAD(&originalData, encKey, signKey, aad)
+	require.NoError(t, err)
+
+	t.Run("Success Round Trip", func(t *testing.T) {
+		var payload SecuredPayload
+		require.NoError(t, json.Unmarshal(securedBytes, &payload))
+		assert.Equal(t, aad, payload.AAD, "AAD should be transported in the clear")
+
+		var recoveredData testPayload
+		require.NoError(t, ValidateAndOpenWithAAD(securedBytes, encKey, signKey, aad, &recoveredData))
+		assert.Equal(t, originalData, recoveredData)
+	})
+
+	t.Run("Fail Different AAD", func(t *testing.T) {
+		var recoveredData testPayload
+		err := ValidateAndOpenWithAAD(securedBytes, encKey, signKey, []byte("context=ctx-2;seq=7;recipient=server-a"), &recoveredData)
+		assert.ErrorIs(t, err, ErrAADMismatch)
+		assert.ErrorIs(t, err, ErrAuthenticationFailed)
+	})
+
+	t.Run("Fail Without AAD", func(t *testing.T) {
+		var recoveredData testPayload
+		assert.ErrorIs(t, ValidateAndOpen(securedBytes, encKey, signKey, &recoveredData), ErrAADMismatch)
+
+		plain, err := Secure(&originalData, encKey, signKey)
+		require.NoError(t, err)
+		assert.ErrorIs(t, ValidateAndOpenWithAAD(plain, encKey, signKey, aad, &recoveredData), ErrAADMismatch)
+	})
+
+	t.Run("Fail Tampered AAD", func(t *testing.T) {
+		var payload SecuredPayload
+		require.NoError(t, json.Unmarshal(securedBytes, &payload))
+		payload.AAD = []byte("context=ctx-2;seq=7;recipient=server-a")
+		tampered, err := json.Marshal(payload)
+		require.NoError(t, err)
+
+		var recoveredData testPayload
+		assert.ErrorIs(t, ValidateAndOpenWithAAD(tampered, encKey, signKey, payload.AAD, &recoveredData), ErrAuthenticationFailed)
+	})
+
+	t.Run("GCM Tag Covers AAD", func(t *testing.T) {
+		var payload SecuredPayload
+		require.NoError(t, json.Unmarshal(securedBytes, &payload))
+		_, err := decrypt(payload.Nonce, payload.Ciphertext, encKey, nil)
+		assert.ErrorIs(t, err, ErrDecryptionFailed)
+	})
+}
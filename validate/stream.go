@@ -0,0 +1,266 @@
+package validate
+
+import (
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+// ErrStreamTruncated indicates an encrypted stream that ended before its final chunk.
+var ErrStreamTruncated = errors.New("encrypted stream is truncated")
+
+const (
+	// StreamChunkSize is the plaintext size of every chunk except the last.
+	StreamChunkSize = 64 * 1024
+
+	streamVersion  = 1
+	streamSaltSize = 16
+	streamKeyInfo  = "gomcp stream v1"
+	// streamTagSize is the GCM tag added to each chunk.
+	streamTagSize = 16
+	// lastChunkFlag is set in the final byte of the nonce of the last chunk.
+	lastChunkFlag = 0x01
+)
+
+// The stream format follows the STREAM construction:
+//
+//	version (1 byte) || salt (16 bytes) || chunk_0 || ... || chunk_n
+//
+// Each chunk is sealed with AES-256-GCM under a key derived from the caller's key and
+// the random salt. Its nonce is an 11 byte big-endian chunk counter followed by a flag
+// byte that is set only for the last chunk. Every chunk except the last holds exactly
+// StreamChunkSize bytes of plaintext, and the last holds less (possibly nothing), so
+// readers know where the stream ends. Reordered chunks fail because of the counter and
+// a stream cut at any point fails because no chunk carrying the final flag is found.
+
+// streamNonce tracks the counter of a stream and builds chunk nonces from it.
+type streamNonce [NonceSize]byte
+
+func (n *streamNonce) next(last bool) ([]byte, error) {
+	nonce := *n
+	if last {
+		nonce[NonceSize-1] = lastChunkFlag
+	}
+	// Increment the counter, which occupies every byte but the flag.
+	for i := NonceSize - 2; i >= 0; i-- {
+		n[i]++
+		if n[i] != 0 {
+			return nonce[:], nil
+		}
+	}
+	return nil, errors.New("stream chunk counter overflow")
+}
+
+func newStreamCipher(key, salt []byte) (cipher.AEAD, error) {
+	if len(key) != AesKeySize {
+		return nil, fmt.Errorf("%w: expected %d bytes for AES key", ErrInvalidKey, AesKeySize)
+	}
+	streamKey := make([]byte, AesKeySize)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, key, salt, []byte(streamKeyInfo)), streamKey); err != nil {
+		return nil, fmt.Errorf("key derivation failed: %w", err)
+	}
+	return newGCM(streamKey)
+}
+
+// StreamWriter encrypts everything written to it in fixed-size chunks. Close must be
+// called to write the final chunk; without it readers reject the stream as truncated.
+type StreamWriter struct {
+	dst    io.Writer
+	aead   cipher.AEAD
+	nonce  streamNonce
+	buf    []byte
+	closed bool
+}
+
+// NewStreamWriter writes the stream header to dst and returns a writer that encrypts
+// with a key derived from the 32 byte key.
+func NewStreamWriter(dst io.Writer, key []byte) (*StreamWriter, error) {
+	header := make([]byte, 1+streamSaltSize)
+	header[0] = streamVersion
+	if _, err := io.ReadFull(rand.Reader, header[1:]); err != nil {
+		return nil, fmt.Errorf("failed to generate salt: %w", err)
+	}
+	aead, err := newStreamCipher(key, header[1:])
+	if err != nil {
+		return nil, err
+	}
+	if _, err := dst.Write(header); err != nil {
+		return nil, fmt.Errorf("failed to write stream header: %w", err)
+	}
+	return &StreamWriter{
+		dst:  dst,
+		aead: aead,
+		buf:  make([]byte, 0, StreamChunkSize),
+	}, nil
+}
+
+// Write buffers p and writes every full chunk. A full buffer is only flushed once more
+// data arrives, because the last chunk must be shorter than StreamChunkSize.
+func (w *StreamWriter) Write(p []byte) (int, error) {
+	if w.closed {
+		return 0, errors.New("write to closed stream")
+	}
+	n := 0
+	for len(p) > 0 {
+		if len(w.buf) == StreamChunkSize {
+			if err := w.flush(false); err != nil {
+				return n, err
+			}
+		}
+		k := copy(w.buf[len(w.buf):StreamChunkSize], p)
+		w.buf = w.buf[:len(w.buf)+k]
+		p = p[k:]
+		n += k
+	}
+	return n, nil
+}
+
+// Close writes the final chunk. It does not close the underlying writer.
+func (w *StreamWriter) Close() error {
+	if w.closed {
+		return nil
+	}
+	if len(w.buf) == StreamChunkSize {
+		if err := w.flush(false); err != nil {
+			return err
+		}
+	}
+	w.closed = true
+	return w.flush(true)
+}
+
+func (w *StreamWriter) flush(last bool) error {
+	nonce, err := w.nonce.next(last)
+	if err != nil {
+		return err
+	}
+	if _, err := w.dst.Write(w.aead.Seal(nil, nonce, w.buf, nil)); err != nil {
+		return fmt.Errorf("failed to write stream chunk: %w", err)
+	}
+	w.buf = w.buf[:0]
+	return nil
+}
+
+// StreamReader decrypts a stream written by StreamWriter. Each chunk is authenticated
+// before any of its plaintext is returned, but the stream as a whole is only known to
+// be complete once Read has returned io.EOF.
+type StreamReader struct {
+	src   io.Reader
+	aead  cipher.AEAD
+	nonce streamNonce
+	chunk []byte
+	plain []byte
+	done  bool
+}
+
+// NewStreamReader reads the stream header from src and returns a reader that
+// decrypts with a key derived from the 32 byte key.
+func NewStreamReader(src io.Reader, key []byte) (*StreamReader, error) {
+	header := make([]byte, 1+streamSaltSize)
+	if _, err := io.ReadFull(src, header); err != nil {
+		return nil, fmt.Errorf("%w: failed to read stream header: %w", ErrInvalidInput, err)
+	}
+	if header[0] != streamVersion {
+		return nil, fmt.Errorf("%w: stream version %d", ErrUnsupportedAlgorithm, header[0])
+	}
+	aead, err := newStreamCipher(key, header[1:])
+	if err != nil {
+		return nil, err
+	}
+	return &StreamReader{
+		src:   src,
+		aead:  aead,
+		chunk: make([]byte, StreamChunkSize+streamTagSize),
+	}, nil
+}
+
+func (r *StreamReader) Read(p []byte) (int, error) {
+	for len(r.plain) == 0 {
+		if r.done {
+			return 0, io.EOF
+		}
+		if err := r.next(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, r.plain)
+	r.plain = r.plain[n:]
+	return n, nil
+}
+
+// next reads and decrypts one chunk. A full-size chunk is never the last one.
+func (r *StreamReader) next() error {
+	n, err := io.ReadFull(r.src, r.chunk)
+	last := false
+	switch {
+	case err == nil:
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		last = true
+	case errors.Is(err, io.EOF):
+		return ErrStreamTruncated
+	default:
+		return fmt.Errorf("failed to read stream chunk: %w", err)
+	}
+	if n < streamTagSize {
+		return ErrStreamTruncated
+	}
+
+	nonce, err := r.nonce.next(last)
+	if err != nil {
+		return err
+	}
+	plain, err := r.aead.Open(r.chunk[:0], nonce, r.chunk[:n], nil)
+	if err != nil {
+		if last {
+			// A short chunk that fails as the final one was most likely cut off.
+			return fmt.Errorf("%w: %w", ErrDecryptionFailed, ErrStreamTruncated)
+		}
+		return fmt.Errorf("%w: chunk authentication failed: %w", ErrDecryptionFailed, err)
+	}
+	r.plain = plain
+	r.done = last
+	return nil
+}
+
+// SecureStream marshals data as JSON straight into an encrypted stream written to dst,
+// without holding the whole plaintext in memory.
+func SecureStream(dst io.Writer, data any, encryptionKey []byte) error {
+	w, err := NewStreamWriter(dst, encryptionKey)
+	if err != nil {
+		return err
+	}
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		return fmt.Errorf("failed to marshal input data: %w", err)
+	}
+	return w.Close()
+}
+
+// OpenStream decrypts a stream written by SecureStream and unmarshals it into
+// 'target', which must be a pointer. The whole stream is read and authenticated;
+// if that fails, target may already hold partially decoded data and must be discarded.
+func OpenStream(src io.Reader, encryptionKey []byte, target any) error {
+	if target == nil {
+		return errors.New("target interface cannot be nil")
+	}
+	r, err := NewStreamReader(src, encryptionKey)
+	if err != nil {
+		return err
+	}
+	if err := json.NewDecoder(r).Decode(target); err != nil {
+		if errors.Is(err, ErrDecryptionFailed) || errors.Is(err, ErrStreamTruncated) {
+			return err
+		}
+		return fmt.Errorf("failed to unmarshal decrypted data into target: %w", err)
+	}
+	// The decoder stops after the value; drain the rest so the final chunk is verified.
+	if _, err := io.Copy(io.Discard, r); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package validate
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func encryptStream(t *testing.T, key, plaintext []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w, err := NewStreamWriter(&buf, key)
+	require.NoError(t, err)
+	_, err = w.Write(plaintext)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func decryptStream(key, encrypted []byte) ([]byte, error) {
+	r, err := NewStreamReader(bytes.NewReader(encrypted), key)
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(r)
+}
+
+func TestStreamRoundTrip(t *testing.T) {
+	key := mustGenerateKey(t, AesKeySize)
+	sizes := []int{0, 1, StreamChunkSize - 1, StreamChunkSize, StreamChunkSize + 1, 3 * StreamChunkSize}
+	for _, size := range sizes {
+		plaintext := mustGenerateKey(t, size)
+		encrypted := encryptStream(t, key, plaintext)
+
+		decrypted, err := decryptStream(key, encrypted)
+		require.NoError(t, err, "size %d", size)
+		assert.Equal(t, plaintext, decrypted, "size %d", size)
+	}
+}
+
+func TestStreamTampering(t *testing.T) {
+	key := mustGenerateKey(t, AesKeySize)
+	plaintext := mustGenerateKey(t, 3*StreamChunkSize+100)
+	encrypted := encryptStream(t, key, plaintext)
+	header := 1 + streamSaltSize
+	chunk := StreamChunkSize + streamTagSize
+
+	t.Run("Truncated At Chunk Boundary", func(t *testing.T) {
+		_, err := decryptStream(key, encrypted[:header+2*chunk])
+		assert.ErrorIs(t, err, ErrStreamTruncated)
+	})
+
+	t.Run("Truncated Mid Chunk", func(t *testing.T) {
+		_, err := decryptStream(key, encrypted[:header+chunk+1000])
+		assert.ErrorIs(t, err, ErrStreamTruncated)
+	})
+
+	t.Run("Header Only", func(t *testing.T) {
+		_, err := decryptStream(key, encrypted[:header])
+		assert.ErrorIs(t, err, ErrStreamTruncated)
+	})
+
+	t.Run("Reordered Chunks", func(t *testing.T) {
+		reordered := append([]byte{}, encrypted[:header]...)
+		reordered = append(reordered, encrypted[header+chunk:header+2*chunk]...)
+		reordered = append(reordered, encrypted[header:header+chunk]...)
+		reordered = append(reordered, encrypted[header+2*chunk:]...)
+		_, err := decryptStream(key, reordered)
+		assert.ErrorIs(t, err, ErrDecryptionFailed)
+	})
+
+	t.Run("Flipped Bit", func(t *testing.T) {
+		tampered := append([]byte{}, encrypted...)
+		tampered[header+chunk+10] ^= 0x01
+		_, err := decryptStream(key, tampered)
+		assert.ErrorIs(t, err, ErrDecryptionFailed)
+	})
+
+	t.Run("Wrong Key", func(t *testing.T) {
+		_, err := decryptStream(mustGenerateKey(t, AesKeySize), encrypted)
+		assert.ErrorIs(t, err, ErrDecryptionFailed)
+	})
+
+	t.Run("Unknown Version", func(t *testing.T) {
+		tampered := append([]byte{}, encrypted...)
+		tampered[0] = 99
+		_, err := decryptStream(key, tampered)
+		assert.ErrorIs(t, err, ErrUnsupportedAlgorithm)
+	})
+}
+
+func TestSecureStream(t *testing.T) {
+	key := mustGenerateKey(t, AesKeySize)
+	original := map[string]string{"output": strings.Repeat("large tool output ", 20_000)}
+
+	var buf bytes.Buffer
+	require.NoError(t, SecureStream(&buf, original, key))
+	encrypted := buf.Bytes()
+
+	var recovered map[string]string
+	require.NoError(t, OpenStream(bytes.NewReader(encrypted), key, &recovered))
+	assert.Equal(t, original, recovered)
+
+	// Dropping only the final chunk leaves a decodable prefix that must still be rejected.
+	lastStart := len(encrypted) - (len(encrypted)-1-streamSaltSize)%(StreamChunkSize+streamTagSize)
+	var partial map[string]string
+	assert.ErrorIs(t, OpenStream(bytes.NewReader(encrypted[:lastStart]), key, &partial), ErrStreamTruncated)
+}
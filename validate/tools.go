@@ -2,6 +2,7 @@ package validate // Or your orchestrator package
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"strings"
@@ -12,13 +13,15 @@ import (
 )
 
 // FindToolDescription retrieves the trusted tool description by name.
-// In a real system, this might involve looking up in a secure registry
-// and potentially verifying signatures/sources stored in SecurityMetadata.
+// Tools that record an IntegrityHash in their SecurityMetadata are refused if their
+// contents no longer match it. Use a ToolVerifier to also check signatures.
 func FindToolDescription(name string, availableTools []msg.ToolDescription) (*msg.ToolDescription, error) {
 	for _, tool := range availableTools {
 		if tool.Name == name {
-			// TODO: Add verification of tool description source/integrity here
-			// based on tool.SecurityMetadata if available.
+			if err := checkIntegrity(tool); err != nil {
+				fmt.Println("SECURITY ALERT:", err) // Log prominently
+				return nil, err
+			}
 			return &tool, nil // Return pointer to avoid copying large schemas
 		}
 	}
@@ -117,3 +120,43 @@ func ValidateToolCallOutput(
 	}
 	return msg.StatusSucceeded, nil
 }
+
+// ValidateStructuredContent checks the structuredContent of a tools/call result
+// against the tool's OutputSchema. Tools that declare an OutputSchema must return
+// structured content conforming to it; tools without one may return any JSON object.
+func ValidateStructuredContent(structured json.RawMessage, toolDesc *msg.ToolDescription) (msg.ExecutionStatus, error) {
+	if len(structured) == 0 {
+		if len(toolDesc.OutputSchema) > 0 {
+			return msg.StatusFailed, fmt.Errorf("tool '%s' declares an OutputSchema but returned no structured content", toolDesc.Name)
+		}
+		return msg.StatusSucceeded, nil
+	}
+
+	var object map[string]any
+	if err := json.Unmarshal(structured, &object); err != nil {
+		return msg.StatusFailed, fmt.Errorf("structured content for tool '%s' must be a JSON object", toolDesc.Name)
+	}
+	if len(toolDesc.OutputSchema) == 0 {
+		return msg.StatusSucceeded, nil
+	}
+
+	outputSchema, err := gojsonschema.NewSchema(gojsonschema.NewBytesLoader(toolDesc.OutputSchema))
+	if err != nil {
+		return msg.StatusError, fmt.Errorf("internal output schema error for tool '%s'", toolDesc.Name)
+	}
+	outputResult, err := outputSchema.Validate(gojsonschema.NewBytesLoader(structured))
+	if err != nil {
+		return msg.StatusError, fmt.Errorf("internal output validation error for tool '%s'", toolDesc.Name)
+	}
+	if !outputResult.Valid() {
+		var validationErrors []string
+		for _, desc := range outputResult.Errors() {
+			validationErrors = append(validationErrors, fmt.Sprintf("- %s", desc))
+		}
+		errorMsg := fmt.Sprintf("structured content for tool '%s' failed validation:\n%s",
+			toolDesc.Name, strings.Join(validationErrors, "\n"))
+		fmt.Println("SECURITY ALERT:", errorMsg) // Log prominently
+		return msg.StatusFailed, errors.New(errorMsg)
+	}
+	return msg.StatusSucceeded, nil
+}
@@ -313,3 +313,85 @@ func TestValidateToolCallOutput(t *testing.T) {
 		})
 	}
 }
+
+// --- Tests for ValidateStructuredContent ---
+
+func TestValidateStructuredContent(t *testing.T) {
+	weather := &availableToolsFixture[0]
+	noSchema := &availableToolsFixture[2]
+	badSchema := &availableToolsFixture[4]
+
+	tests := []struct {
+		name           string
+		structured     json.RawMessage
+		tool           *msg.ToolDescription
+		expectedStatus msg.ExecutionStatus
+		expectError    bool
+		errorContains  string
+	}{
+		{
+			name:           "Valid Structured Content",
+			structured:     json.RawMessage(`{"temperature": 21.5, "conditions": "Clear"}`),
+			tool:           weather,
+			expectedStatus: msg.StatusSucceeded,
+		},
+		{
+			name:           "Schema Violation",
+			structured:     json.RawMessage(`{"temperature": "warm", "conditions": "Clear"}`),
+			tool:           weather,
+			expectedStatus: msg.StatusFailed,
+			expectError:    true,
+			errorContains:  "temperature: Invalid type",
+		},
+		{
+			name:           "Missing Structured Content With Schema",
+			tool:           weather,
+			expectedStatus: msg.StatusFailed,
+			expectError:    true,
+			errorContains:  "returned no structured content",
+		},
+		{
+			name:           "Not An Object",
+			structured:     json.RawMessage(`[1, 2, 3]`),
+			tool:           noSchema,
+			expectedStatus: msg.StatusFailed,
+			expectError:    true,
+			errorContains:  "must be a JSON object",
+		},
+		{
+			name:           "No Schema Accepts Any Object",
+			structured:     json.RawMessage(`{"anything": true}`),
+			tool:           noSchema,
+			expectedStatus: msg.StatusSucceeded,
+		},
+		{
+			name:           "No Schema And No Content",
+			tool:           noSchema,
+			expectedStatus: msg.StatusSucceeded,
+		},
+		{
+			name:           "Invalid Output Schema Syntax",
+			structured:     json.RawMessage(`{"temperature": 20}`),
+			tool:           badSchema,
+			expectedStatus: msg.StatusError,
+			expectError:    true,
+			errorContains:  "internal output schema error",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			status, err := ValidateStructuredContent(tc.structured, tc.tool)
+
+			assert.Equal(t, tc.expectedStatus, status, "Status mismatch")
+			if tc.expectError {
+				require.Error(t, err, "Expected an error but got nil")
+				if tc.errorContains != "" {
+					assert.Contains(t, err.Error(), tc.errorContains, "Error message mismatch")
+				}
+			} else {
+				assert.NoError(t, err, "Expected no error but got one")
+			}
+		})
+	}
+}
@@ -0,0 +1,219 @@
+package validate
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	msg "github.com/gomcp/types"
+)
+
+var (
+	// ErrToolUnsigned indicates a tool description without a signature where one is required.
+	ErrToolUnsigned = errors.New("tool description is not signed")
+	// ErrToolTampered indicates a tool description that no longer matches its integrity hash or signature.
+	ErrToolTampered = errors.New("tool description has been tampered with")
+	// ErrUnknownSigningKey indicates the PublicKeyID of a signed tool could not be resolved.
+	ErrUnknownSigningKey = errors.New("unknown tool signing key")
+	// ErrUnsupportedKey indicates a key type that cannot sign or verify tool descriptions.
+	ErrUnsupportedKey = errors.New("unsupported key type: expected Ed25519 or ECDSA")
+)
+
+// KeyResolver looks up the public key identified by a SecurityMetadata.PublicKeyID.
+type KeyResolver interface {
+	PublicKey(keyID string) (crypto.PublicKey, error)
+}
+
+// StaticKeys is a KeyResolver backed by a fixed set of Ed25519 or ECDSA public keys.
+type StaticKeys map[string]crypto.PublicKey
+
+func (k StaticKeys) PublicKey(keyID string) (crypto.PublicKey, error) {
+	key, ok := k[keyID]
+	if !ok {
+		return nil, fmt.Errorf("%w: '%s'", ErrUnknownSigningKey, keyID)
+	}
+	return key, nil
+}
+
+// CanonicalToolBytes returns the canonical serialization of a tool description used
+// for hashing and signing: compact JSON with object keys sorted at every level.
+// The Signature and IntegrityHash fields of the tool's SecurityMetadata are excluded;
+// its Source, PublicKeyID and Version are covered so they cannot be swapped.
+func CanonicalToolBytes(tool msg.ToolDescription) ([]byte, error) {
+	if tool.Security != nil {
+		security := *tool.Security
+		security.Signature = ""
+		security.IntegrityHash = ""
+		tool.Security = &security
+	}
+	raw, err := json.Marshal(tool)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal tool description: %w", err)
+	}
+
+	// Round trip through generic values so embedded schemas are normalized too.
+	// encoding/json writes map keys in sorted order; UseNumber keeps numbers exact.
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var generic any
+	if err := dec.Decode(&generic); err != nil {
+		return nil, fmt.Errorf("failed to normalize tool description: %w", err)
+	}
+	return json.Marshal(generic)
+}
+
+// ToolIntegrityHash returns the hex encoded SHA-256 hash of the tool's canonical serialization.
+func ToolIntegrityHash(tool msg.ToolDescription) (string, error) {
+	canonical, err := CanonicalToolBytes(tool)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(canonical)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// SignTool sets the tool's PublicKeyID, IntegrityHash and Signature using an
+// Ed25519 or ECDSA private key. Any existing Source and Version are kept and signed.
+func SignTool(tool *msg.ToolDescription, keyID string, signer crypto.Signer) error {
+	if tool.Security == nil {
+		tool.Security = &msg.SecurityMetadata{}
+	}
+	tool.Security.PublicKeyID = keyID
+
+	canonical, err := CanonicalToolBytes(*tool)
+	if err != nil {
+		return err
+	}
+	var signature []byte
+	switch signer.Public().(type) {
+	case ed25519.PublicKey:
+		signature, err = signer.Sign(rand.Reader, canonical, crypto.Hash(0))
+	case *ecdsa.PublicKey:
+		digest := sha256.Sum256(canonical)
+		signature, err = signer.Sign(rand.Reader, digest[:], crypto.SHA256)
+	default:
+		return ErrUnsupportedKey
+	}
+	if err != nil {
+		return fmt.Errorf("failed to sign tool '%s': %w", tool.Name, err)
+	}
+
+	sum := sha256.Sum256(canonical)
+	tool.Security.IntegrityHash = hex.EncodeToString(sum[:])
+	tool.Security.Signature = base64.StdEncoding.EncodeToString(signature)
+	return nil
+}
+
+// VerifyTool checks the tool's IntegrityHash and verifies its Signature with the
+// key resolved from its PublicKeyID.
+func VerifyTool(tool msg.ToolDescription, keys KeyResolver) error {
+	if tool.Security == nil || tool.Security.Signature == "" {
+		return fmt.Errorf("%w: '%s'", ErrToolUnsigned, tool.Name)
+	}
+	if err := checkIntegrity(tool); err != nil {
+		return err
+	}
+	canonical, err := CanonicalToolBytes(tool)
+	if err != nil {
+		return err
+	}
+	if keys == nil {
+		return fmt.Errorf("%w: no keys configured to verify tool '%s'", ErrUnknownSigningKey, tool.Name)
+	}
+	key, err := keys.PublicKey(tool.Security.PublicKeyID)
+	if err != nil {
+		return fmt.Errorf("cannot verify tool '%s': %w", tool.Name, err)
+	}
+	signature, err := base64.StdEncoding.DecodeString(tool.Security.Signature)
+	if err != nil {
+		return fmt.Errorf("%w: malformed signature on tool '%s'", ErrToolTampered, tool.Name)
+	}
+
+	var valid bool
+	switch pub := key.(type) {
+	case ed25519.PublicKey:
+		valid = ed25519.Verify(pub, canonical, signature)
+	case *ecdsa.PublicKey:
+		digest := sha256.Sum256(canonical)
+		valid = ecdsa.VerifyASN1(pub, digest[:], signature)
+	default:
+		return ErrUnsupportedKey
+	}
+	if !valid {
+		return fmt.Errorf("%w: signature on tool '%s' does not verify", ErrToolTampered, tool.Name)
+	}
+	return nil
+}
+
+// checkIntegrity compares a recorded IntegrityHash against the tool's current
+// contents. Tools without an IntegrityHash pass unchecked.
+func checkIntegrity(tool msg.ToolDescription) error {
+	if tool.Security == nil || tool.Security.IntegrityHash == "" {
+		return nil
+	}
+	hash, err := ToolIntegrityHash(tool)
+	if err != nil {
+		return err
+	}
+	if hash != tool.Security.IntegrityHash {
+		return fmt.Errorf("%w: integrity hash mismatch for tool '%s'", ErrToolTampered, tool.Name)
+	}
+	return nil
+}
+
+// ToolVerifier looks up and validates tool descriptions like FindToolDescription and
+// ValidateToolSchema, additionally verifying their signatures. Signed tools are verified
+// whenever Keys is set; unsigned tools are refused when the policy sets RequireSignedTools.
+type ToolVerifier struct {
+	Keys   KeyResolver
+	Policy msg.SecurityPolicy
+}
+
+// NewToolVerifier creates a verifier for the given policy and signing keys.
+func NewToolVerifier(policy msg.SecurityPolicy, keys KeyResolver) *ToolVerifier {
+	return &ToolVerifier{Keys: keys, Policy: policy}
+}
+
+// Verify checks a single tool description.
+func (v *ToolVerifier) Verify(tool msg.ToolDescription) error {
+	signed := tool.Security != nil && tool.Security.Signature != ""
+	if !v.Policy.RequireSignedTools && (!signed || v.Keys == nil) {
+		return checkIntegrity(tool)
+	}
+	return VerifyTool(tool, v.Keys)
+}
+
+// FindToolDescription retrieves the tool description by name and verifies it.
+func (v *ToolVerifier) FindToolDescription(name string, availableTools []msg.ToolDescription) (*msg.ToolDescription, error) {
+	tool, err := FindToolDescription(name, availableTools)
+	if err != nil {
+		return nil, err
+	}
+	if err := v.Verify(*tool); err != nil {
+		fmt.Println("SECURITY ALERT:", err) // Log prominently
+		return nil, err
+	}
+	return tool, nil
+}
+
+// ValidateToolSchema verifies the requested tool before validating the call's arguments.
+func (v *ToolVerifier) ValidateToolSchema(
+	ctx context.Context,
+	toolCall msg.ToolCall,
+	availableTools []msg.ToolDescription,
+) (msg.ExecutionStatus, error) {
+	toolDesc, err := v.FindToolDescription(toolCall.FunctionName, availableTools)
+	if err != nil {
+		return msg.StatusFailed, fmt.Errorf("tool description verification failed: %w", err)
+	}
+	return ValidateToolSchema(ctx, toolCall, []msg.ToolDescription{*toolDesc})
+}